@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -23,6 +24,9 @@ type Config struct {
 	Name                   string              `mapstructure:"name" yaml:"name"`
 	Telegram               TelegramConfig      `mapstructure:"telegram" yaml:"telegram"`
 	Pushover               PushoverConfig      `mapstructure:"pushover" yaml:"pushover"`
+	Matrix                 MatrixConfig        `mapstructure:"matrix" yaml:"matrix"`
+	WebPush                WebPushConfig       `mapstructure:"webpush" yaml:"webpush"`
+	Notification           NotificationConfig  `mapstructure:"notification" yaml:"notification"`
 	Database               DatabaseConfig      `mapstructure:"database" yaml:"database"`
 	Jwt                    JwtConfig           `mapstructure:"jwt" yaml:"jwt"`
 	Server                 ServerConfig        `mapstructure:"server" yaml:"server"`
@@ -68,12 +72,35 @@ type DonetickCloudConfig struct {
 
 type TelegramConfig struct {
 	Token string `mapstructure:"token" yaml:"token"`
+	// APIEndpoint overrides the Bot API URL, e.g. a local Bot API server or a stand-in for testing
+	APIEndpoint string `mapstructure:"api_endpoint" yaml:"api_endpoint"`
+	// Interactive adds Done, Skip and Snooze buttons to reminders and polls for their presses
+	Interactive bool `mapstructure:"interactive" yaml:"interactive"`
 }
 
 type PushoverConfig struct {
 	Token string `mapstructure:"token" yaml:"token"`
 }
 
+type MatrixConfig struct {
+	Homeserver  string `mapstructure:"homeserver" yaml:"homeserver"`
+	AccessToken string `mapstructure:"access_token" yaml:"access_token"`
+}
+
+// WebPushConfig holds the VAPID identity used for browser push, when the keys are empty
+// a key pair is generated on first use and stored in the database
+type WebPushConfig struct {
+	Subject    string `mapstructure:"subject" yaml:"subject"`
+	PublicKey  string `mapstructure:"public_key" yaml:"public_key"`
+	PrivateKey string `mapstructure:"private_key" yaml:"private_key"`
+}
+
+type NotificationConfig struct {
+	// Providers lists the enabled notification providers by name (telegram, pushover, discord, email, ntfy, gotify, matrix, slack, webhook, webpush),
+	// when empty every provider that is configured is enabled
+	Providers []string `mapstructure:"providers" yaml:"providers"`
+}
+
 type DatabaseConfig struct {
 	Type      string `mapstructure:"type" yaml:"type"`
 	Host      string `mapstructure:"host" yaml:"host"`
@@ -99,12 +126,22 @@ type ServerConfig struct {
 	WriteTimeout     time.Duration `mapstructure:"write_timeout" yaml:"write_timeout"`
 	CorsAllowOrigins []string      `mapstructure:"cors_allow_origins" yaml:"cors_allow_origins"`
 	ServeFrontend    bool          `mapstructure:"serve_frontend" yaml:"serve_frontend"`
+	// PublicHost is the host the server is reached at, e.g. donetick.example.com or https://donetick.example.com
+	PublicHost string `mapstructure:"public_host" yaml:"public_host"`
 }
 
 type SchedulerConfig struct {
 	DueJob     time.Duration `mapstructure:"due_job" yaml:"due_job"`
 	OverdueJob time.Duration `mapstructure:"overdue_job" yaml:"overdue_job"`
 	PreDueJob  time.Duration `mapstructure:"pre_due_job" yaml:"pre_due_job"`
+	// NaggingInterval is how often an overdue chore is re-sent to its assignee
+	NaggingInterval time.Duration `mapstructure:"nagging_interval" yaml:"nagging_interval"`
+	// NaggingCutoff stops nagging once a chore has been overdue for this long
+	NaggingCutoff time.Duration `mapstructure:"nagging_cutoff" yaml:"nagging_cutoff"`
+	// DigestJob is how often users are checked for a digest that is due
+	DigestJob time.Duration `mapstructure:"digest_job" yaml:"digest_job"`
+	// EscalationJob is how often overdue chores are checked against their escalation policy
+	EscalationJob time.Duration `mapstructure:"escalation_job" yaml:"escalation_job"`
 }
 
 type StripeConfig struct {
@@ -140,8 +177,18 @@ type OAuth2Config struct {
 }
 
 type WebhookConfig struct {
-	Timeout   time.Duration `mapstructure:"timeout" yaml:"timeout" default:"5s"`
-	QueueSize int           `mapstructure:"queue_size" yaml:"queue_size" default:"100"`
+	Timeout time.Duration `mapstructure:"timeout" yaml:"timeout" default:"5s"`
+	// OutboxPollInterval is how often the dispatcher looks for events it wasn't woken up for
+	OutboxPollInterval time.Duration `mapstructure:"outbox_poll_interval" yaml:"outbox_poll_interval" default:"5s"`
+	OutboxBatchSize    int           `mapstructure:"outbox_batch_size" yaml:"outbox_batch_size" default:"100"`
+	// OutboxMaxAttempts is how many times the dispatcher tries an event before it's marked failed
+	OutboxMaxAttempts int `mapstructure:"outbox_max_attempts" yaml:"outbox_max_attempts" default:"10"`
+	// EventSource prefixes the CloudEvents source of the events, e.g. https://donetick.example.com. the
+	// public host of the server is used when it's empty
+	EventSource string `mapstructure:"event_source" yaml:"event_source"`
+	// DataSchemaBase is the URL the JSON schemas of the event data are published under, the dataschema of a
+	// CloudEvent is <base>/v<version>/<type>.json. https://donetick.com/schemas/webhooks when it's empty
+	DataSchemaBase string `mapstructure:"data_schema_base" yaml:"data_schema_base"`
 }
 
 type RealTimeConfig struct {
@@ -185,7 +232,8 @@ func NewConfig() *Config {
 
 	return &Config{
 		Telegram: TelegramConfig{
-			Token: "",
+			Token:       "",
+			Interactive: true,
 		},
 		Database: DatabaseConfig{
 			Type:      "sqlite",
@@ -226,6 +274,12 @@ func configEnvironmentOverrides(Config *Config) {
 	if os.Getenv("DONETICK_PUSHOVER_TOKEN") != "" {
 		Config.Pushover.Token = os.Getenv("DONETICK_PUSHOVER_TOKEN")
 	}
+	if os.Getenv("DONETICK_MATRIX_ACCESS_TOKEN") != "" {
+		Config.Matrix.AccessToken = os.Getenv("DONETICK_MATRIX_ACCESS_TOKEN")
+	}
+	if os.Getenv("DONETICK_WEBPUSH_PRIVATE_KEY") != "" {
+		Config.WebPush.PrivateKey = os.Getenv("DONETICK_WEBPUSH_PRIVATE_KEY")
+	}
 	if os.Getenv("DONETICK_DISABLE_SIGNUP") == "true" {
 		Config.IsUserCreationDisabled = true
 	}
@@ -370,3 +424,14 @@ func (c *LogConfig) ParseLogLevel() zapcore.Level {
 		return zapcore.InfoLevel
 	}
 }
+
+// IsProviderEnabled reports whether the notification provider with the given name is enabled,
+// every provider is enabled when none are listed
+func (n *NotificationConfig) IsProviderEnabled(name string) bool {
+	if len(n.Providers) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(n.Providers, func(e string) bool {
+		return strings.EqualFold(strings.TrimSpace(e), name)
+	})
+}
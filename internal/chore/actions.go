@@ -0,0 +1,330 @@
+package chore
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	"donetick.com/core/internal/events"
+	nps "donetick.com/core/internal/notifier/service"
+	"donetick.com/core/internal/realtime"
+	stRepo "donetick.com/core/internal/subtask/repo"
+	uModel "donetick.com/core/internal/user/model"
+	uRepo "donetick.com/core/internal/user/repo"
+	"donetick.com/core/internal/utils"
+	"donetick.com/core/logging"
+)
+
+// ActionError is a failed step of a chore action, Message is the reply the handlers have always sent for it
+type ActionError struct {
+	Message string
+	Err     error
+}
+
+func (e *ActionError) Error() string {
+	return e.Message + ": " + e.Err.Error()
+}
+
+func (e *ActionError) Unwrap() error {
+	return e.Err
+}
+
+// actionErrorMessage returns the reply for an error returned by Actions
+func actionErrorMessage(err error) string {
+	var actionErr *ActionError
+	if errors.As(err, &actionErr) {
+		return actionErr.Message
+	}
+	return "Error completing chore"
+}
+
+// Actions completes, skips and snoozes chores. the HTTP handlers and the Telegram bot share it so a
+// chore done from a reminder goes through the same scheduling, notifications and events
+type Actions struct {
+	choreRepo       *chRepo.ChoreRepository
+	userRepo        *uRepo.UserRepository
+	nPlanner        *nps.NotificationPlanner
+	eventProducer   *events.EventsProducer
+	stRepo          *stRepo.SubTasksRepository
+	realTimeService *realtime.RealTimeService
+}
+
+func NewActions(cr *chRepo.ChoreRepository, ur *uRepo.UserRepository, np *nps.NotificationPlanner, ep *events.EventsProducer, stRepo *stRepo.SubTasksRepository, rts *realtime.RealTimeService) *Actions {
+	return &Actions{
+		choreRepo:       cr,
+		userRepo:        ur,
+		nPlanner:        np,
+		eventProducer:   ep,
+		stRepo:          stRepo,
+		realTimeService: rts,
+	}
+}
+
+// Complete marks the chore done by completedBy on behalf of performer, who must be able to complete it
+func (a *Actions) Complete(c context.Context, chore *chModel.Chore, performer *uModel.UserDetails, completedBy int, completedDate time.Time, note *string) (*chModel.Chore, error) {
+	log := logging.FromContext(c)
+	if !chore.CanComplete(performer.ID) {
+		return nil, chModel.ErrNotAssigned
+	}
+	if chore.CompletionWindow != nil {
+		if completedDate.UTC().Before(chore.NextDueDate.UTC().Add(-time.Hour * time.Duration(*chore.CompletionWindow))) {
+			return nil, chModel.ErrOutsideCompletionWindow
+		}
+	}
+
+	var nextDueDate *time.Time
+	if chore.FrequencyType == "adaptive" {
+		history, err := a.choreRepo.GetChoreHistoryWithLimit(c, chore.ID, 5)
+		if err != nil {
+			return nil, &ActionError{Message: "Error getting chore history", Err: err}
+		}
+		nextDueDate, err = scheduleAdaptiveNextDueDate(chore, completedDate, history)
+		if err != nil {
+			return nil, &ActionError{Message: "Error scheduling next due date", Err: err}
+		}
+	} else {
+		var err error
+		nextDueDate, err = scheduleNextDueDate(c, chore, completedDate.UTC(), userTimezone(c, a.userRepo, chore))
+		if err != nil {
+			return nil, &ActionError{Message: "Error scheduling next due date", Err: err}
+		}
+	}
+	choreHistory, err := a.choreRepo.GetChoreHistory(c, chore.ID)
+	if err != nil {
+		return nil, &ActionError{Message: "Error getting chore history", Err: err}
+	}
+	nextAssignedTo, err := checkNextAssignee(chore, choreHistory, completedBy)
+	if err != nil {
+		return nil, &ActionError{Message: "Error checking next assignee", Err: err}
+	}
+
+	// the event is saved with the completion, so it's sent if and only if the completion is committed
+	var updatedChore *chModel.Chore
+	err = a.choreRepo.Transaction(c, func(c context.Context) error {
+		if err := a.choreRepo.CompleteChore(c, chore, note, completedBy, nextDueDate, &completedDate, nextAssignedTo, true); err != nil {
+			return &ActionError{Message: "Error completing chore", Err: err}
+		}
+		var err error
+		updatedChore, err = a.choreRepo.GetChore(c, chore.ID)
+		if err != nil {
+			return &ActionError{Message: "Error getting chore", Err: err}
+		}
+		if err := a.eventProducer.ChoreCompleted(c, performer.WebhookURL, chore, &performer.User); err != nil {
+			return &ActionError{Message: "Error completing chore", Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if updatedChore.SubTasks != nil && updatedChore.FrequencyType != chModel.FrequencyTypeOnce {
+		a.stRepo.ResetSubtasksCompletion(c, updatedChore.ID)
+	}
+
+	a.nPlanner.GenerateNotifications(c, updatedChore)
+	if err := a.nPlanner.GenerateCompletionNotifications(c, updatedChore, completedBy); err != nil {
+		log.Errorw("Error generating completion notifications", "chore_id", updatedChore.ID, "error", err)
+	}
+	if a.realTimeService != nil {
+		broadcaster := a.realTimeService.GetEventBroadcaster()
+		broadcaster.BroadcastChoreCompleted(updatedChore, &performer.User, a.lastHistory(c, chore.ID), note)
+	}
+	return updatedChore, nil
+}
+
+// Skip moves the chore to its next due date without completing it
+func (a *Actions) Skip(c context.Context, chore *chModel.Chore, performer *uModel.UserDetails) (*chModel.Chore, error) {
+	if chore.NextDueDate == nil {
+		return nil, chModel.ErrChoreWithoutNextDueDate
+	}
+	nextDueDate, err := scheduleNextDueDate(c, chore, chore.NextDueDate.UTC(), userTimezone(c, a.userRepo, chore))
+	if err != nil {
+		return nil, &ActionError{Message: "Error scheduling next due date", Err: err}
+	}
+
+	nextAssigedTo := chore.AssignedTo
+	var updatedChore *chModel.Chore
+	err = a.choreRepo.Transaction(c, func(c context.Context) error {
+		if err := a.choreRepo.SkipChore(c, chore, performer.ID, nextDueDate, nextAssigedTo); err != nil {
+			return &ActionError{Message: "Error completing chore", Err: err}
+		}
+		var err error
+		updatedChore, err = a.choreRepo.GetChore(c, chore.ID)
+		if err != nil {
+			return &ActionError{Message: "Error getting chore", Err: err}
+		}
+		if err := a.eventProducer.ChoreSkipped(c, performer.WebhookURL, updatedChore, &performer.User); err != nil {
+			return &ActionError{Message: "Error completing chore", Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if a.realTimeService != nil {
+		broadcaster := a.realTimeService.GetEventBroadcaster()
+		broadcaster.BroadcastChoreSkipped(updatedChore, &performer.User, a.lastHistory(c, chore.ID), nil)
+	}
+	return updatedChore, nil
+}
+
+// Snooze reminds the performer about the chore again at until
+func (a *Actions) Snooze(c context.Context, chore *chModel.Chore, performer *uModel.UserDetails, until time.Time) error {
+	if !chore.CanComplete(performer.ID) {
+		return chModel.ErrNotAssigned
+	}
+	if chore.NextDueDate == nil {
+		return chModel.ErrChoreWithoutNextDueDate
+	}
+	if err := a.nPlanner.GenerateSnoozedNotifications(c, chore, performer.ID, until); err != nil {
+		return &ActionError{Message: "Error snoozing chore", Err: err}
+	}
+	return nil
+}
+
+// CompleteChore, SkipChore and SnoozeChore look the chore up for callers that only have its id,
+// such as the Telegram bot
+func (a *Actions) CompleteChore(c context.Context, choreID int, user *uModel.UserDetails) (*chModel.Chore, error) {
+	chore, err := a.circleChore(c, choreID, user)
+	if err != nil {
+		return nil, err
+	}
+	return a.Complete(c, chore, user, user.ID, time.Now().UTC(), nil)
+}
+
+func (a *Actions) SkipChore(c context.Context, choreID int, user *uModel.UserDetails) (*chModel.Chore, error) {
+	chore, err := a.circleChore(c, choreID, user)
+	if err != nil {
+		return nil, err
+	}
+	if !chore.CanComplete(user.ID) {
+		return nil, chModel.ErrNotAssigned
+	}
+	return a.Skip(c, chore, user)
+}
+
+func (a *Actions) SnoozeChore(c context.Context, choreID int, user *uModel.UserDetails, until time.Time) (*chModel.Chore, error) {
+	chore, err := a.circleChore(c, choreID, user)
+	if err != nil {
+		return nil, err
+	}
+	return chore, a.Snooze(c, chore, user, until)
+}
+
+// CreateChore adds a one time chore assigned to the user, as the chore API does for its lite requests
+func (a *Actions) CreateChore(c context.Context, user *uModel.UserDetails, name string, dueDate *time.Time) (*chModel.Chore, error) {
+	chore := &chModel.Chore{
+		CreatedBy:      user.ID,
+		CircleID:       user.CircleID,
+		Name:           name,
+		IsActive:       true,
+		FrequencyType:  chModel.FrequencyTypeOnce,
+		AssignStrategy: chModel.AssignmentStrategyRandom,
+		AssignedTo:     user.ID,
+		Assignees:      []chModel.ChoreAssignees{{UserID: user.ID}},
+		NextDueDate:    dueDate,
+		CreatedAt:      time.Now().UTC(),
+	}
+	var createdChore *chModel.Chore
+	err := a.choreRepo.Transaction(c, func(c context.Context) error {
+		id, err := a.choreRepo.CreateChore(c, chore)
+		if err != nil {
+			return &ActionError{Message: "Error creating chore", Err: err}
+		}
+		createdChore, err = a.choreRepo.GetChore(c, id)
+		if err != nil {
+			return &ActionError{Message: "Error getting chore", Err: err}
+		}
+		if err := a.eventProducer.ResourceChanged(c, user.CircleID, user.WebhookURL, events.EventTypeTaskCreated, events.ResourceTask, createdChore.ID, &user.User, nil, events.NewSnapshot(createdChore)); err != nil {
+			return &ActionError{Message: "Error creating chore", Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return createdChore, nil
+}
+
+// ChoresDueBefore returns the active chores assigned to the user, or all of the circle's when
+// circleWide, that are due before the given time
+func (a *Actions) ChoresDueBefore(c context.Context, user *uModel.UserDetails, circleWide bool, before time.Time) ([]*chModel.Chore, error) {
+	if circleWide {
+		return a.choreRepo.GetCircleChoresDueBefore(c, user.CircleID, before)
+	}
+	return a.choreRepo.GetAssignedChoresDueBefore(c, user.CircleID, user.ID, before)
+}
+
+// AssignedChores returns the user's active chores, including the ones without a due date
+func (a *Actions) AssignedChores(c context.Context, user *uModel.UserDetails) ([]*chModel.Chore, error) {
+	chores, err := a.choreRepo.GetChores(c, user.CircleID, user.ID, false)
+	if err != nil {
+		return nil, err
+	}
+	assigned := make([]*chModel.Chore, 0, len(chores))
+	for _, chore := range chores {
+		if chore.AssignedTo == user.ID {
+			assigned = append(assigned, chore)
+		}
+	}
+	return assigned, nil
+}
+
+// IsCircleGroup reports whether one of the user's circle chores sends its circle group
+// notifications to the chat
+func (a *Actions) IsCircleGroup(c context.Context, user *uModel.UserDetails, chatID int64) (bool, error) {
+	chores, err := a.choreRepo.GetActiveCircleChores(c, user.CircleID)
+	if err != nil {
+		return false, err
+	}
+	for _, chore := range chores {
+		metadata := chore.NotificationMetadataV2
+		if metadata != nil && metadata.CircleGroup && metadata.CircleGroupID != nil && *metadata.CircleGroupID == chatID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (a *Actions) circleChore(c context.Context, choreID int, user *uModel.UserDetails) (*chModel.Chore, error) {
+	chore, err := a.choreRepo.GetChore(c, choreID)
+	if err != nil {
+		return nil, &ActionError{Message: "Error getting chore", Err: err}
+	}
+	if chore.CircleID != user.CircleID {
+		return nil, chModel.ErrChoreNotInCircle
+	}
+	return chore, nil
+}
+
+func (a *Actions) lastHistory(c context.Context, choreID int) *chModel.ChoreHistory {
+	history, _ := a.choreRepo.GetChoreHistoryWithLimit(c, choreID, 1)
+	if len(history) > 0 {
+		return history[0]
+	}
+	return nil
+}
+
+// userTimezone returns the timezone of the assignee of the chore, or of its creator when the assignee has
+// none, to schedule chores that were saved without a timezone of their own
+func userTimezone(c context.Context, userRepo *uRepo.UserRepository, chore *chModel.Chore) string {
+	if chore.FrequencyMetadataV2 != nil && chore.FrequencyMetadataV2.Timezone != "" && utils.IsValidTimezone(chore.FrequencyMetadataV2.Timezone) {
+		return ""
+	}
+	for _, userID := range []int{chore.AssignedTo, chore.CreatedBy} {
+		if userID == 0 {
+			continue
+		}
+		user, err := userRepo.GetUserByID(c, userID)
+		if err != nil {
+			logging.FromContext(c).Warnw("Error getting user timezone", "chore_id", chore.ID, "user_id", userID, "error", err)
+			continue
+		}
+		if user.Timezone != "" && utils.IsValidTimezone(user.Timezone) {
+			return user.Timezone
+		}
+	}
+	return ""
+}
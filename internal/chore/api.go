@@ -1,6 +1,7 @@
 package chore
 
 import (
+	"context"
 	"strconv"
 	"time"
 
@@ -151,18 +152,25 @@ func (h *API) CreateChore(c *gin.Context) {
 		CreatedAt:      time.Now().UTC(),
 	}
 
-	id, err := h.choreRepo.CreateChore(c, chore)
+	var createdChore *chModel.Chore
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		id, err := h.choreRepo.CreateChore(ctx, chore)
+		if err != nil {
+			return &ActionError{Message: "Error creating chore", Err: err}
+		}
+		// Fetch the created chore with all relations
+		createdChore, err = h.choreRepo.GetChore(ctx, id)
+		if err != nil {
+			return &ActionError{Message: "Error fetching created chore", Err: err}
+		}
+		if err := h.eventProducer.ResourceChanged(ctx, user.CircleID, user.WebhookURL, events.EventTypeTaskCreated, events.ResourceTask, createdChore.ID, &user.User, nil, events.NewSnapshot(createdChore)); err != nil {
+			return &ActionError{Message: "Error creating chore", Err: err}
+		}
+		return nil
+	})
 	if err != nil {
 		log.Errorw("chore.api.CreateChore failed to create chore", "error", err)
-		c.JSON(500, gin.H{"error": "Error creating chore"})
-		return
-	}
-
-	// Fetch the created chore with all relations
-	createdChore, err := h.choreRepo.GetChore(c, id)
-	if err != nil {
-		log.Errorw("chore.api.CreateChore failed to fetch created chore", "error", err)
-		c.JSON(500, gin.H{"error": "Error fetching created chore"})
+		c.JSON(500, gin.H{"error": actionErrorMessage(err)})
 		return
 	}
 
@@ -260,18 +268,25 @@ func (h *API) UpdateChore(c *gin.Context) {
 		"updated_at":    time.Now().UTC(),
 	}
 
-	err = h.choreRepo.UpdateChoreFields(c, choreID, updates)
+	var updatedChore *chModel.Chore
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.UpdateChoreFields(ctx, choreID, updates); err != nil {
+			return &ActionError{Message: "Error updating chore", Err: err}
+		}
+		// Fetch the updated chore
+		var err error
+		updatedChore, err = h.choreRepo.GetChore(ctx, choreID)
+		if err != nil {
+			return &ActionError{Message: "Error fetching updated chore", Err: err}
+		}
+		if err := h.eventProducer.ResourceChanged(ctx, user.CircleID, user.WebhookURL, events.EventTypeTaskUpdated, events.ResourceTask, choreID, &user.User, events.NewSnapshot(existingChore), events.NewSnapshot(updatedChore)); err != nil {
+			return &ActionError{Message: "Error updating chore", Err: err}
+		}
+		return nil
+	})
 	if err != nil {
 		log.Errorw("chore.api.UpdateChore failed to update chore", "error", err)
-		c.JSON(500, gin.H{"error": "Error updating chore"})
-		return
-	}
-
-	// Fetch the updated chore
-	updatedChore, err := h.choreRepo.GetChore(c, choreID)
-	if err != nil {
-		log.Errorw("chore.api.UpdateChore failed to fetch updated chore", "error", err)
-		c.JSON(500, gin.H{"error": "Error fetching updated chore"})
+		c.JSON(500, gin.H{"error": actionErrorMessage(err)})
 		return
 	}
 
@@ -358,7 +373,7 @@ func (h *API) CompleteChore(c *gin.Context) {
 		}
 
 	} else {
-		nextDueDate, err = scheduleNextDueDate(c, chore, completedDate.UTC())
+		nextDueDate, err = scheduleNextDueDate(c, chore, completedDate.UTC(), userTimezone(c, h.userRepo, chore))
 		if err != nil {
 			log.Debugw("chore.api.CompleteChore failed to schedule next due date", "error", err)
 			c.JSON(500, gin.H{
@@ -384,7 +399,14 @@ func (h *API) CompleteChore(c *gin.Context) {
 		return
 	}
 
-	if err := h.choreRepo.CompleteChore(c, chore, nil, performer, nextDueDate, &completedDate, nextAssignedTo, true); err != nil {
+	// the event is saved with the completion, so it's sent if and only if the completion is committed
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.CompleteChore(ctx, chore, nil, performer, nextDueDate, &completedDate, nextAssignedTo, true); err != nil {
+			return err
+		}
+		return h.eventProducer.ChoreCompleted(ctx, currentUser.WebhookURL, chore, &currentUser.User)
+	})
+	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error completing chore",
 		})
@@ -402,7 +424,9 @@ func (h *API) CompleteChore(c *gin.Context) {
 		return
 	}
 	h.nPlanner.GenerateNotifications(c, updatedChore)
-	h.eventProducer.ChoreCompleted(c, currentUser.WebhookURL, chore, &currentUser.User)
+	if err := h.nPlanner.GenerateCompletionNotifications(c, updatedChore, performer); err != nil {
+		log.Errorw("Error generating completion notifications", "chore_id", updatedChore.ID, "error", err)
+	}
 	c.JSON(200,
 		updatedChore,
 	)
@@ -456,7 +480,13 @@ func (h *API) DeleteChore(c *gin.Context) {
 		c.JSON(403, gin.H{"error": "You can only delete your own chores"})
 		return
 	}
-	if err := h.choreRepo.DeleteChore(c, choreID); err != nil {
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.DeleteChore(ctx, choreID); err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeTaskDeleted, events.ResourceTask, choreID, &currentUser.User, events.NewSnapshot(chore), nil)
+	})
+	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to delete chore"})
 		return
 	}
@@ -0,0 +1,265 @@
+package chore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"donetick.com/core/config"
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	cRepo "donetick.com/core/internal/circle/repo"
+	"donetick.com/core/internal/events"
+	nps "donetick.com/core/internal/notifier/service"
+	"donetick.com/core/internal/realtime"
+	"donetick.com/core/logging"
+)
+
+const defaultEscalationJobInterval = 15 * time.Minute
+
+// EscalationScheduler applies the escalation policies to overdue chores. every applied step is recorded
+// in the chore history, so a step runs once per due date even across restarts
+type EscalationScheduler struct {
+	choreRepo       *chRepo.ChoreRepository
+	circleRepo      *cRepo.CircleRepository
+	nPlanner        *nps.NotificationPlanner
+	eventProducer   *events.EventsProducer
+	realTimeService *realtime.RealTimeService
+	interval        time.Duration
+	stopChan        chan bool
+}
+
+func NewEscalationScheduler(cfg *config.Config, cr *chRepo.ChoreRepository, circleRepo *cRepo.CircleRepository, np *nps.NotificationPlanner, ep *events.EventsProducer, rts *realtime.RealTimeService) *EscalationScheduler {
+	interval := cfg.SchedulerJobs.EscalationJob
+	if interval <= 0 {
+		interval = defaultEscalationJobInterval
+	}
+	return &EscalationScheduler{
+		choreRepo:       cr,
+		circleRepo:      circleRepo,
+		nPlanner:        np,
+		eventProducer:   ep,
+		realTimeService: rts,
+		interval:        interval,
+		stopChan:        make(chan bool),
+	}
+}
+
+func (s *EscalationScheduler) Start(c context.Context) {
+	logger := logging.FromContext(c)
+	logger.Info("Escalation scheduler started")
+
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			if err := s.escalateOverdueChores(c, time.Now().UTC()); err != nil {
+				logger.Errorw("Failed to escalate overdue chores", "error", err)
+			}
+			select {
+			case <-s.stopChan:
+				logger.Info("Escalation scheduler stopped")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *EscalationScheduler) Stop() {
+	s.stopChan <- true
+}
+
+func (s *EscalationScheduler) escalateOverdueChores(c context.Context, now time.Time) error {
+	log := logging.FromContext(c)
+	policies, err := s.choreRepo.GetEscalationPolicies(c)
+	if err != nil {
+		return err
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+	circlePolicies := map[int]chModel.EscalationSteps{}
+	chorePolicies := map[int]chModel.EscalationSteps{}
+	for _, policy := range policies {
+		if policy.ChoreID == 0 {
+			circlePolicies[policy.CircleID] = policy.Steps
+		} else {
+			chorePolicies[policy.ChoreID] = policy.Steps
+		}
+	}
+
+	chores, err := s.choreRepo.GetOverdueChoresForEscalation(c, now)
+	if err != nil {
+		return err
+	}
+	for _, chore := range chores {
+		steps, ok := chorePolicies[chore.ID]
+		if !ok {
+			steps = circlePolicies[chore.CircleID]
+		}
+		if len(steps) == 0 {
+			continue
+		}
+		if err := s.escalateChore(c, chore, steps, now); err != nil {
+			log.Errorw("Error escalating chore", "chore_id", chore.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+// escalateChore applies the steps that became due since the last run, a step that fails is retried on
+// the next run together with the ones after it. the chore change of a step, its history entry and its
+// event are committed together, admins are notified before that so a failed commit notifies them again
+// on the next run rather than not at all
+func (s *EscalationScheduler) escalateChore(c context.Context, chore *chModel.Chore, steps chModel.EscalationSteps, now time.Time) error {
+	dueDate := *chore.NextDueDate
+	applied, err := s.choreRepo.GetEscalations(c, chore.ID, dueDate)
+	if err != nil {
+		return err
+	}
+	pending := pendingEscalationSteps(steps, now.Sub(dueDate), len(applied))
+	if len(pending) == 0 {
+		return nil
+	}
+	circle, err := s.circleRepo.GetCircleByID(c, chore.CircleID)
+	if err != nil {
+		return err
+	}
+
+	for _, step := range pending {
+		if step.Action == chModel.EscalationActionNotifyAdmins {
+			if err := s.nPlanner.GenerateEscalationNotifications(c, chore); err != nil {
+				return fmt.Errorf("applying %s: %w", step.Action, err)
+			}
+		}
+		var history *chModel.ChoreHistory
+		var note string
+		var changes map[string]interface{}
+		err = s.choreRepo.Transaction(c, func(c context.Context) error {
+			var err error
+			note, changes, err = s.applyEscalationStep(c, chore, step, now)
+			if err != nil {
+				return fmt.Errorf("applying %s: %w", step.Action, err)
+			}
+			updatedAt := now
+			history = &chModel.ChoreHistory{
+				ChoreID:    chore.ID,
+				AssignedTo: chore.AssignedTo,
+				DueDate:    &dueDate,
+				Note:       &note,
+				Status:     chModel.ChoreHistoryStatusEscalated,
+				CreatedAt:  now,
+				UpdatedAt:  &updatedAt,
+			}
+			if err := s.choreRepo.AddChoreHistory(c, history); err != nil {
+				return err
+			}
+			return s.eventProducer.ChoreEscalated(c, circle.WebhookURL, chore, step.Action, note)
+		})
+		if err != nil {
+			return err
+		}
+		logging.FromContext(c).Infow("Escalated overdue chore", "chore_id", chore.ID, "action", step.Action, "note", note)
+
+		if _, reassigned := changes["assignedTo"]; reassigned {
+			// the reminders of the chore were planned for the previous assignee. only the reminders are
+			// planned again, the admin notifications queued by an earlier step of this run are kept
+			s.nPlanner.GenerateNotifications(c, chore)
+		}
+		if s.realTimeService != nil {
+			s.realTimeService.GetEventBroadcaster().BroadcastChoreEscalated(chore, history, changes)
+		}
+	}
+	return nil
+}
+
+// applyEscalationStep performs the step's change of the chore and returns the note recorded for it and the
+// chore fields it changed. the notifications of notify_admins are sent by the caller
+func (s *EscalationScheduler) applyEscalationStep(c context.Context, chore *chModel.Chore, step chModel.EscalationStep, now time.Time) (string, map[string]interface{}, error) {
+	switch step.Action {
+	case chModel.EscalationActionNotifyAdmins:
+		return "Circle admins were notified", nil, nil
+
+	case chModel.EscalationActionReassign:
+		if len(chore.Assignees) < 2 {
+			return "Not reassigned, the chore has no other assignee", nil, nil
+		}
+		history, err := s.choreRepo.GetChoreHistory(c, chore.ID)
+		if err != nil {
+			return "", nil, err
+		}
+		nextAssignee, err := checkNextAssignee(chore, history, chore.AssignedTo)
+		if err != nil {
+			return "", nil, err
+		}
+		if nextAssignee == chore.AssignedTo {
+			return "Not reassigned, the assign strategy kept the current assignee", nil, nil
+		}
+		members, err := s.circleRepo.GetCircleUsers(c, chore.CircleID)
+		if err != nil {
+			return "", nil, err
+		}
+		if err := s.choreRepo.UpdateChoreFields(c, chore.ID, map[string]interface{}{
+			"assigned_to": nextAssignee,
+			"updated_at":  now,
+		}); err != nil {
+			return "", nil, err
+		}
+		names := map[int]string{}
+		for _, member := range members {
+			names[member.UserID] = member.DisplayName
+		}
+		note := fmt.Sprintf("Reassigned from %s to %s", names[chore.AssignedTo], names[nextAssignee])
+		chore.AssignedTo = nextAssignee
+		chore.UpdatedAt = now
+		return note, map[string]interface{}{"assignedTo": nextAssignee, "updatedAt": now}, nil
+
+	case chModel.EscalationActionRaisePriority:
+		priority := raisedPriority(chore.Priority)
+		if priority == chore.Priority {
+			return "Priority is already the highest", nil, nil
+		}
+		if err := s.choreRepo.UpdateChoreFields(c, chore.ID, map[string]interface{}{
+			"priority":   priority,
+			"updated_at": now,
+		}); err != nil {
+			return "", nil, err
+		}
+		chore.Priority = priority
+		chore.UpdatedAt = now
+		return fmt.Sprintf("Priority raised to P%d", priority), map[string]interface{}{"priority": priority, "updatedAt": now}, nil
+	}
+	return "", nil, fmt.Errorf("unknown escalation action %q", step.Action)
+}
+
+// pendingEscalationSteps returns the steps due for a chore overdue by overdueFor that come after the
+// applied ones, steps are applied in order of their delay
+func pendingEscalationSteps(steps chModel.EscalationSteps, overdueFor time.Duration, applied int) []chModel.EscalationStep {
+	sorted := steps.Sorted()
+	due := 0
+	for _, step := range sorted {
+		after, err := step.After()
+		if err != nil || after > overdueFor {
+			break
+		}
+		due++
+	}
+	if applied >= due {
+		return nil
+	}
+	return sorted[applied:due]
+}
+
+// raisedPriority returns the next higher priority, 1 is the highest and a chore without a priority
+// gets the lowest one
+func raisedPriority(priority int) int {
+	switch {
+	case priority <= 0:
+		return 4
+	case priority > 1:
+		return priority - 1
+	default:
+		return priority
+	}
+}
@@ -0,0 +1,296 @@
+package chore
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	chModel "donetick.com/core/internal/chore/model"
+	cModel "donetick.com/core/internal/circle/model"
+	"donetick.com/core/internal/events"
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+type escalationPolicyReq struct {
+	Steps chModel.EscalationSteps `json:"steps"`
+}
+
+func isCircleAdmin(circleUsers []*cModel.UserCircleDetail, userID int) bool {
+	for _, circleUser := range circleUsers {
+		if circleUser.UserID == userID && circleUser.Role == "admin" {
+			return true
+		}
+	}
+	return false
+}
+
+// getCircleEscalationPolicy returns the circle's default escalation policy, null when there is none
+func (h *Handler) getCircleEscalationPolicy(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	policy, err := h.choreRepo.GetEscalationPolicy(c, currentUser.CircleID, 0)
+	if err != nil {
+		logging.FromContext(c).Errorw("Error getting escalation policy", "circle_id", currentUser.CircleID, "error", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting escalation policy",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": policy,
+	})
+}
+
+// updateCircleEscalationPolicy sets the escalation policy of the circle's chores that have none of their own
+func (h *Handler) updateCircleEscalationPolicy(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireEscalationAdmin(c, currentUser) {
+		return
+	}
+	h.saveEscalationPolicy(c, currentUser, 0)
+}
+
+func (h *Handler) deleteCircleEscalationPolicy(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireEscalationAdmin(c, currentUser) {
+		return
+	}
+	h.removeEscalationPolicy(c, currentUser, 0)
+}
+
+// getChoreEscalationPolicy returns the chore's own policy and the circle default it falls back to
+func (h *Handler) getChoreEscalationPolicy(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	chore, ok := h.escalationChore(c, currentUser)
+	if !ok {
+		return
+	}
+	log := logging.FromContext(c)
+	policy, err := h.choreRepo.GetEscalationPolicy(c, chore.CircleID, chore.ID)
+	if err != nil {
+		log.Errorw("Error getting escalation policy", "chore_id", chore.ID, "error", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting escalation policy",
+		})
+		return
+	}
+	circlePolicy, err := h.choreRepo.GetEscalationPolicy(c, chore.CircleID, 0)
+	if err != nil {
+		log.Errorw("Error getting escalation policy", "circle_id", chore.CircleID, "error", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting escalation policy",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{
+			"policy":       policy,
+			"circlePolicy": circlePolicy,
+		},
+	})
+}
+
+// updateChoreEscalationPolicy replaces the circle default for the chore, no steps disables escalation for it
+func (h *Handler) updateChoreEscalationPolicy(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	chore, ok := h.escalationChore(c, currentUser)
+	if !ok || !h.requireEscalationEditor(c, currentUser, chore) {
+		return
+	}
+	h.saveEscalationPolicy(c, currentUser, chore.ID)
+}
+
+// deleteChoreEscalationPolicy makes the chore use the circle default again
+func (h *Handler) deleteChoreEscalationPolicy(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	chore, ok := h.escalationChore(c, currentUser)
+	if !ok || !h.requireEscalationEditor(c, currentUser, chore) {
+		return
+	}
+	h.removeEscalationPolicy(c, currentUser, chore.ID)
+}
+
+func (h *Handler) saveEscalationPolicy(c *gin.Context, currentUser *uModel.UserDetails, choreID int) {
+	log := logging.FromContext(c)
+	var req escalationPolicyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+	if err := req.Steps.Validate(); err != nil {
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	existing, err := h.choreRepo.GetEscalationPolicy(c, currentUser.CircleID, choreID)
+	if err != nil {
+		log.Errorw("Error getting escalation policy", "circle_id", currentUser.CircleID, "chore_id", choreID, "error", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting escalation policy",
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	policy := &chModel.EscalationPolicy{
+		CircleID:  currentUser.CircleID,
+		ChoreID:   choreID,
+		Steps:     req.Steps.Sorted(),
+		UpdatedBy: currentUser.ID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	var saved *chModel.EscalationPolicy
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.UpsertEscalationPolicy(ctx, policy); err != nil {
+			return err
+		}
+		var err error
+		saved, err = h.choreRepo.GetEscalationPolicy(ctx, currentUser.CircleID, choreID)
+		if err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeEscalationPolicyUpdated, events.ResourceEscalationPolicy, saved.ID, &currentUser.User, events.NewSnapshot(existing), events.NewSnapshot(saved))
+	})
+	if err != nil {
+		log.Errorw("Error saving escalation policy", "circle_id", currentUser.CircleID, "chore_id", choreID, "error", err)
+		c.JSON(500, gin.H{
+			"error": "Error saving escalation policy",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": saved,
+	})
+}
+
+func (h *Handler) removeEscalationPolicy(c *gin.Context, currentUser *uModel.UserDetails, choreID int) {
+	log := logging.FromContext(c)
+	existing, err := h.choreRepo.GetEscalationPolicy(c, currentUser.CircleID, choreID)
+	if err != nil {
+		log.Errorw("Error getting escalation policy", "circle_id", currentUser.CircleID, "chore_id", choreID, "error", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting escalation policy",
+		})
+		return
+	}
+	var deleted int64
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		var err error
+		deleted, err = h.choreRepo.DeleteEscalationPolicy(ctx, currentUser.CircleID, choreID)
+		if err != nil || deleted == 0 || existing == nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeEscalationPolicyDeleted, events.ResourceEscalationPolicy, existing.ID, &currentUser.User, events.NewSnapshot(existing), nil)
+	})
+	if err != nil {
+		log.Errorw("Error deleting escalation policy", "circle_id", currentUser.CircleID, "chore_id", choreID, "error", err)
+		c.JSON(500, gin.H{
+			"error": "Error deleting escalation policy",
+		})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(404, gin.H{
+			"error": "Escalation policy not found",
+		})
+		return
+	}
+	c.JSON(200, gin.H{})
+}
+
+// escalationChore loads the chore of the request, writing the error response when it is not in the user's circle
+func (h *Handler) escalationChore(c *gin.Context, currentUser *uModel.UserDetails) (*chModel.Chore, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid ID",
+		})
+		return nil, false
+	}
+	chore, err := h.choreRepo.GetChore(c, id)
+	if err != nil || chore.CircleID != currentUser.CircleID {
+		c.JSON(404, gin.H{
+			"error": "Chore not found",
+		})
+		return nil, false
+	}
+	return chore, true
+}
+
+func (h *Handler) requireEscalationAdmin(c *gin.Context, currentUser *uModel.UserDetails) bool {
+	circleUsers, err := h.circleRepo.GetCircleUsers(c, currentUser.CircleID)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting circle users",
+		})
+		return false
+	}
+	if !isCircleAdmin(circleUsers, currentUser.ID) {
+		c.JSON(403, gin.H{
+			"error": "Only circle admins can change the circle's escalation policy",
+		})
+		return false
+	}
+	return true
+}
+
+// requireEscalationEditor allows the users who can edit the chore to change its escalation policy
+func (h *Handler) requireEscalationEditor(c *gin.Context, currentUser *uModel.UserDetails, chore *chModel.Chore) bool {
+	circleUsers, err := h.circleRepo.GetCircleUsers(c, currentUser.CircleID)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting circle users",
+		})
+		return false
+	}
+	if err := chore.CanEdit(currentUser.ID, circleUsers, nil); err != nil {
+		c.JSON(403, gin.H{
+			"error": "You cannot edit this chore",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,231 @@
+package chore
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	chModel "donetick.com/core/internal/chore/model"
+	chRepo "donetick.com/core/internal/chore/repo"
+	cModel "donetick.com/core/internal/circle/model"
+	cRepo "donetick.com/core/internal/circle/repo"
+	"donetick.com/core/internal/events"
+	evModel "donetick.com/core/internal/events/model"
+	evRepo "donetick.com/core/internal/events/repo"
+	nModel "donetick.com/core/internal/notifier/model"
+	nRepo "donetick.com/core/internal/notifier/repo"
+	nps "donetick.com/core/internal/notifier/service"
+	stModel "donetick.com/core/internal/subtask/model"
+	tModel "donetick.com/core/internal/thing/model"
+	uModel "donetick.com/core/internal/user/model"
+	uRepo "donetick.com/core/internal/user/repo"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+func TestEscalationStepsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   chModel.EscalationSteps
+		wantErr bool
+	}{
+		{name: "empty", steps: nil},
+		{
+			name: "valid",
+			steps: chModel.EscalationSteps{
+				{Value: 1, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionNotifyAdmins},
+				{Value: 48, Unit: chModel.NotificationTemplateUnitHour, Action: chModel.EscalationActionReassign},
+				{Value: 30, Unit: chModel.NotificationTemplateUnitMinute, Action: chModel.EscalationActionRaisePriority},
+			},
+		},
+		{
+			name:    "zero value",
+			steps:   chModel.EscalationSteps{{Value: 0, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionReassign}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown unit",
+			steps:   chModel.EscalationSteps{{Value: 1, Unit: "w", Action: chModel.EscalationActionReassign}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown action",
+			steps:   chModel.EscalationSteps{{Value: 1, Unit: chModel.NotificationTemplateUnitDay, Action: "archive"}},
+			wantErr: true,
+		},
+		{
+			name: "too many steps",
+			steps: chModel.EscalationSteps{
+				{Value: 1, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionNotifyAdmins},
+				{Value: 2, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionNotifyAdmins},
+				{Value: 3, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionNotifyAdmins},
+				{Value: 4, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionNotifyAdmins},
+				{Value: 5, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionNotifyAdmins},
+				{Value: 6, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionNotifyAdmins},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.steps.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPendingEscalationSteps(t *testing.T) {
+	// out of order on purpose, steps apply by their delay
+	steps := chModel.EscalationSteps{
+		{Value: 3, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionRaisePriority},
+		{Value: 1, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionNotifyAdmins},
+		{Value: 2, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionReassign},
+	}
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name       string
+		overdueFor time.Duration
+		applied    int
+		want       []chModel.EscalationAction
+	}{
+		{name: "not overdue long enough", overdueFor: 23 * time.Hour},
+		{name: "first step", overdueFor: day, want: []chModel.EscalationAction{chModel.EscalationActionNotifyAdmins}},
+		{name: "first step already applied", overdueFor: day + time.Hour, applied: 1},
+		{name: "second step", overdueFor: 2 * day, applied: 1, want: []chModel.EscalationAction{chModel.EscalationActionReassign}},
+		{
+			name:       "missed runs apply every due step",
+			overdueFor: 4 * day,
+			want:       []chModel.EscalationAction{chModel.EscalationActionNotifyAdmins, chModel.EscalationActionReassign, chModel.EscalationActionRaisePriority},
+		},
+		{name: "all applied", overdueFor: 10 * day, applied: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pendingEscalationSteps(steps, tt.overdueFor, tt.applied)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d steps %v, want %v", len(got), got, tt.want)
+			}
+			for i, step := range got {
+				if step.Action != tt.want[i] {
+					t.Errorf("step %d = %s, want %s", i, step.Action, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRaisedPriority(t *testing.T) {
+	tests := map[int]int{0: 4, 4: 3, 3: 2, 2: 1, 1: 1}
+	for priority, want := range tests {
+		if got := raisedPriority(priority); got != want {
+			t.Errorf("raisedPriority(%d) = %d, want %d", priority, got, want)
+		}
+	}
+}
+
+func TestEscalateChoreNotifiesAdminsAndReassignsInOneRun(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "escalation.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&chModel.Chore{}, &chModel.ChoreAssignees{}, &chModel.ChoreHistory{}, &chModel.TimeSession{}, &chModel.Label{}, &tModel.ThingChore{}, &stModel.SubTask{},
+		&cModel.Circle{}, &cModel.UserCircle{}, &uModel.User{}, &uModel.UserNotificationTarget{}, &uModel.NotificationTarget{},
+		&nModel.Notification{}, &evModel.OutboxEvent{}, &evModel.WebhookSubscription{}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{}
+	choreRepo := chRepo.NewChoreRepository(db, cfg)
+	circleRepo := cRepo.NewCircleRepository(db)
+	notificationRepo := nRepo.NewNotificationRepository(db)
+	planner := nps.NewNotificationPlanner(notificationRepo, circleRepo, uRepo.NewUserRepository(db, cfg), nil, nil)
+	scheduler := NewEscalationScheduler(cfg, choreRepo, circleRepo, planner, events.NewEventsProducer(cfg, evRepo.NewWebhookRepository(db), circleRepo), nil)
+
+	if err := db.Create(&cModel.Circle{ID: 1, Name: "home"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, member := range []struct {
+		id   int
+		role string
+	}{{1, "admin"}, {2, "member"}, {3, "member"}} {
+		if err := db.Create(&uModel.User{ID: member.id, Username: fmt.Sprintf("user%d", member.id), Email: fmt.Sprintf("user%d@example.com", member.id), CircleID: 1}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&cModel.UserCircle{UserID: member.id, CircleID: 1, Role: member.role, IsActive: true}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&uModel.NotificationTarget{UserID: member.id, Type: nModel.NotificationPlatformTelegram, TargetID: fmt.Sprintf("%d", 40+member.id), Enabled: true}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now().UTC()
+	dueDate := now.Add(-3 * 24 * time.Hour)
+	chore := &chModel.Chore{
+		ID:                     1,
+		Name:                   "Take out the trash",
+		FrequencyType:          chModel.FrequencyTypeDaily,
+		NextDueDate:            &dueDate,
+		AssignedTo:             2,
+		AssignStrategy:         chModel.AssignmentStrategyRoundRobin,
+		IsActive:               true,
+		Notification:           true,
+		NotificationMetadataV2: &chModel.NotificationMetadata{DueDate: true},
+		CircleID:               1,
+		CreatedBy:              1,
+	}
+	if err := db.Omit(clause.Associations).Create(chore).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&[]chModel.ChoreAssignees{{ChoreID: 1, UserID: 2}, {ChoreID: 1, UserID: 3}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	chore, err = choreRepo.GetChore(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// both steps are due in the same run, the replan after the reassignment must not drop the admin notification
+	steps := chModel.EscalationSteps{
+		{Value: 1, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionNotifyAdmins},
+		{Value: 2, Unit: chModel.NotificationTemplateUnitDay, Action: chModel.EscalationActionReassign},
+	}
+	if err := scheduler.escalateChore(ctx, chore, steps, now); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, err := choreRepo.GetChore(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.AssignedTo != 3 {
+		t.Errorf("assignee after escalation = %d, want 3", updated.AssignedTo)
+	}
+	var escalations []*nModel.Notification
+	if err := db.Where("chore_id = ? AND event_type = ?", 1, nModel.EventTypeEscalation).Find(&escalations).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(escalations) != 1 || escalations[0].UserID != 1 || escalations[0].IsSent {
+		t.Errorf("escalation notifications = %+v, want one pending notification to the admin", escalations)
+	}
+	var reminders []*nModel.Notification
+	if err := db.Where("chore_id = ? AND event_type <> ?", 1, nModel.EventTypeEscalation).Find(&reminders).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, reminder := range reminders {
+		if reminder.UserID != 3 {
+			t.Errorf("reminder %s planned for user %d, want the new assignee 3", reminder.EventType, reminder.UserID)
+		}
+	}
+}
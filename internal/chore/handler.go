@@ -1,6 +1,8 @@
 package chore
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -46,6 +48,7 @@ type Handler struct {
 	storageRepo     *storageRepo.StorageRepository
 	storage         *storage.S3Storage
 	realTimeService *realtime.RealTimeService
+	actions         *Actions
 }
 
 func NewHandler(cr *chRepo.ChoreRepository, circleRepo *cRepo.CircleRepository, nt *notifier.Notifier,
@@ -53,7 +56,8 @@ func NewHandler(cr *chRepo.ChoreRepository, circleRepo *cRepo.CircleRepository,
 	ep *events.EventsProducer, stRepo *stRepo.SubTasksRepository,
 	storage *storage.S3Storage,
 	stoRepo *storageRepo.StorageRepository,
-	rts *realtime.RealTimeService) *Handler {
+	rts *realtime.RealTimeService,
+	actions *Actions) *Handler {
 	return &Handler{
 		choreRepo:       cr,
 		circleRepo:      circleRepo,
@@ -67,6 +71,7 @@ func NewHandler(cr *chRepo.ChoreRepository, circleRepo *cRepo.CircleRepository,
 		storageRepo:     stoRepo,
 		storage:         storage,
 		realTimeService: rts,
+		actions:         actions,
 	}
 }
 
@@ -184,6 +189,13 @@ func (h *Handler) createChore(c *gin.Context) {
 		})
 		return
 	}
+	choreReq.FrequencyMetadata = applyTimezoneFallback(choreReq.FrequencyMetadata, currentUser.Timezone)
+	if err := validateFrequency(choreReq.FrequencyType, choreReq.FrequencyMetadata); err != nil {
+		c.JSON(400, gin.H{
+			"error": fmt.Sprintf("Invalid frequency: %s", err.Error()),
+		})
+		return
+	}
 
 	circleUsers, err := h.circleRepo.GetCircleUsers(c, currentUser.CircleID)
 	if err != nil {
@@ -227,6 +239,13 @@ func (h *Handler) createChore(c *gin.Context) {
 
 	}
 
+	if err := anchorRRule(choreReq.FrequencyType, choreReq.FrequencyMetadata, dueDate); err != nil {
+		c.JSON(400, gin.H{
+			"error": fmt.Sprintf("Invalid frequency: %s", err.Error()),
+		})
+		return
+	}
+
 	createdChore := &chModel.Chore{
 
 		Name:                   choreReq.Name,
@@ -255,40 +274,52 @@ func (h *Handler) createChore(c *gin.Context) {
 		// it's need custom logic to handle subtask creation as we send negative ids sometimes when we creating parent child releationship
 		// when the subtask is not yet created
 	}
-	id, err := h.choreRepo.CreateChore(c, createdChore)
-	createdChore.ID = id
+	// the chore and its task.created event are committed together
+	var id int
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		var err error
+		id, err = h.choreRepo.CreateChore(ctx, createdChore)
+		createdChore.ID = id
+		if err != nil {
+			return &ActionError{Message: "Error creating chore", Err: err}
+		}
+
+		if choreReq.SubTasks != nil {
+			h.stRepo.UpdateSubtask(ctx, createdChore.ID, nil, *choreReq.SubTasks)
+		}
 
+		var choreAssignees []*chModel.ChoreAssignees
+		for _, assignee := range choreReq.Assignees {
+			choreAssignees = append(choreAssignees, &chModel.ChoreAssignees{
+				ChoreID: id,
+				UserID:  assignee.UserID,
+			})
+		}
+		if choreReq.LabelsV2 != nil {
+			labelsV2 := make([]int, len(*choreReq.LabelsV2))
+			for i, label := range *choreReq.LabelsV2 {
+				labelsV2[i] = int(label.LabelID)
+			}
+			if err := h.lRepo.AssignLabelsToChore(ctx, createdChore.ID, currentUser.ID, currentUser.CircleID, labelsV2, []int{}); err != nil {
+				return &ActionError{Message: "Error adding labels", Err: err}
+			}
+		}
+		if err := h.choreRepo.UpdateChoreAssignees(ctx, choreAssignees); err != nil {
+			return &ActionError{Message: "Error adding chore assignees", Err: err}
+		}
+		if err := publishChoreChange(ctx, h.eventProducer, h.choreRepo, currentUser, events.EventTypeTaskCreated, createdChore.ID, nil); err != nil {
+			return &ActionError{Message: "Error creating chore", Err: err}
+		}
+		return nil
+	})
 	if err != nil {
+		logger.Errorw("Error creating chore", "error", err)
 		c.JSON(500, gin.H{
-			"error": "Error creating chore",
+			"error": actionErrorMessage(err),
 		})
 		return
 	}
 
-	if choreReq.SubTasks != nil {
-		h.stRepo.UpdateSubtask(c, createdChore.ID, nil, *choreReq.SubTasks)
-	}
-
-	var choreAssignees []*chModel.ChoreAssignees
-	for _, assignee := range choreReq.Assignees {
-		choreAssignees = append(choreAssignees, &chModel.ChoreAssignees{
-			ChoreID: id,
-			UserID:  assignee.UserID,
-		})
-	}
-	if choreReq.LabelsV2 != nil {
-		labelsV2 := make([]int, len(*choreReq.LabelsV2))
-		for i, label := range *choreReq.LabelsV2 {
-			labelsV2[i] = int(label.LabelID)
-		}
-		if err := h.lRepo.AssignLabelsToChore(c, createdChore.ID, currentUser.ID, currentUser.CircleID, labelsV2, []int{}); err != nil {
-			c.JSON(500, gin.H{
-				"error": "Error adding labels",
-			})
-			return
-		}
-	}
-
 	if choreReq.Description != nil {
 		description := *choreReq.Description
 		if err := h.cleanUpUnreferencedFiles(c, currentUser.ID, storageModel.EntityTypeChoreDescription, createdChore.ID, description); err != nil {
@@ -298,12 +329,6 @@ func (h *Handler) createChore(c *gin.Context) {
 			return
 		}
 	}
-	if err := h.choreRepo.UpdateChoreAssignees(c, choreAssignees); err != nil {
-		c.JSON(500, gin.H{
-			"error": "Error adding chore assignees",
-		})
-		return
-	}
 	go func() {
 		h.nPlanner.GenerateNotifications(c, createdChore)
 	}()
@@ -341,6 +366,13 @@ func (h *Handler) editChore(c *gin.Context) {
 		})
 		return
 	}
+	choreReq.FrequencyMetadata = applyTimezoneFallback(choreReq.FrequencyMetadata, currentUser.Timezone)
+	if err := validateFrequency(choreReq.FrequencyType, choreReq.FrequencyMetadata); err != nil {
+		c.JSON(400, gin.H{
+			"error": fmt.Sprintf("Invalid frequency: %s", err.Error()),
+		})
+		return
+	}
 
 	circleUsers, err := h.circleRepo.GetCircleUsers(c, currentUser.CircleID)
 	if err != nil {
@@ -454,6 +486,8 @@ func (h *Handler) editChore(c *gin.Context) {
 		return
 	}
 
+	before := events.NewSnapshot(oldChore)
+
 	// Create a map to store the existing labels for quick lookup
 	oldLabelsMap := make(map[int]struct{})
 	for _, oldLabel := range *oldChore.LabelsV2 {
@@ -478,12 +512,6 @@ func (h *Handler) editChore(c *gin.Context) {
 		}
 	}
 
-	if err := h.lRepo.AssignLabelsToChore(c, choreReq.ID, currentUser.ID, currentUser.CircleID, labelsV2ToAdd, labelsV2ToBeRemoved); err != nil {
-		c.JSON(500, gin.H{
-			"error": "Error adding labels",
-		})
-		return
-	}
 	description := *choreReq.Description
 	if choreReq.Description == nil && oldChore.Description != nil {
 		description = ""
@@ -496,6 +524,13 @@ func (h *Handler) editChore(c *gin.Context) {
 		return
 	}
 
+	if err := anchorRRule(choreReq.FrequencyType, choreReq.FrequencyMetadata, dueDate); err != nil {
+		c.JSON(400, gin.H{
+			"error": fmt.Sprintf("Invalid frequency: %s", err.Error()),
+		})
+		return
+	}
+
 	updatedChore := &chModel.Chore{
 		ID:                  choreReq.ID,
 		Name:                choreReq.Name,
@@ -523,78 +558,84 @@ func (h *Handler) editChore(c *gin.Context) {
 		Priority:               choreReq.Priority,
 		Status:                 oldChore.Status,
 	}
-	if err := h.choreRepo.UpsertChore(c, updatedChore); err != nil {
-		c.JSON(500, gin.H{
-			"error": "Error adding chore",
-		})
-		return
-	}
-	if choreReq.SubTasks != nil {
-		ToBeRemoved := []stModel.SubTask{}
-		ToBeAdded := []stModel.SubTask{}
-		if oldChore.SubTasks == nil {
-			oldChore.SubTasks = &[]stModel.SubTask{}
+	// the chore changes and their task.updated event are committed together
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		var err error
+		if err := h.lRepo.AssignLabelsToChore(ctx, choreReq.ID, currentUser.ID, currentUser.CircleID, labelsV2ToAdd, labelsV2ToBeRemoved); err != nil {
+			return &ActionError{Message: "Error adding labels", Err: err}
 		}
-		if choreReq.SubTasks == nil {
-			choreReq.SubTasks = &[]stModel.SubTask{}
+		if err := h.choreRepo.UpsertChore(ctx, updatedChore); err != nil {
+			return &ActionError{Message: "Error adding chore", Err: err}
 		}
-		for _, existedSubTask := range *oldChore.SubTasks {
-			found := false
-			for _, newSubTask := range *choreReq.SubTasks {
-				if existedSubTask.ID == newSubTask.ID {
-					found = true
-					break
-				}
+		if choreReq.SubTasks != nil {
+			ToBeRemoved := []stModel.SubTask{}
+			ToBeAdded := []stModel.SubTask{}
+			if oldChore.SubTasks == nil {
+				oldChore.SubTasks = &[]stModel.SubTask{}
 			}
-			if !found {
-				ToBeRemoved = append(ToBeRemoved, existedSubTask)
+			if choreReq.SubTasks == nil {
+				choreReq.SubTasks = &[]stModel.SubTask{}
 			}
-		}
-
-		for _, newSubTask := range *choreReq.SubTasks {
-			found := false
-			newSubTask.ChoreID = oldChore.ID
-
 			for _, existedSubTask := range *oldChore.SubTasks {
-				if existedSubTask.ID == newSubTask.ID {
-					if existedSubTask.Name != newSubTask.Name || existedSubTask.OrderID != newSubTask.OrderID {
-						// there is a change in the subtask, update it
+				found := false
+				for _, newSubTask := range *choreReq.SubTasks {
+					if existedSubTask.ID == newSubTask.ID {
+						found = true
+						break
+					}
+				}
+				if !found {
+					ToBeRemoved = append(ToBeRemoved, existedSubTask)
+				}
+			}
+
+			for _, newSubTask := range *choreReq.SubTasks {
+				found := false
+				newSubTask.ChoreID = oldChore.ID
+
+				for _, existedSubTask := range *oldChore.SubTasks {
+					if existedSubTask.ID == newSubTask.ID {
+						if existedSubTask.Name != newSubTask.Name || existedSubTask.OrderID != newSubTask.OrderID {
+							// there is a change in the subtask, update it
+							break
+						}
+						found = true
 						break
 					}
-					found = true
-					break
+				}
+				if !found {
+					ToBeAdded = append(ToBeAdded, newSubTask)
 				}
 			}
-			if !found {
-				ToBeAdded = append(ToBeAdded, newSubTask)
+			if err := h.stRepo.UpdateSubtask(ctx, oldChore.ID, ToBeRemoved, ToBeAdded); err != nil {
+				return &ActionError{Message: "Error adding subtasks", Err: err}
 			}
 		}
-		if err := h.stRepo.UpdateSubtask(c, oldChore.ID, ToBeRemoved, ToBeAdded); err != nil {
-			c.JSON(500, gin.H{
-				"error": "Error adding subtasks",
-			})
-			return
-		}
-	}
 
-	if len(choreAssigneesToAdd) > 0 {
-		err = h.choreRepo.UpdateChoreAssignees(c, choreAssigneesToAdd)
+		if len(choreAssigneesToAdd) > 0 {
+			err = h.choreRepo.UpdateChoreAssignees(ctx, choreAssigneesToAdd)
 
-		if err != nil {
-			c.JSON(500, gin.H{
-				"error": "Error updating chore assignees",
-			})
-			return
+			if err != nil {
+				return &ActionError{Message: "Error updating chore assignees", Err: err}
+			}
 		}
-	}
-	if len(choreAssigneesToDelete) > 0 {
-		err = h.choreRepo.DeleteChoreAssignees(c, choreAssigneesToDelete)
-		if err != nil {
-			c.JSON(500, gin.H{
-				"error": "Error deleting chore assignees",
-			})
-			return
+		if len(choreAssigneesToDelete) > 0 {
+			err = h.choreRepo.DeleteChoreAssignees(ctx, choreAssigneesToDelete)
+			if err != nil {
+				return &ActionError{Message: "Error deleting chore assignees", Err: err}
+			}
 		}
+		if err := publishChoreChange(ctx, h.eventProducer, h.choreRepo, currentUser, events.EventTypeTaskUpdated, updatedChore.ID, before); err != nil {
+			return &ActionError{Message: "Error adding chore", Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		logging.FromContext(c).Errorw("Error editing chore", "chore_id", choreReq.ID, "error", err)
+		c.JSON(500, gin.H{
+			"error": actionErrorMessage(err),
+		})
+		return
 	}
 	go func() {
 		h.nPlanner.GenerateNotifications(c, updatedChore)
@@ -626,6 +667,140 @@ func (h *Handler) editChore(c *gin.Context) {
 	})
 }
 
+func (h *Handler) previewChore(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	count := 5
+	if rawCount := c.Query("count"); rawCount != "" {
+		parsedCount, err := strconv.Atoi(rawCount)
+		if err != nil || parsedCount <= 0 || parsedCount > 50 {
+			c.JSON(400, gin.H{
+				"error": "Invalid count, must be between 1 and 50",
+			})
+			return
+		}
+		count = parsedCount
+	}
+
+	var choreReq chModel.ChoreReq
+	if err := c.ShouldBindJSON(&choreReq); err != nil {
+		log.Print(err)
+		c.JSON(400, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+	choreReq.FrequencyMetadata = applyTimezoneFallback(choreReq.FrequencyMetadata, currentUser.Timezone)
+	if err := validateFrequency(choreReq.FrequencyType, choreReq.FrequencyMetadata); err != nil {
+		c.JSON(400, gin.H{
+			"error": fmt.Sprintf("Invalid frequency: %s", err.Error()),
+		})
+		return
+	}
+
+	circleUsers, err := h.circleRepo.GetCircleUsers(c, currentUser.CircleID)
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": "Error getting circle users",
+		})
+		return
+	}
+	for _, assignee := range choreReq.Assignees {
+		userFound := false
+		for _, circleUser := range circleUsers {
+			if assignee.UserID == circleUser.UserID {
+				userFound = true
+				break
+			}
+		}
+		if !userFound {
+			c.JSON(400, gin.H{
+				"error": "Assignee not found in circle",
+			})
+			return
+		}
+	}
+	if choreReq.AssignedTo <= 0 {
+		if len(choreReq.Assignees) > 0 {
+			choreReq.AssignedTo = choreReq.Assignees[rand.Intn(len(choreReq.Assignees))].UserID
+		} else {
+			choreReq.AssignedTo = currentUser.ID
+		}
+	}
+
+	var dueDate *time.Time
+	if choreReq.DueDate != "" {
+		rawDueDate, err := time.Parse(time.RFC3339, choreReq.DueDate)
+		if err != nil {
+			c.JSON(400, gin.H{
+				"error": "Invalid date",
+			})
+			return
+		}
+		rawDueDate = rawDueDate.UTC()
+		dueDate = &rawDueDate
+	}
+
+	if err := anchorRRule(choreReq.FrequencyType, choreReq.FrequencyMetadata, dueDate); err != nil {
+		c.JSON(400, gin.H{
+			"error": fmt.Sprintf("Invalid frequency: %s", err.Error()),
+		})
+		return
+	}
+
+	chore := &chModel.Chore{
+		ID:                  choreReq.ID,
+		Name:                choreReq.Name,
+		FrequencyType:       choreReq.FrequencyType,
+		Frequency:           choreReq.Frequency,
+		FrequencyMetadataV2: choreReq.FrequencyMetadata,
+		NextDueDate:         dueDate,
+		Assignees:           choreReq.Assignees,
+		AssignStrategy:      choreReq.AssignStrategy,
+		AssignedTo:          choreReq.AssignedTo,
+		IsRolling:           choreReq.IsRolling,
+		CircleID:            currentUser.CircleID,
+		CreatedBy:           currentUser.ID,
+	}
+
+	// when previewing changes to an existing chore, its history drives the assignee rotation
+	var history []*chModel.ChoreHistory
+	if choreReq.ID > 0 {
+		existingChore, err := h.choreRepo.GetChore(c, choreReq.ID)
+		if err != nil || existingChore.CircleID != currentUser.CircleID {
+			c.JSON(404, gin.H{
+				"error": "Chore not found",
+			})
+			return
+		}
+		history, err = h.choreRepo.GetChoreHistory(c, choreReq.ID)
+		if err != nil {
+			c.JSON(500, gin.H{
+				"error": "Error getting chore history",
+			})
+			return
+		}
+	}
+
+	occurrences, err := previewOccurrences(c, chore, history, count, userTimezone(c, h.actions.userRepo, chore))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": fmt.Sprintf("Error scheduling next due date: %s", err.Error()),
+		})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"res": occurrences,
+	})
+}
+
 func (h *Handler) cleanUpUnreferencedFiles(ctx *gin.Context, userID int, entityType storageModel.EntityType, entityID int, text string) error {
 	existedFiles, err := h.storageRepo.GetFilesByUser(ctx, userID, entityType, entityID)
 	if err != nil {
@@ -715,7 +890,13 @@ func (h *Handler) deleteChore(c *gin.Context) {
 		return
 	}
 
-	if err := h.choreRepo.DeleteChore(c, id); err != nil {
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.DeleteChore(ctx, id); err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeTaskDeleted, events.ResourceTask, chore.ID, &currentUser.User, events.NewSnapshot(chore), nil)
+	})
+	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error deleting chore",
 		})
@@ -823,12 +1004,19 @@ func (h *Handler) updateAssignee(c *gin.Context) {
 		})
 		return
 	}
+	before := events.NewSnapshot(chore)
 
-	if err := h.choreRepo.UpdateChoreFields(c, id, map[string]interface{}{
-		"assigned_to": assigneeReq.Assignee,
-		"updated_by":  currentUser.ID,
-		"updated_at":  assigneeReq.UpdatedAt,
-	}); err != nil {
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.UpdateChoreFields(ctx, id, map[string]interface{}{
+			"assigned_to": assigneeReq.Assignee,
+			"updated_by":  currentUser.ID,
+			"updated_at":  assigneeReq.UpdatedAt,
+		}); err != nil {
+			return err
+		}
+		return publishChoreChange(ctx, h.eventProducer, h.choreRepo, currentUser, events.EventTypeTaskReassigned, id, before)
+	})
+	if err != nil {
 		logging.FromContext(c).Error("Error updating assignee", "error", err, "choreID", id, "assignee", assigneeReq.Assignee)
 
 		c.JSON(500, gin.H{
@@ -887,39 +1075,49 @@ func (h *Handler) startChore(c *gin.Context) {
 		})
 		return
 	}
+	if chore.Status != chModel.ChoreStatusNoStatus && chore.Status != chModel.ChoreStatusPaused {
+		c.JSON(400, gin.H{
+			"error": "Chore is not in a state that can be started",
+		})
+		return
+	}
 	var session *chModel.TimeSession
-	switch chore.Status {
-	case chModel.ChoreStatusNoStatus:
-		session, err = h.choreRepo.CreateTimeSession(c, chore, currentUser.ID)
-		if err != nil {
-			c.JSON(500, gin.H{
-				"error": "Error creating time session",
-			})
-			return
+	var sessionBefore events.Snapshot
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		var err error
+		switch chore.Status {
+		case chModel.ChoreStatusNoStatus:
+			session, err = h.choreRepo.CreateTimeSession(ctx, chore, currentUser.ID)
+			if err != nil {
+				return &ActionError{Message: "Error creating time session", Err: err}
+			}
+		case chModel.ChoreStatusPaused:
+			session, err = h.choreRepo.GetActiveTimeSession(ctx, chore.ID)
+			if err != nil {
+				return &ActionError{Message: "Error getting active time session", Err: err}
+			}
+			if session != nil {
+				sessionBefore = events.NewSnapshot(session)
+				session.Start(currentUser.ID)
+				if err := h.choreRepo.UpdateTimeSession(ctx, session); err != nil {
+					return &ActionError{Message: "Error updating time session", Err: err}
+				}
+			}
 		}
-		h.choreRepo.UpdateChoreStatus(c, chore.ID, chModel.ChoreStatusInProgress)
-	case chModel.ChoreStatusPaused:
-		session, err = h.choreRepo.GetActiveTimeSession(c, chore.ID)
-		if err != nil {
-			c.JSON(500, gin.H{
-				"error": "Error getting active time session",
-			})
-			return
+		if err := h.choreRepo.UpdateChoreStatus(ctx, chore.ID, chModel.ChoreStatusInProgress); err != nil {
+			return &ActionError{Message: "Error updating chore status", Err: err}
 		}
-		if session != nil {
-			session.Start(currentUser.ID)
-			if err := h.choreRepo.UpdateTimeSession(c, session); err != nil {
-				c.JSON(500, gin.H{
-					"error": "Error updating time session",
-				})
-				return
-			}
+		if session == nil {
+			return nil
 		}
-		h.choreRepo.UpdateChoreStatus(c, chore.ID, chModel.ChoreStatusInProgress)
-
-	default:
-		c.JSON(400, gin.H{
-			"error": "Chore is not in a state that can be started",
+		if err := h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeTaskTimerStarted, events.ResourceTimeSession, session.ID, &currentUser.User, sessionBefore, events.NewSnapshot(session)); err != nil {
+			return &ActionError{Message: "Error updating time session", Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(500, gin.H{
+			"error": actionErrorMessage(err),
 		})
 		return
 	}
@@ -992,14 +1190,23 @@ func (h *Handler) pauseChore(c *gin.Context) {
 		})
 		return
 	}
+	sessionBefore := events.NewSnapshot(session)
 	session.Pause(currentUser.ID)
-	if err := h.choreRepo.UpdateTimeSession(c, session); err != nil {
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.UpdateTimeSession(ctx, session); err != nil {
+			return err
+		}
+		if err := h.choreRepo.UpdateChoreStatus(ctx, chore.ID, chModel.ChoreStatusPaused); err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeTaskTimerPaused, events.ResourceTimeSession, session.ID, &currentUser.User, sessionBefore, events.NewSnapshot(session))
+	})
+	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error updating time session",
 		})
 		return
 	}
-	h.choreRepo.UpdateChoreStatus(c, chore.ID, chModel.ChoreStatusPaused)
 	if h.realTimeService != nil {
 		chore.Status = chModel.ChoreStatusPaused
 		broadcaster := h.realTimeService.GetEventBroadcaster()
@@ -1072,6 +1279,8 @@ func (h *Handler) ResetChoreTimer(c *gin.Context) {
 		return
 	}
 
+	sessionBefore := events.NewSnapshot(session)
+
 	// Reset the timer: clear pause log, reset duration, set start time to now
 	timeNow := time.Now().UTC()
 	session.PauseLog = chModel.PauseLogEntries{}
@@ -1087,16 +1296,23 @@ func (h *Handler) ResetChoreTimer(c *gin.Context) {
 		UpdateBy:  currentUser.ID,
 	})
 
-	if err := h.choreRepo.UpdateTimeSession(c, session); err != nil {
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.UpdateTimeSession(ctx, session); err != nil {
+			return err
+		}
+		// Update chore status to in progress
+		if err := h.choreRepo.UpdateChoreStatus(ctx, chore.ID, chModel.ChoreStatusInProgress); err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeTaskTimerReset, events.ResourceTimeSession, session.ID, &currentUser.User, sessionBefore, events.NewSnapshot(session))
+	})
+	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error updating time session",
 		})
 		return
 	}
 
-	// Update chore status to in progress
-	h.choreRepo.UpdateChoreStatus(c, chore.ID, chModel.ChoreStatusInProgress)
-
 	// Broadcast the change via real-time service
 	if h.realTimeService != nil {
 		chore.Status = chModel.ChoreStatusInProgress
@@ -1145,42 +1361,19 @@ func (h *Handler) skipChore(c *gin.Context) {
 		})
 		return
 	}
-	nextDueDate, err := scheduleNextDueDate(c, chore, chore.NextDueDate.UTC())
-	if err != nil {
-		c.JSON(500, gin.H{
-			"error": "Error scheduling next due date",
-		})
-		return
-	}
-
-	nextAssigedTo := chore.AssignedTo
-	if err := h.choreRepo.SkipChore(c, chore, currentUser.ID, nextDueDate, nextAssigedTo); err != nil {
-		c.JSON(500, gin.H{
-			"error": "Error completing chore",
-		})
-		return
-	}
-
-	updatedChore, err := h.choreRepo.GetChore(c, id)
+	updatedChore, err := h.actions.Skip(c, chore, currentUser)
 	if err != nil {
+		if errors.Is(err, chModel.ErrChoreWithoutNextDueDate) {
+			c.JSON(400, gin.H{
+				"error": "Chore has no due date",
+			})
+			return
+		}
 		c.JSON(500, gin.H{
-			"error": "Error getting chore",
+			"error": actionErrorMessage(err),
 		})
 		return
 	}
-	h.eventProducer.ChoreSkipped(c, currentUser.WebhookURL, updatedChore, &currentUser.User)
-
-	// Broadcast real-time chore skip event
-	if h.realTimeService != nil {
-		broadcaster := h.realTimeService.GetEventBroadcaster()
-		// Get the skip history entry
-		history, _ := h.choreRepo.GetChoreHistoryWithLimit(c, chore.ID, 1)
-		var choreHistory *chModel.ChoreHistory
-		if len(history) > 0 {
-			choreHistory = history[0]
-		}
-		broadcaster.BroadcastChoreSkipped(updatedChore, &currentUser.User, choreHistory, nil)
-	}
 
 	c.JSON(200, gin.H{
 		"res": updatedChore,
@@ -1245,11 +1438,18 @@ func (h *Handler) updateDueDate(c *gin.Context) {
 		c.JSON(403, gin.H{})
 		return
 	}
-	if err := h.choreRepo.UpdateChoreFields(c, chore.ID, map[string]interface{}{
-		"next_due_date": dueDate,
-		"updated_by":    currentUser.ID,
-		"updated_at":    time.Now().UTC(),
-	}); err != nil {
+	before := events.NewSnapshot(chore)
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.UpdateChoreFields(ctx, chore.ID, map[string]interface{}{
+			"next_due_date": dueDate,
+			"updated_by":    currentUser.ID,
+			"updated_at":    time.Now().UTC(),
+		}); err != nil {
+			return err
+		}
+		return publishChoreChange(ctx, h.eventProducer, h.choreRepo, currentUser, events.EventTypeTaskDueDateChanged, chore.ID, before)
+	})
+	if err != nil {
 		log.Printf("Error updating due date: %s", err)
 		c.JSON(500, gin.H{
 			"error": "Error updating due date",
@@ -1293,8 +1493,13 @@ func (h *Handler) archiveChore(c *gin.Context) {
 		return
 	}
 
-	err = h.choreRepo.ArchiveChore(c, id, currentUser.ID)
-
+	before := choreSnapshot(c, h.choreRepo, id)
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.ArchiveChore(ctx, id, currentUser.ID); err != nil {
+			return err
+		}
+		return publishChoreChange(ctx, h.eventProducer, h.choreRepo, currentUser, events.EventTypeTaskArchived, id, before)
+	})
 	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error archiving chore",
@@ -1339,8 +1544,13 @@ func (h *Handler) UnarchiveChore(c *gin.Context) {
 		return
 	}
 
-	err = h.choreRepo.UnarchiveChore(c, id, currentUser.ID)
-
+	before := choreSnapshot(c, h.choreRepo, id)
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.UnarchiveChore(ctx, id, currentUser.ID); err != nil {
+			return err
+		}
+		return publishChoreChange(ctx, h.eventProducer, h.choreRepo, currentUser, events.EventTypeTaskUnarchived, id, before)
+	})
 	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error unarchiving chore",
@@ -1420,24 +1630,6 @@ func (h *Handler) completeChore(c *gin.Context) {
 		return
 	}
 
-	// user need to be assigned to the chore to complete it
-	if !chore.CanComplete(currentUser.ID) {
-		c.JSON(400, gin.H{
-			"error": "User is not assigned to chore",
-		})
-		return
-	}
-
-	// confirm that the chore in completion window:
-	if chore.CompletionWindow != nil {
-		if completedDate.UTC().Before(chore.NextDueDate.UTC().Add(-time.Hour * time.Duration(*chore.CompletionWindow))) {
-			c.JSON(400, gin.H{
-				"error": "Chore is out of completion window",
-			})
-			return
-		}
-	}
-
 	if req.CompletedBy != nil {
 		// Only allow admins to complete chores on behalf of others in the circle
 		ok := authorizeChoreCompletionForUser(h, c, currentUser, req.CompletedBy)
@@ -1446,74 +1638,25 @@ func (h *Handler) completeChore(c *gin.Context) {
 		}
 		completedBy = *req.CompletedBy
 	}
-	var nextDueDate *time.Time
-	if chore.FrequencyType == "adaptive" {
-		history, err := h.choreRepo.GetChoreHistoryWithLimit(c, chore.ID, 5)
-		if err != nil {
-			c.JSON(500, gin.H{
-				"error": "Error getting chore history",
+	updatedChore, err := h.actions.Complete(c, chore, currentUser, completedBy, completedDate, additionalNotes)
+	if err != nil {
+		switch {
+		case errors.Is(err, chModel.ErrNotAssigned):
+			c.JSON(400, gin.H{
+				"error": "User is not assigned to chore",
 			})
-			return
-		}
-		nextDueDate, err = scheduleAdaptiveNextDueDate(chore, completedDate, history)
-		if err != nil {
-			log.Printf("Error scheduling next due date: %s", err)
-			c.JSON(500, gin.H{
-				"error": "Error scheduling next due date",
+		case errors.Is(err, chModel.ErrOutsideCompletionWindow):
+			c.JSON(400, gin.H{
+				"error": "Chore is out of completion window",
 			})
-			return
-		}
-
-	} else {
-		nextDueDate, err = scheduleNextDueDate(c, chore, completedDate.UTC())
-		if err != nil {
-			log.Printf("Error scheduling next due date: %s", err)
+		default:
+			log.Printf("Error completing chore: %s", err)
 			c.JSON(500, gin.H{
-				"error": "Error scheduling next due date",
+				"error": actionErrorMessage(err),
 			})
-			return
 		}
-	}
-	choreHistory, err := h.choreRepo.GetChoreHistory(c, chore.ID)
-	if err != nil {
-		c.JSON(500, gin.H{
-			"error": "Error getting chore history",
-		})
 		return
 	}
-
-	nextAssignedTo, err := checkNextAssignee(chore, choreHistory, completedBy)
-	if err != nil {
-		log.Printf("Error checking next assignee: %s", err)
-		c.JSON(500, gin.H{
-			"error": "Error checking next assignee",
-		})
-		return
-	}
-
-	if err := h.choreRepo.CompleteChore(c, chore, additionalNotes, completedBy, nextDueDate, &completedDate, nextAssignedTo, true); err != nil {
-		c.JSON(500, gin.H{
-			"error": "Error completing chore",
-		})
-		return
-	}
-	updatedChore, err := h.choreRepo.GetChore(c, id)
-	if err != nil {
-		c.JSON(500, gin.H{
-			"error": "Error getting chore",
-		})
-		return
-	}
-	if updatedChore.SubTasks != nil && updatedChore.FrequencyType != chModel.FrequencyTypeOnce {
-		h.stRepo.ResetSubtasksCompletion(c, updatedChore.ID)
-	}
-
-	// go func() {
-
-	// 	h.notifier.SendChoreCompletion(c, chore, currentUser)
-	// }()
-	h.nPlanner.GenerateNotifications(c, updatedChore)
-	h.eventProducer.ChoreCompleted(c, currentUser.WebhookURL, chore, &currentUser.User)
 	
 	// Update goal progress when points are earned
 	if chore.Points != nil && *chore.Points > 0 {
@@ -1522,17 +1665,6 @@ func (h *Handler) completeChore(c *gin.Context) {
 			// Don't fail the request, just log the error
 		}
 	}
-	if h.realTimeService != nil {
-		broadcaster := h.realTimeService.GetEventBroadcaster()
-		// Get the completion history entry
-		history, _ := h.choreRepo.GetChoreHistoryWithLimit(c, chore.ID, 1)
-
-		var choreHistory *chModel.ChoreHistory
-		if len(history) > 0 {
-			choreHistory = history[0]
-		}
-		broadcaster.BroadcastChoreCompleted(updatedChore, &currentUser.User, choreHistory, additionalNotes)
-	}
 
 	c.JSON(200, gin.H{
 		"res": updatedChore,
@@ -1570,6 +1702,8 @@ func authorizeChoreCompletionForUser(h *Handler, c *gin.Context, currentUser *uM
 	return true
 }
 
+// GetChoreHistory returns the history of the chore, the escalation steps applied while it was overdue are
+// part of it with the escalated status
 func (h *Handler) GetChoreHistory(c *gin.Context) {
 	rawID := c.Param("id")
 	id, err := strconv.Atoi(rawID)
@@ -1580,7 +1714,7 @@ func (h *Handler) GetChoreHistory(c *gin.Context) {
 		return
 	}
 
-	choreHistory, err := h.choreRepo.GetChoreHistory(c, id)
+	choreHistory, err := h.choreRepo.GetChoreHistoryWithEscalations(c, id)
 	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error getting chore history",
@@ -1679,6 +1813,7 @@ func (h *Handler) ModifyHistory(c *gin.Context) {
 		})
 		return
 	}
+	before := events.NewSnapshot(history)
 	if req.PerformedAt != nil {
 		history.PerformedAt = req.PerformedAt
 	}
@@ -1689,7 +1824,13 @@ func (h *Handler) ModifyHistory(c *gin.Context) {
 		history.Note = req.Notes
 	}
 
-	if err := h.choreRepo.UpdateChoreHistory(c, history); err != nil {
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.UpdateChoreHistory(ctx, history); err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeTaskHistoryUpdated, events.ResourceTaskHistory, history.ID, &currentUser.User, before, events.NewSnapshot(history))
+	})
+	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error updating history",
 		})
@@ -1732,7 +1873,14 @@ func (h *Handler) updatePriority(c *gin.Context) {
 		return
 	}
 
-	if err := h.choreRepo.UpdateChorePriority(c, currentUser.ID, id, *priorityReq.Priority); err != nil {
+	before := choreSnapshot(c, h.choreRepo, id)
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.UpdateChorePriority(ctx, currentUser.ID, id, *priorityReq.Priority); err != nil {
+			return err
+		}
+		return publishChoreChange(ctx, h.eventProducer, h.choreRepo, currentUser, events.EventTypeTaskUpdated, id, before)
+	})
+	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error updating priority",
 		})
@@ -1826,7 +1974,13 @@ func (h *Handler) DeleteHistory(c *gin.Context) {
 		return
 	}
 
-	if err := h.choreRepo.DeleteChoreHistory(c, historyID); err != nil {
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.DeleteChoreHistory(ctx, historyID); err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeTaskHistoryDeleted, events.ResourceTaskHistory, history.ID, &currentUser.User, events.NewSnapshot(history), nil)
+	})
+	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error deleting history",
 		})
@@ -1887,7 +2041,19 @@ func (h *Handler) UpdateSubtaskCompletedAt(c *gin.Context) {
 	if req.CompletedAt != nil {
 		completedAt = req.CompletedAt
 	}
-	err = h.stRepo.UpdateSubTaskStatus(c, currentUser.ID, req.ID, completedAt)
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.stRepo.UpdateSubTaskStatus(ctx, currentUser.ID, req.ID, completedAt); err != nil {
+			return err
+		}
+		return h.eventProducer.SubtaskUpdated(ctx, currentUser.CircleID, req.ChoreID, currentUser.WebhookURL,
+			&stModel.SubTask{
+				ID:          req.ID,
+				ChoreID:     req.ChoreID,
+				CompletedAt: completedAt,
+				CompletedBy: currentUser.ID,
+			},
+		)
+	})
 	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error getting subtask",
@@ -1910,14 +2076,6 @@ func (h *Handler) UpdateSubtaskCompletedAt(c *gin.Context) {
 
 	}
 
-	h.eventProducer.SubtaskUpdated(c, currentUser.WebhookURL,
-		&stModel.SubTask{
-			ID:          req.ID,
-			ChoreID:     req.ChoreID,
-			CompletedAt: completedAt,
-			CompletedBy: currentUser.ID,
-		},
-	)
 	c.JSON(200, gin.H{})
 
 }
@@ -2083,6 +2241,8 @@ func (h *Handler) UpdateTimeSession(c *gin.Context) {
 		return
 	}
 
+	before := events.NewSnapshot(session)
+
 	// Update the session fields
 	if req.StartTime != nil {
 		session.StartTime = *req.StartTime
@@ -2097,7 +2257,13 @@ func (h *Handler) UpdateTimeSession(c *gin.Context) {
 	session.UpdateBy = currentUser.ID
 
 	// Save the updated session (this will recalculate duration)
-	if err := h.choreRepo.UpdateTimeSessionData(c, session); err != nil {
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.UpdateTimeSessionData(ctx, session); err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeTaskTimeSessionUpdated, events.ResourceTimeSession, session.ID, &currentUser.User, before, events.NewSnapshot(session))
+	})
+	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error updating time session",
 		})
@@ -2180,7 +2346,13 @@ func (h *Handler) DeleteTimeSession(c *gin.Context) {
 	}
 
 	// Delete the time session
-	if err := h.choreRepo.DeleteTimeSession(c, sessionID, choreID); err != nil {
+	err = h.choreRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.choreRepo.DeleteTimeSession(ctx, sessionID, choreID); err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeTaskTimeSessionDeleted, events.ResourceTimeSession, session.ID, &currentUser.User, events.NewSnapshot(session), nil)
+	})
+	if err != nil {
 		c.JSON(500, gin.H{
 			"error": "Error deleting time session",
 		})
@@ -2490,15 +2662,22 @@ func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 		choresRoutes.GET("/", h.getChores)
 		choresRoutes.GET("/archived", h.getArchivedChores)
 		choresRoutes.GET("/history", h.getChoresHistory)
+		choresRoutes.GET("/escalation-policy", h.getCircleEscalationPolicy)
+		choresRoutes.PUT("/escalation-policy", h.updateCircleEscalationPolicy)
+		choresRoutes.DELETE("/escalation-policy", h.deleteCircleEscalationPolicy)
 		choresRoutes.PUT("/", h.editChore)
 		choresRoutes.PUT("/:id/priority", h.updatePriority)
 		choresRoutes.POST("/", h.createChore)
+		choresRoutes.POST("/preview", h.previewChore)
 		choresRoutes.GET("/:id", h.getChore)
 		choresRoutes.PUT("/:id/subtask", h.UpdateSubtaskCompletedAt)
 		choresRoutes.GET("/:id/details", h.GetChoreDetail)
 		choresRoutes.GET("/:id/history", h.GetChoreHistory)
 		choresRoutes.PUT("/:id/history/:history_id", h.ModifyHistory)
 		choresRoutes.DELETE("/:id/history/:history_id", h.DeleteHistory)
+		choresRoutes.GET("/:id/escalation-policy", h.getChoreEscalationPolicy)
+		choresRoutes.PUT("/:id/escalation-policy", h.updateChoreEscalationPolicy)
+		choresRoutes.DELETE("/:id/escalation-policy", h.deleteChoreEscalationPolicy)
 		choresRoutes.POST("/:id/do", h.completeChore)
 		choresRoutes.POST("/:id/skip", h.skipChore)
 		choresRoutes.PUT("/:id/start", h.startChore)
@@ -0,0 +1,74 @@
+package chore
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"donetick.com/core/config"
+	cModel "donetick.com/core/internal/circle/model"
+	cRepo "donetick.com/core/internal/circle/repo"
+	uModel "donetick.com/core/internal/user/model"
+	uRepo "donetick.com/core/internal/user/repo"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newPreviewHandler(t *testing.T) (*Handler, *uModel.UserDetails) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "chore.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&uModel.User{}, &uModel.UserNotificationTarget{}, &cModel.UserCircle{}); err != nil {
+		t.Fatal(err)
+	}
+	user := &uModel.User{Username: "alex", DisplayName: "Alex", CircleID: 1, Timezone: "America/New_York"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&cModel.UserCircle{UserID: user.ID, CircleID: 1, Role: "admin"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{
+		circleRepo: cRepo.NewCircleRepository(db),
+		actions:    &Actions{userRepo: uRepo.NewUserRepository(db, &config.Config{})},
+	}
+	return h, &uModel.UserDetails{User: *user}
+}
+
+func previewRequest(h *Handler, user *uModel.UserDetails, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/chores/preview", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set("id", user)
+	h.previewChore(c)
+	return w
+}
+
+func TestPreviewAdaptiveChoreWithoutDueDate(t *testing.T) {
+	h, user := newPreviewHandler(t)
+
+	w := previewRequest(h, user, `{"name":"Water the plants","frequencyType":"adaptive","assignStrategy":"keep_last_assigned","assignees":[{"userId":`+strconv.Itoa(user.ID)+`}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("preview status = %d, body %s", w.Code, w.Body.String())
+	}
+	var res struct {
+		Res []json.RawMessage `json:"res"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Res) != 0 {
+		t.Errorf("preview of an adaptive chore without history returned %d occurrences, want none", len(res.Res))
+	}
+}
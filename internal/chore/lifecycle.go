@@ -0,0 +1,29 @@
+package chore
+
+import (
+	"context"
+
+	chRepo "donetick.com/core/internal/chore/repo"
+	"donetick.com/core/internal/events"
+	uModel "donetick.com/core/internal/user/model"
+)
+
+// choreSnapshot loads the chore with its assignees, labels and subtasks so both sides of a lifecycle diff
+// have the same shape, nil when it can't be loaded
+func choreSnapshot(c context.Context, choreRepo *chRepo.ChoreRepository, choreID int) events.Snapshot {
+	chore, err := choreRepo.GetChore(c, choreID)
+	if err != nil {
+		return nil
+	}
+	return events.NewSnapshot(chore)
+}
+
+// publishChoreChange sends the lifecycle webhook of a chore that was created or changed, before is nil for
+// created chores. it's called in the transaction of the change
+func publishChoreChange(c context.Context, ep *events.EventsProducer, choreRepo *chRepo.ChoreRepository, user *uModel.UserDetails, eventType events.EventType, choreID int, before events.Snapshot) error {
+	chore, err := choreRepo.GetChore(c, choreID)
+	if err != nil {
+		return err
+	}
+	return ep.ResourceChanged(c, user.CircleID, user.WebhookURL, eventType, events.ResourceTask, choreID, &user.User, before, events.NewSnapshot(chore))
+}
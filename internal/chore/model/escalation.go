@@ -0,0 +1,114 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxEscalationSteps limits the number of steps of an escalation policy
+const MaxEscalationSteps = 5
+
+type EscalationAction string
+
+const (
+	// EscalationActionNotifyAdmins notifies the circle admins that the chore is overdue
+	EscalationActionNotifyAdmins EscalationAction = "notify_admins"
+	// EscalationActionReassign picks a new assignee with the chore's AssignStrategy
+	EscalationActionReassign EscalationAction = "reassign"
+	// EscalationActionRaisePriority raises the chore's priority by one level
+	EscalationActionRaisePriority EscalationAction = "raise_priority"
+)
+
+// EscalationPolicy is the list of steps applied while a chore stays overdue. a policy without a chore is
+// the circle default, a chore policy replaces it and disables escalation for the chore when it has no steps
+type EscalationPolicy struct {
+	ID        int             `json:"id" gorm:"primary_key"`
+	CircleID  int             `json:"circleId" gorm:"column:circle_id;uniqueIndex:idx_escalation_policy"`
+	ChoreID   int             `json:"choreId,omitempty" gorm:"column:chore_id;uniqueIndex:idx_escalation_policy"` // 0 for the circle default
+	Steps     EscalationSteps `json:"steps" gorm:"column:steps;type:json"`
+	UpdatedBy int             `json:"updatedBy" gorm:"column:updated_by"`
+	CreatedAt time.Time       `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time       `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+// EscalationStep applies Action once the chore has been overdue for Value Unit
+type EscalationStep struct {
+	Value  int                      `json:"value"`
+	Unit   NotificationTemplateUnit `json:"unit"`
+	Action EscalationAction         `json:"action"`
+}
+
+// After returns how long the chore has to be overdue before the step applies
+func (s EscalationStep) After() (time.Duration, error) {
+	switch s.Unit {
+	case NotificationTemplateUnitMinute:
+		return time.Duration(s.Value) * time.Minute, nil
+	case NotificationTemplateUnitHour:
+		return time.Duration(s.Value) * time.Hour, nil
+	case NotificationTemplateUnitDay:
+		return time.Duration(s.Value) * 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("unsupported time unit: %s", s.Unit)
+	}
+}
+
+type EscalationSteps []EscalationStep
+
+func (s EscalationSteps) Validate() error {
+	if len(s) > MaxEscalationSteps {
+		return fmt.Errorf("escalation steps cannot exceed %d items (got %d)", MaxEscalationSteps, len(s))
+	}
+	for _, step := range s {
+		if step.Value <= 0 {
+			return fmt.Errorf("escalation step value must be positive (got %d)", step.Value)
+		}
+		if _, err := step.After(); err != nil {
+			return err
+		}
+		switch step.Action {
+		case EscalationActionNotifyAdmins, EscalationActionReassign, EscalationActionRaisePriority:
+		default:
+			return fmt.Errorf("unknown escalation action %q", step.Action)
+		}
+	}
+	return nil
+}
+
+// Sorted returns a copy of the steps ordered by how long the chore has to be overdue, steps with the
+// same delay keep their order
+func (s EscalationSteps) Sorted() EscalationSteps {
+	sorted := make(EscalationSteps, len(s))
+	copy(sorted, s)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, _ := sorted[i].After()
+		b, _ := sorted[j].After()
+		return a < b
+	})
+	return sorted
+}
+
+func (s EscalationSteps) Value() (driver.Value, error) {
+	if s == nil {
+		return json.Marshal(EscalationSteps{})
+	}
+	return json.Marshal(s)
+}
+
+func (s *EscalationSteps) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return errors.New("type assertion to []byte or string failed")
+	}
+}
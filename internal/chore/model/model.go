@@ -15,6 +15,13 @@ import (
 
 const MAX_TEMPLATES = 5
 
+var (
+	ErrNotAssigned             = errors.New("user is not assigned to chore")
+	ErrOutsideCompletionWindow = errors.New("chore is out of completion window")
+	ErrChoreNotInCircle        = errors.New("chore is not in the user's circle")
+	ErrChoreWithoutNextDueDate = errors.New("chore has no due date")
+)
+
 type FrequencyType string
 
 const (
@@ -29,6 +36,8 @@ const (
 	FrequencyTypeDayOfTheMonth FrequencyType = "day_of_the_month"
 	FrequencyTypeTrigger       FrequencyType = "trigger"
 	FrequencyTypeNoRepeat      FrequencyType = "no_repeat"
+	FrequencyTypeRRule         FrequencyType = "rrule"
+	FrequencyTypeNthDayOfMonth FrequencyType = "nth_day_of_the_month"
 )
 
 type AssignmentStrategy string
@@ -98,7 +107,7 @@ type ChoreHistory struct {
 	DueDate     *time.Time         `json:"dueDate" gorm:"column:due_date"`                    // When the chore was due
 	UpdatedAt   *time.Time         `json:"updatedAt" gorm:"column:updated_at"`                // When the record was last updated
 	CreatedAt   time.Time          `json:"createdAt" gorm:"column:created_at;autoCreateTime"` // When the record was created
-	Status      ChoreHistoryStatus `json:"status" gorm:"column:status"`                       // Status of the chore (1=completed, 2=skipped)
+	Status      ChoreHistoryStatus `json:"status" gorm:"column:status"`                       // Status of the chore (1=completed, 2=skipped, 3=escalated)
 	Points      *int               `json:"points,omitempty" gorm:"column:points"`             // Points for completing the chore
 	Duration    *int               `json:"duration,omitempty" gorm:"<-:false;-:migration"`    // Duration in seconds calculated from query (read-only, no DB column)
 }
@@ -109,6 +118,8 @@ const (
 	ChoreHistoryStatusStarted   ChoreHistoryStatus = 0
 	ChoreHistoryStatusCompleted ChoreHistoryStatus = 1
 	ChoreHistoryStatusSkipped   ChoreHistoryStatus = 2
+	// ChoreHistoryStatusEscalated records an escalation step applied to an overdue chore, it is not a completion
+	ChoreHistoryStatusEscalated ChoreHistoryStatus = 3
 )
 
 type FrequencyMetadata struct {
@@ -117,6 +128,17 @@ type FrequencyMetadata struct {
 	Unit     *string   `json:"unit,omitempty"`
 	Time     string    `json:"time,omitempty"`
 	Timezone string    `json:"timezone,omitempty"`
+	RRule    string    `json:"rrule,omitempty"` // RFC 5545 recurrence (RRULE with optional DTSTART/EXDATE lines) used by FrequencyTypeRRule
+
+	Occurrences []*MonthlyOccurrence `json:"occurrences,omitempty"` // Days in the month used by FrequencyTypeNthDayOfMonth, filtered by Months
+}
+
+// MonthlyOccurrence describes a day within a month, e.g. "first Monday" ({1, monday}), "last Friday" ({-1, friday})
+// or "last day of the month" ({-1, nil}). Positive ordinals count from the start of the month and negative ones from the end.
+// Without a weekday the ordinal is the day of the month itself.
+type MonthlyOccurrence struct {
+	Ordinal int     `json:"ordinal"`
+	Weekday *string `json:"weekday,omitempty"`
 }
 
 type NotificationMetadata struct {
@@ -126,6 +148,7 @@ type NotificationMetadata struct {
 	PreDue        bool                    `json:"predue,omitempty"`
 	CircleGroup   bool                    `json:"circleGroup,omitempty"`
 	CircleGroupID *int64                  `json:"circleGroupID,omitempty"`
+	MatrixRoomID  *string                 `json:"matrixRoomID,omitempty"`               // Matrix room that gets the circle group notifications
 	Templates     []*NotificationTemplate `json:"templates,omitempty" validate:"max=5"` // Template for notification
 }
 
@@ -168,6 +191,14 @@ type ChoreDetail struct {
 	TimerUpdatedAt      *time.Time         `json:"timerUpdatedAt" gorm:"column:timer_updated_at"` // When the chore was last started
 }
 
+// CompletedChore is a completion joined with its chore name, used by the notification digests
+type CompletedChore struct {
+	ChoreID     int       `json:"choreId" gorm:"column:chore_id"`
+	Name        string    `json:"name" gorm:"column:name"`
+	CompletedBy int       `json:"completedBy" gorm:"column:completed_by"`
+	PerformedAt time.Time `json:"performedAt" gorm:"column:performed_at"`
+}
+
 type Label struct {
 	ID        int    `json:"id" gorm:"primary_key"`
 	Name      string `json:"name" gorm:"column:name"`
@@ -215,6 +246,12 @@ type ChoreReq struct {
 	UpdatedAt            *time.Time            `json:"updatedAt,omitempty"` // For internal use only when syncing a chore updated offline
 }
 
+// ChoreOccurrence is a predicted future due date of a chore and who it will be assigned to at that point.
+type ChoreOccurrence struct {
+	DueDate    time.Time `json:"dueDate"`
+	AssignedTo int       `json:"assignedTo"`
+}
+
 func (c *Chore) CanEdit(userID int, circleUsers []*cModel.UserCircleDetail, updatedAt *time.Time) error {
 	userHasPermission := false
 	choreCanModified := true
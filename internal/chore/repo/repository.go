@@ -9,10 +9,13 @@ import (
 	config "donetick.com/core/config"
 	chModel "donetick.com/core/internal/chore/model"
 	cModel "donetick.com/core/internal/circle/model"
+	"donetick.com/core/internal/database/dbtx"
+	nModel "donetick.com/core/internal/notifier/model"
 	storageModel "donetick.com/core/internal/storage/model"
 	stModel "donetick.com/core/internal/subtask/model"
 	"donetick.com/core/logging"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChoreRepository struct {
@@ -24,12 +27,18 @@ func NewChoreRepository(db *gorm.DB, cfg *config.Config) *ChoreRepository {
 	return &ChoreRepository{db: db, dbType: cfg.Database.Type}
 }
 
+// Transaction calls fn in a transaction carried by its context, the chore writes and events published
+// in fn are committed together
+func (r *ChoreRepository) Transaction(c context.Context, fn func(c context.Context) error) error {
+	return dbtx.Run(c, r.db, fn)
+}
+
 func (r *ChoreRepository) UpsertChore(c context.Context, chore *chModel.Chore) error {
-	return r.db.WithContext(c).Model(&chore).Save(chore).Error
+	return dbtx.DB(c, r.db).Model(&chore).Save(chore).Error
 }
 func (r *ChoreRepository) UpdateChorePriority(c context.Context, userID int, choreID int, priority int) error {
 	var affectedRows int64
-	r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ? and created_by = ?", choreID, userID).Update("priority", priority).Count(&affectedRows)
+	dbtx.DB(c, r.db).Model(&chModel.Chore{}).Where("id = ? and created_by = ?", choreID, userID).Update("priority", priority).Count(&affectedRows)
 	if affectedRows == 0 {
 		return errors.New("no rows affected")
 	}
@@ -37,14 +46,14 @@ func (r *ChoreRepository) UpdateChorePriority(c context.Context, userID int, cho
 }
 
 func (r *ChoreRepository) UpdateChoreFields(ctx context.Context, choreID int, fields map[string]interface{}) error {
-	return r.db.WithContext(ctx).Model(&chModel.Chore{}).Where("id = ?", choreID).Updates(fields).Error
+	return dbtx.DB(ctx, r.db).Model(&chModel.Chore{}).Where("id = ?", choreID).Updates(fields).Error
 }
 
 func (r *ChoreRepository) UpdateChores(c context.Context, chores []*chModel.Chore) error {
-	return r.db.WithContext(c).Save(&chores).Error
+	return dbtx.DB(c, r.db).Save(&chores).Error
 }
 func (r *ChoreRepository) CreateChore(c context.Context, chore *chModel.Chore) (int, error) {
-	if err := r.db.WithContext(c).Create(chore).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Create(chore).Error; err != nil {
 		return 0, err
 	}
 	return chore.ID, nil
@@ -52,7 +61,7 @@ func (r *ChoreRepository) CreateChore(c context.Context, chore *chModel.Chore) (
 
 func (r *ChoreRepository) GetChore(c context.Context, choreID int) (*chModel.Chore, error) {
 	var chore chModel.Chore
-	if err := r.db.Debug().WithContext(c).Model(&chModel.Chore{}).Preload("SubTasks", "chore_id = ?", choreID).Preload("Assignees").Preload("ThingChore").Preload("LabelsV2").First(&chore, choreID).Error; err != nil {
+	if err := dbtx.DB(c, r.db.Debug()).Model(&chModel.Chore{}).Preload("SubTasks", "chore_id = ?", choreID).Preload("Assignees").Preload("ThingChore").Preload("LabelsV2").First(&chore, choreID).Error; err != nil {
 		return nil, err
 	}
 	return &chore, nil
@@ -60,7 +69,7 @@ func (r *ChoreRepository) GetChore(c context.Context, choreID int) (*chModel.Cho
 
 func (r *ChoreRepository) GetChores(c context.Context, circleID int, userID int, includeArchived bool) ([]*chModel.Chore, error) {
 	var chores []*chModel.Chore
-	query := r.db.WithContext(c).Preload("Assignees").Preload("LabelsV2").Joins("left join chore_assignees on chores.id = chore_assignees.chore_id").Where("chores.circle_id = ? AND (chores.created_by = ? OR chore_assignees.user_id = ?)", circleID, userID, userID).Group("chores.id").Order("next_due_date asc")
+	query := dbtx.DB(c, r.db).Preload("Assignees").Preload("LabelsV2").Joins("left join chore_assignees on chores.id = chore_assignees.chore_id").Where("chores.circle_id = ? AND (chores.created_by = ? OR chore_assignees.user_id = ?)", circleID, userID, userID).Group("chores.id").Order("next_due_date asc")
 	if !includeArchived {
 		query = query.Where("chores.is_active = ?", true)
 	}
@@ -72,13 +81,13 @@ func (r *ChoreRepository) GetChores(c context.Context, circleID int, userID int,
 
 func (r *ChoreRepository) GetArchivedChores(c context.Context, circleID int, userID int) ([]*chModel.Chore, error) {
 	var chores []*chModel.Chore
-	if err := r.db.WithContext(c).Preload("Assignees").Preload("LabelsV2").Joins("left join chore_assignees on chores.id = chore_assignees.chore_id").Where("chores.circle_id = ? AND (chores.created_by = ? OR chore_assignees.user_id = ?)", circleID, userID, userID).Group("chores.id").Order("next_due_date asc").Find(&chores, "circle_id = ? AND is_active = ?", circleID, false).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Preload("Assignees").Preload("LabelsV2").Joins("left join chore_assignees on chores.id = chore_assignees.chore_id").Where("chores.circle_id = ? AND (chores.created_by = ? OR chore_assignees.user_id = ?)", circleID, userID, userID).Group("chores.id").Order("next_due_date asc").Find(&chores, "circle_id = ? AND is_active = ?", circleID, false).Error; err != nil {
 		return nil, err
 	}
 	return chores, nil
 }
 func (r *ChoreRepository) DeleteChore(c context.Context, id int) error {
-	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("chore_id = ?", id).Delete(&chModel.ChoreAssignees{}).Error; err != nil {
 			return err
 		}
@@ -88,6 +97,9 @@ func (r *ChoreRepository) DeleteChore(c context.Context, id int) error {
 		if err := tx.Delete(&chModel.ChoreHistory{}, "chore_id = ?", id).Error; err != nil {
 			return err
 		}
+		if err := tx.Delete(&chModel.EscalationPolicy{}, "chore_id = ?", id).Error; err != nil {
+			return err
+		}
 		// subtask if exists:
 		if err := tx.Where("chore_id = ?", id).Delete(&stModel.SubTask{}).Error; err != nil {
 			return err
@@ -109,18 +121,18 @@ func (r *ChoreRepository) DeleteChore(c context.Context, id int) error {
 }
 
 func (r *ChoreRepository) SoftDelete(c context.Context, id int, userID int) error {
-	return r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ?", id).Where("created_by = ? ", userID).Update("is_active", false).Error
+	return dbtx.DB(c, r.db).Model(&chModel.Chore{}).Where("id = ?", id).Where("created_by = ? ", userID).Update("is_active", false).Error
 
 }
 
 func (r *ChoreRepository) IsChoreOwner(c context.Context, choreID int, userID int) error {
 	var chore chModel.Chore
-	err := r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ? AND created_by = ?", choreID, userID).First(&chore).Error
+	err := dbtx.DB(c, r.db).Model(&chModel.Chore{}).Where("id = ? AND created_by = ?", choreID, userID).First(&chore).Error
 	return err
 }
 
 func (r *ChoreRepository) CompleteChore(c context.Context, chore *chModel.Chore, note *string, userID int, dueDate *time.Time, completedDate *time.Time, nextAssignedTo int, applyPoints bool) error {
-	err := r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+	err := dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
 
 		choreUpdates := map[string]interface{}{}
 		choreUpdates["next_due_date"] = dueDate
@@ -194,7 +206,7 @@ func (r *ChoreRepository) CompleteChore(c context.Context, chore *chModel.Chore,
 }
 
 func (r *ChoreRepository) SkipChore(c context.Context, chore *chModel.Chore, userID int, dueDate *time.Time, nextAssignedTo int) error {
-	err := r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+	err := dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
 		choreUpdates := map[string]interface{}{}
 		choreUpdates["next_due_date"] = dueDate
 		choreUpdates["status"] = chModel.ChoreStatusNoStatus
@@ -262,26 +274,41 @@ func (r *ChoreRepository) SkipChore(c context.Context, chore *chModel.Chore, use
 	return err
 }
 
+// GetChoreHistory returns the completions and skips of the chore, the scheduling and assignee rotation
+// work on these
 func (r *ChoreRepository) GetChoreHistory(c context.Context, choreID int) ([]*chModel.ChoreHistory, error) {
+	return r.getChoreHistory(c, choreID, false)
+}
+
+// GetChoreHistoryWithEscalations returns the chore's history including the escalation steps applied while
+// it was overdue, it's what the chore history endpoint shows
+func (r *ChoreRepository) GetChoreHistoryWithEscalations(c context.Context, choreID int) ([]*chModel.ChoreHistory, error) {
+	return r.getChoreHistory(c, choreID, true)
+}
+
+func (r *ChoreRepository) getChoreHistory(c context.Context, choreID int, withEscalations bool) ([]*chModel.ChoreHistory, error) {
 	var histories []*chModel.ChoreHistory
-	if err := r.db.WithContext(c).
+	query := dbtx.DB(c, r.db).
 		Table("chore_histories").
 		Select("chore_histories.*, time_sessions.duration").
 		Joins("LEFT JOIN time_sessions ON chore_histories.id = time_sessions.chore_history_id").
-		Where("chore_histories.chore_id = ?", choreID).
-		Order("chore_histories.updated_at desc").
-		Find(&histories).Error; err != nil {
+		Where("chore_histories.chore_id = ?", choreID)
+	if !withEscalations {
+		query = query.Where("chore_histories.status <> ?", chModel.ChoreHistoryStatusEscalated)
+	}
+	if err := query.Order("chore_histories.updated_at desc").Find(&histories).Error; err != nil {
 		return nil, err
 	}
 	return histories, nil
 }
+
 func (r *ChoreRepository) GetChoreHistoryWithLimit(c context.Context, choreID int, limit int) ([]*chModel.ChoreHistory, error) {
 	var histories []*chModel.ChoreHistory
-	if err := r.db.WithContext(c).
+	if err := dbtx.DB(c, r.db).
 		Table("chore_histories").
 		Select("chore_histories.*, time_sessions.duration").
 		Joins("LEFT JOIN time_sessions ON chore_histories.id = time_sessions.chore_history_id").
-		Where("chore_histories.chore_id = ?", choreID).
+		Where("chore_histories.chore_id = ? AND chore_histories.status <> ?", choreID, chModel.ChoreHistoryStatusEscalated).
 		Order("chore_histories.performed_at desc").
 		Limit(limit).
 		Find(&histories).Error; err != nil {
@@ -292,27 +319,27 @@ func (r *ChoreRepository) GetChoreHistoryWithLimit(c context.Context, choreID in
 
 func (r *ChoreRepository) GetChoreHistoryByID(c context.Context, choreID int, historyID int) (*chModel.ChoreHistory, error) {
 	var history chModel.ChoreHistory
-	if err := r.db.WithContext(c).Where("id = ? and chore_id = ? ", historyID, choreID).First(&history).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Where("id = ? and chore_id = ? ", historyID, choreID).First(&history).Error; err != nil {
 		return nil, err
 	}
 	return &history, nil
 }
 
 func (r *ChoreRepository) UpdateChoreHistory(c context.Context, history *chModel.ChoreHistory) error {
-	return r.db.WithContext(c).Save(history).Error
+	return dbtx.DB(c, r.db).Save(history).Error
 }
 
 func (r *ChoreRepository) UpdateLatestChoreHistory(c context.Context, choreID int, updates map[string]interface{}) error {
 	//get the latest chore history for the given chore ID
 	var latestHistory chModel.ChoreHistory
-	if err := r.db.WithContext(c).Where("chore_id = ?", choreID).Order("created_at desc").First(&latestHistory).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Where("chore_id = ? AND status <> ?", choreID, chModel.ChoreHistoryStatusEscalated).Order("created_at desc").First(&latestHistory).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return fmt.Errorf("no history found for chore ID %d", choreID)
 		}
 		return err
 	}
 	// Update the latest history with the provided updates
-	if err := r.db.WithContext(c).Model(&latestHistory).Updates(updates).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Model(&latestHistory).Updates(updates).Error; err != nil {
 		return fmt.Errorf("failed to update latest chore history: %w", err)
 	}
 	return nil
@@ -320,7 +347,7 @@ func (r *ChoreRepository) UpdateLatestChoreHistory(c context.Context, choreID in
 
 func (r *ChoreRepository) DeleteChoreHistory(c context.Context, historyID int) error {
 	// create transaction and delete all the chore timer assiociated with the chore history then delete the chore history
-	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
 		if err := tx.WithContext(c).Where("chore_history_id = ?", historyID).Delete(&chModel.TimeSession{}).Error; err != nil {
 			return fmt.Errorf("failed to delete chore time sessions: %w", err)
 		}
@@ -334,34 +361,37 @@ func (r *ChoreRepository) DeleteChoreHistory(c context.Context, historyID int) e
 }
 
 func (r *ChoreRepository) UpdateChoreAssignees(c context.Context, assignees []*chModel.ChoreAssignees) error {
-	return r.db.WithContext(c).Save(&assignees).Error
+	return dbtx.DB(c, r.db).Save(&assignees).Error
 }
 
 func (r *ChoreRepository) DeleteChoreAssignees(c context.Context, choreAssignees []*chModel.ChoreAssignees) error {
-	return r.db.WithContext(c).Delete(&choreAssignees).Error
+	return dbtx.DB(c, r.db).Delete(&choreAssignees).Error
 }
 
 func (r *ChoreRepository) GetChoreAssignees(c context.Context, choreID int) ([]*chModel.ChoreAssignees, error) {
 	var assignees []*chModel.ChoreAssignees
-	if err := r.db.WithContext(c).Find(&assignees, "chore_id = ?", choreID).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Find(&assignees, "chore_id = ?", choreID).Error; err != nil {
 		return nil, err
 	}
 	return assignees, nil
 }
 
 func (r *ChoreRepository) RemoveChoreAssigneeByCircleID(c context.Context, userID int, circleID int) error {
-	return r.db.WithContext(c).Where("user_id = ? AND chore_id IN (SELECT id FROM chores WHERE circle_id = ? and created_by != ?)", userID, circleID, userID).Delete(&chModel.ChoreAssignees{}).Error
+	return dbtx.DB(c, r.db).Where("user_id = ? AND chore_id IN (SELECT id FROM chores WHERE circle_id = ? and created_by != ?)", userID, circleID, userID).Delete(&chModel.ChoreAssignees{}).Error
 }
 
 // func (r *ChoreReposity) GetOverdueChoresForNotification(c context.Context, overdueDuration time.Duration, everyDuration time.Duration, untilDuration time.Duration) ([]*chModel.Chore, error) {
 // 	var chores []*chModel.Chore
-// 	query := r.db.Debug().WithContext(c).Table("chores").Select("chores.*, MAX(n.created_at) as max_notification_created_at").Joins("left join notifications n on n.chore_id = chores.id and n.scheduled_for = chores.next_due_date and n.type = 2")
+// 	query := dbtx.DB(c, r.db.Debug()).Table("chores").Select("chores.*, MAX(n.created_at) as max_notification_created_at").Joins("left join notifications n on n.chore_id = chores.id and n.scheduled_for = chores.next_due_date and n.type = 2")
 // 	if err := query.Where("chores.is_active = ? and chores.notification = ? and chores.next_due_date < ? and chores.next_due_date > ?", true, true, time.Now().Add(overdueDuration).UTC(), time.Now().Add(untilDuration).UTC()).Where(readJSONBooleanField(r.dbType, "chores.notification_meta", "nagging")).Having("MAX(n.created_at) is null or MAX(n.created_at) < ?", time.Now().Add(everyDuration).UTC()).Group("chores.id").Find(&chores).Error; err != nil {
 // 		return nil, err
 // 	}
 // 	return chores, nil
 // }
 
+// GetOverdueChoresForNotification returns active chores with nagging enabled that have been overdue for at
+// least overdueFor but no longer than until, and that have not been nagged within the last every duration
+// for their current due date.
 func (r *ChoreRepository) GetOverdueChoresForNotification(c context.Context, overdueFor time.Duration, everyDuration time.Duration, untilDuration time.Duration) ([]*chModel.Chore, error) {
 	var chores []*chModel.Chore
 	now := time.Now().UTC()
@@ -369,12 +399,12 @@ func (r *ChoreRepository) GetOverdueChoresForNotification(c context.Context, ove
 	everyTime := now.Add(-everyDuration)
 	untilTime := now.Add(-untilDuration)
 
-	query := r.db.Debug().WithContext(c).
+	query := dbtx.DB(c, r.db).
 		Table("chores").
-		Select("chores.*, MAX(n.created_at) as max_notification_created_at").
-		Joins("left join notifications n on n.chore_id = chores.id and n.type = 2").
+		Select("chores.*").
+		Joins("left join notifications n on n.chore_id = chores.id and n.event_type = ? and n.scheduled_for >= chores.next_due_date", nModel.EventTypeNagging).
 		Where("chores.is_active = ? AND chores.notification = ? AND chores.next_due_date < ? AND chores.next_due_date > ?", true, true, overdueTime, untilTime).
-		Where(readJSONBooleanField(r.dbType, "chores.notification_meta", "nagging")).
+		Where(readJSONBooleanField(r.dbType, "chores.notification_meta_v2", "nagging")).
 		Group("chores.id").
 		Having("MAX(n.created_at) IS NULL OR MAX(n.created_at) < ?", everyTime)
 
@@ -385,10 +415,58 @@ func (r *ChoreRepository) GetOverdueChoresForNotification(c context.Context, ove
 	return chores, nil
 }
 
+// GetAssignedChoresDueBefore returns the active chores assigned to the user that are due before the given time
+func (r *ChoreRepository) GetAssignedChoresDueBefore(c context.Context, circleID int, userID int, before time.Time) ([]*chModel.Chore, error) {
+	var chores []*chModel.Chore
+	if err := dbtx.DB(c, r.db).
+		Where("circle_id = ? AND assigned_to = ? AND is_active = ? AND next_due_date IS NOT NULL AND next_due_date < ?", circleID, userID, true, before).
+		Order("next_due_date asc").
+		Find(&chores).Error; err != nil {
+		return nil, err
+	}
+	return chores, nil
+}
+
+// GetActiveCircleChores returns all of the circle's active chores
+func (r *ChoreRepository) GetActiveCircleChores(c context.Context, circleID int) ([]*chModel.Chore, error) {
+	var chores []*chModel.Chore
+	if err := dbtx.DB(c, r.db).Where("circle_id = ? AND is_active = ?", circleID, true).Find(&chores).Error; err != nil {
+		return nil, err
+	}
+	return chores, nil
+}
+
+// GetCircleChoresDueBefore returns the circle's active chores due before the given time, whoever they are assigned to
+func (r *ChoreRepository) GetCircleChoresDueBefore(c context.Context, circleID int, before time.Time) ([]*chModel.Chore, error) {
+	var chores []*chModel.Chore
+	if err := dbtx.DB(c, r.db).
+		Where("circle_id = ? AND is_active = ? AND next_due_date IS NOT NULL AND next_due_date < ?", circleID, true, before).
+		Order("next_due_date asc").
+		Find(&chores).Error; err != nil {
+		return nil, err
+	}
+	return chores, nil
+}
+
+// GetCompletedChores returns the circle's completions performed in [from, to)
+func (r *ChoreRepository) GetCompletedChores(c context.Context, circleID int, from time.Time, to time.Time) ([]*chModel.CompletedChore, error) {
+	var completed []*chModel.CompletedChore
+	if err := dbtx.DB(c, r.db).
+		Table("chore_histories").
+		Select("chore_histories.chore_id, chores.name, chore_histories.completed_by, chore_histories.performed_at").
+		Joins("JOIN chores ON chore_histories.chore_id = chores.id").
+		Where("chores.circle_id = ? AND chore_histories.status = ? AND chore_histories.performed_at >= ? AND chore_histories.performed_at < ?", circleID, chModel.ChoreHistoryStatusCompleted, from, to).
+		Order("chore_histories.performed_at asc").
+		Scan(&completed).Error; err != nil {
+		return nil, err
+	}
+	return completed, nil
+}
+
 // a predue notfication is a notification send before the due date in 6 hours, 3 hours :
 func (r *ChoreRepository) GetPreDueChoresForNotification(c context.Context, preDueDuration time.Duration, everyDuration time.Duration) ([]*chModel.Chore, error) {
 	var chores []*chModel.Chore
-	query := r.db.WithContext(c).Table("chores").Select("chores.*, MAX(n.created_at) as max_notification_created_at").Joins("left join notifications n on n.chore_id = chores.id and n.scheduled_for = chores.next_due_date and n.type = 3")
+	query := dbtx.DB(c, r.db).Table("chores").Select("chores.*, MAX(n.created_at) as max_notification_created_at").Joins("left join notifications n on n.chore_id = chores.id and n.scheduled_for = chores.next_due_date and n.type = 3")
 	if err := query.Where("chores.is_active = ? and chores.notification = ? and chores.next_due_date > ? and chores.next_due_date < ?", true, true, time.Now().UTC(), time.Now().Add(everyDuration*2).UTC()).Where(readJSONBooleanField(r.dbType, "chores.notification_meta", "predue")).Having("MAX(n.created_at) is null or MAX(n.created_at) < ?", time.Now().Add(everyDuration).UTC()).Group("chores.id").Find(&chores).Error; err != nil {
 		return nil, err
 	}
@@ -403,19 +481,19 @@ func readJSONBooleanField(dbType string, columnName string, fieldName string) st
 }
 
 func (r *ChoreRepository) SetDueDate(c context.Context, choreID int, dueDate time.Time) error {
-	return r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ?", choreID).Updates(map[string]interface{}{
+	return dbtx.DB(c, r.db).Model(&chModel.Chore{}).Where("id = ?", choreID).Updates(map[string]interface{}{
 		"next_due_date": dueDate,
 		"is_active":     true,
 	}).Error
 }
 
 func (r *ChoreRepository) SetDueDateIfNotExisted(c context.Context, choreID int, dueDate time.Time) error {
-	return r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ? and next_due_date is null and is_active = ?", choreID, true).Update("next_due_date", dueDate).Error
+	return dbtx.DB(c, r.db).Model(&chModel.Chore{}).Where("id = ? and next_due_date is null and is_active = ?", choreID, true).Update("next_due_date", dueDate).Error
 }
 
 func (r *ChoreRepository) GetChoreDetailByID(c context.Context, choreID int, circleID int) (*chModel.ChoreDetail, error) {
 	var choreDetail chModel.ChoreDetail
-	if err := r.db.WithContext(c).
+	if err := dbtx.DB(c, r.db).
 		Table("chores").
 		Preload("Subtasks").
 		Select(`
@@ -436,7 +514,7 @@ func (r *ChoreRepository) GetChoreDetailByID(c context.Context, choreID int, cir
 		recent_history.notes,
         recent_history.last_assigned_to as last_completed_by,
         COUNT(chore_histories.id) as total_completed`).
-		Joins("LEFT JOIN chore_histories ON chores.id = chore_histories.chore_id").
+		Joins("LEFT JOIN chore_histories ON chores.id = chore_histories.chore_id AND chore_histories.status <> ?", chModel.ChoreHistoryStatusEscalated).
 		Joins(`LEFT JOIN (
         SELECT 
             chore_id, 
@@ -462,24 +540,24 @@ func (r *ChoreRepository) GetChoreDetailByID(c context.Context, choreID int, cir
 }
 
 func (r *ChoreRepository) ArchiveChore(c context.Context, choreID int, userID int) error {
-	return r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ? and created_by = ?", choreID, userID).Update("is_active", false).Error
+	return dbtx.DB(c, r.db).Model(&chModel.Chore{}).Where("id = ? and created_by = ?", choreID, userID).Update("is_active", false).Error
 }
 
 func (r *ChoreRepository) UnarchiveChore(c context.Context, choreID int, userID int) error {
-	return r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ? and created_by = ?", choreID, userID).Update("is_active", true).Error
+	return dbtx.DB(c, r.db).Model(&chModel.Chore{}).Where("id = ? and created_by = ?", choreID, userID).Update("is_active", true).Error
 }
 
 func (r *ChoreRepository) GetChoresHistoryByUserID(c context.Context, userID int, circleID int, days int, includeCircle bool) ([]*chModel.ChoreHistory, error) {
 
 	var chores []*chModel.ChoreHistory
 	since := time.Now().AddDate(0, 0, days*-1)
-	query := r.db.WithContext(c).
+	query := dbtx.DB(c, r.db).
 		Table("chore_histories").
 		Select("chore_histories.*, circles.id as circle_id, time_sessions.duration, time_sessions.start_time, time_sessions.updated_at as timer_updated_at").
 		Joins("LEFT JOIN chores ON chore_histories.chore_id = chores.id").
 		Joins("LEFT JOIN circles ON chores.circle_id = circles.id").
 		Joins("LEFT JOIN time_sessions ON chore_histories.id = time_sessions.chore_history_id").
-		Where("circles.id = ? AND chore_histories.updated_at > ?", circleID, since).
+		Where("circles.id = ? AND chore_histories.updated_at > ? AND chore_histories.status <> ?", circleID, since, chModel.ChoreHistoryStatusEscalated).
 		Order("chore_histories.performed_at desc, chore_histories.updated_at desc")
 
 	if !includeCircle {
@@ -493,12 +571,12 @@ func (r *ChoreRepository) GetChoresHistoryByUserID(c context.Context, userID int
 }
 
 func (r *ChoreRepository) UpdateChoreStatus(c context.Context, choreID int, status chModel.Status) error {
-	return r.db.WithContext(c).Model(&chModel.Chore{}).Where("id = ?", choreID).Update("status", status).Error
+	return dbtx.DB(c, r.db).Model(&chModel.Chore{}).Where("id = ?", choreID).Update("status", status).Error
 }
 
 func (r *ChoreRepository) GetActiveTimeSession(c context.Context, choreID int) (*chModel.TimeSession, error) {
 	var session chModel.TimeSession
-	if err := r.db.WithContext(c).Where("chore_id = ? AND status < 2", choreID).First(&session).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Where("chore_id = ? AND status < 2", choreID).First(&session).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // No active session found
 		}
@@ -510,7 +588,7 @@ func (r *ChoreRepository) GetActiveTimeSession(c context.Context, choreID int) (
 func (r *ChoreRepository) CreateTimeSession(c context.Context, chore *chModel.Chore, userID int) (*chModel.TimeSession, error) {
 	log := logging.FromContext(c)
 	var timeSession *chModel.TimeSession
-	err := r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+	err := dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
 		ch := &chModel.ChoreHistory{
 			ChoreID:     chore.ID,
 			CompletedBy: userID,
@@ -540,12 +618,12 @@ func (r *ChoreRepository) CreateTimeSession(c context.Context, chore *chModel.Ch
 }
 
 func (r *ChoreRepository) UpdateTimeSession(c context.Context, session *chModel.TimeSession) error {
-	return r.db.WithContext(c).Save(session).Error
+	return dbtx.DB(c, r.db).Save(session).Error
 }
 
 func (r *ChoreRepository) CompleteTimeSession(c context.Context, session *chModel.TimeSession, chore *chModel.Chore, userID int) error {
 	log := logging.FromContext(c)
-	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
 		session.Finish(userID)
 		if err := tx.Save(session).Error; err != nil {
 			log.Errorf("Failed to complete time session: %v", err)
@@ -561,12 +639,12 @@ func (r *ChoreRepository) GetTimeSessionsByChoreID(c context.Context, choreID in
 
 	if choreHistoryId != nil {
 		// Get sessions for specific chore history ID
-		if err := r.db.WithContext(c).Where("chore_id = ? AND chore_history_id = ?", choreID, *choreHistoryId).Find(&session).Error; err != nil {
+		if err := dbtx.DB(c, r.db).Where("chore_id = ? AND chore_history_id = ?", choreID, *choreHistoryId).Find(&session).Error; err != nil {
 			return nil, err
 		}
 	} else {
 		// Get sessions for the most recent chore history (based on due_date)
-		query := r.db.WithContext(c).
+		query := dbtx.DB(c, r.db).
 			Table("time_sessions").
 			Select("time_sessions.*").
 			Joins("LEFT JOIN chore_histories ON time_sessions.chore_history_id = chore_histories.id").
@@ -583,7 +661,7 @@ func (r *ChoreRepository) GetTimeSessionsByChoreID(c context.Context, choreID in
 
 func (r *ChoreRepository) GetTimeSessionByID(c context.Context, sessionID int) (*chModel.TimeSession, error) {
 	var session chModel.TimeSession
-	if err := r.db.WithContext(c).First(&session, sessionID).Error; err != nil {
+	if err := dbtx.DB(c, r.db).First(&session, sessionID).Error; err != nil {
 		return nil, err
 	}
 	return &session, nil
@@ -599,11 +677,11 @@ func (r *ChoreRepository) UpdateTimeSessionData(c context.Context, session *chMo
 	}
 
 	session.UpdateAt = time.Now().UTC()
-	return r.db.WithContext(c).Save(session).Error
+	return dbtx.DB(c, r.db).Save(session).Error
 }
 
 func (r *ChoreRepository) DeleteTimeSession(c context.Context, sessionID int, choreID int) error {
-	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
 		// delete existing choreHistory linked to the time session:
 		if err := tx.Where(
 			"chore_id = ? and status = ?", choreID, chModel.ChoreHistoryStatusStarted,
@@ -622,3 +700,73 @@ func (r *ChoreRepository) DeleteTimeSession(c context.Context, sessionID int, ch
 	// delete where session ID matches and chore ID matches
 
 }
+
+// GetEscalationPolicy returns the policy of the chore, or the circle default when choreID is 0. nil when there is none
+func (r *ChoreRepository) GetEscalationPolicy(c context.Context, circleID int, choreID int) (*chModel.EscalationPolicy, error) {
+	var policy chModel.EscalationPolicy
+	if err := dbtx.DB(c, r.db).Where("circle_id = ? AND chore_id = ?", circleID, choreID).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// GetEscalationPolicies returns every circle default and chore policy
+func (r *ChoreRepository) GetEscalationPolicies(c context.Context) ([]*chModel.EscalationPolicy, error) {
+	var policies []*chModel.EscalationPolicy
+	if err := dbtx.DB(c, r.db).Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// UpsertEscalationPolicy creates the policy or replaces the steps of the existing one for the same circle and chore
+func (r *ChoreRepository) UpsertEscalationPolicy(c context.Context, policy *chModel.EscalationPolicy) error {
+	return dbtx.DB(c, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "circle_id"}, {Name: "chore_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"steps", "updated_by", "updated_at"}),
+	}).Create(policy).Error
+}
+
+func (r *ChoreRepository) DeleteEscalationPolicy(c context.Context, circleID int, choreID int) (int64, error) {
+	result := dbtx.DB(c, r.db).Where("circle_id = ? AND chore_id = ?", circleID, choreID).Delete(&chModel.EscalationPolicy{})
+	return result.RowsAffected, result.Error
+}
+
+// GetOverdueChoresForEscalation returns the active chores overdue at now in the circles that have an escalation policy
+func (r *ChoreRepository) GetOverdueChoresForEscalation(c context.Context, now time.Time) ([]*chModel.Chore, error) {
+	var chores []*chModel.Chore
+	if err := dbtx.DB(c, r.db).
+		Preload("Assignees").
+		Where("is_active = ? AND next_due_date IS NOT NULL AND next_due_date < ?", true, now).
+		Where("circle_id IN (?)", r.db.Model(&chModel.EscalationPolicy{}).Select("circle_id")).
+		Find(&chores).Error; err != nil {
+		return nil, err
+	}
+	return chores, nil
+}
+
+// GetEscalations returns the escalation steps applied to the chore since it became due at dueDate
+func (r *ChoreRepository) GetEscalations(c context.Context, choreID int, dueDate time.Time) ([]*chModel.ChoreHistory, error) {
+	var histories []*chModel.ChoreHistory
+	if err := dbtx.DB(c, r.db).
+		Where("chore_id = ? AND status = ? AND created_at >= ?", choreID, chModel.ChoreHistoryStatusEscalated, dueDate).
+		Order("created_at asc").
+		Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	// the due date is compared here, timestamps don't compare reliably across databases
+	escalations := make([]*chModel.ChoreHistory, 0, len(histories))
+	for _, history := range histories {
+		if history.DueDate != nil && history.DueDate.Equal(dueDate) {
+			escalations = append(escalations, history)
+		}
+	}
+	return escalations, nil
+}
+
+func (r *ChoreRepository) AddChoreHistory(c context.Context, history *chModel.ChoreHistory) error {
+	return dbtx.DB(c, r.db).Create(history).Error
+}
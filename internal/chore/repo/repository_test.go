@@ -0,0 +1,104 @@
+package chore
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	chModel "donetick.com/core/internal/chore/model"
+	nModel "donetick.com/core/internal/notifier/model"
+	nRepo "donetick.com/core/internal/notifier/repo"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRepository(t *testing.T) (*ChoreRepository, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "chores.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&chModel.Chore{}, &nModel.Notification{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewChoreRepository(db, &config.Config{}), db
+}
+
+func TestGetOverdueChoresForNotification(t *testing.T) {
+	ctx := context.Background()
+	repo, db := newTestRepository(t)
+	now := time.Now().UTC()
+	interval := 24 * time.Hour
+	cutoff := 7 * 24 * time.Hour
+
+	nagging := &chModel.NotificationMetadata{Nagging: true}
+	overdue := func(id int, name string, overdueFor time.Duration, metadata *chModel.NotificationMetadata) *chModel.Chore {
+		dueDate := now.Add(-overdueFor)
+		return &chModel.Chore{ID: id, Name: name, CircleID: 1, IsActive: true, Notification: true, NotificationMetadataV2: metadata, NextDueDate: &dueDate}
+	}
+	chores := []*chModel.Chore{
+		overdue(1, "never nagged", 2*24*time.Hour, nagging),
+		overdue(2, "nagged inside the interval", 2*24*time.Hour, nagging),
+		overdue(3, "nagged before the interval", 3*24*time.Hour, nagging),
+		overdue(4, "nagged for an earlier due date", 2*24*time.Hour, nagging),
+		overdue(5, "not overdue for an interval yet", time.Hour, nagging),
+		overdue(6, "past the cutoff", 8*24*time.Hour, nagging),
+		overdue(7, "nagging disabled", 2*24*time.Hour, &chModel.NotificationMetadata{}),
+	}
+	for _, chore := range chores {
+		if err := db.Omit(clause.Associations).Create(chore).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	nag := func(choreID int, at time.Time) *nModel.Notification {
+		return &nModel.Notification{ChoreID: choreID, CircleID: 1, EventType: nModel.EventTypeNagging, IsSent: true, ScheduledFor: at, CreatedAt: at}
+	}
+	notificationRepo := nRepo.NewNotificationRepository(db)
+	if err := notificationRepo.BatchInsertNotifications([]*nModel.Notification{
+		nag(2, now.Add(-time.Hour)),
+		nag(3, now.Add(-25*time.Hour)),
+		nag(4, now.Add(-3*24*time.Hour)),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertNagged := func(want ...int) {
+		t.Helper()
+		got, err := repo.GetOverdueChoresForNotification(ctx, interval, interval, cutoff)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := make([]int, 0, len(got))
+		for _, chore := range got {
+			ids = append(ids, chore.ID)
+		}
+		sort.Ints(ids)
+		if len(ids) != len(want) {
+			t.Fatalf("chores to nag = %v, want %v", ids, want)
+		}
+		for i := range ids {
+			if ids[i] != want[i] {
+				t.Fatalf("chores to nag = %v, want %v", ids, want)
+			}
+		}
+	}
+	assertNagged(1, 3, 4)
+
+	// planning the reminders of a chore again keeps the nag that was sent, so it isn't nagged again early
+	if err := notificationRepo.DeleteChoreReminders(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	assertNagged(1, 3, 4)
+
+	// a queued nag dedupes the next run the same as a sent one
+	if err := notificationRepo.BatchInsertNotifications([]*nModel.Notification{{ChoreID: 1, CircleID: 1, EventType: nModel.EventTypeNagging, ScheduledFor: now, CreatedAt: now}}); err != nil {
+		t.Fatal(err)
+	}
+	assertNagged(3, 4)
+}
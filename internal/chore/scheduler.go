@@ -9,26 +9,37 @@ import (
 	"time"
 
 	chModel "donetick.com/core/internal/chore/model"
+	"donetick.com/core/internal/utils"
 	"donetick.com/core/logging"
+	"github.com/teambition/rrule-go"
 )
 
-func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDate time.Time) (*time.Time, error) {
+// scheduleNextDueDate returns the next due date of the chore completed at completedDate. fallbackTimezone is the
+// timezone used for chores without one of their own, see frequencyLocation
+func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDate time.Time, fallbackTimezone string) (*time.Time, error) {
 	if chore.FrequencyType == "once" || chore.FrequencyType == "no_repeat" || chore.FrequencyType == "trigger" {
 		return nil, nil
 	}
 
+	// all the calendar math below happens in the chore's own timezone so the wall-clock time
+	// of the chore is preserved across DST changes and weekdays/days of the month are the local ones.
+	loc := frequencyLocation(ctx, chore, fallbackTimezone)
+
 	var baseDate time.Time
 	if chore.NextDueDate != nil {
-		baseDate = chore.NextDueDate.UTC()
+		baseDate = chore.NextDueDate.In(loc)
 	} else {
-		baseDate = completedDate.UTC()
+		baseDate = completedDate.In(loc)
 	}
 	if chore.IsRolling {
-		baseDate = completedDate.UTC()
+		baseDate = completedDate.In(loc)
 	}
 
 	// Handle time-based frequencies, ensure time is in the future
-	if chore.FrequencyType == "day_of_the_month" || chore.FrequencyType == "days_of_the_week" || chore.FrequencyType == "interval" {
+	if chore.FrequencyType == "day_of_the_month" || chore.FrequencyType == "days_of_the_week" || chore.FrequencyType == "interval" || chore.FrequencyType == "nth_day_of_the_month" {
+		if chore.FrequencyMetadataV2 == nil {
+			return nil, fmt.Errorf("%s requires frequency metadata", chore.FrequencyType)
+		}
 		t, err := time.Parse(time.RFC3339, chore.FrequencyMetadataV2.Time)
 		if err != nil {
 			log := logging.FromContext(ctx)
@@ -37,14 +48,14 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 
 			// fallback to use the next due date time if available:
 			if chore.NextDueDate != nil {
-				t = chore.NextDueDate.UTC()
+				t = *chore.NextDueDate
 			} else {
-				t = time.Now().UTC()
+				t = time.Now()
 			}
 
 		}
-		t = t.UTC()
-		baseDate = time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+		t = t.In(loc)
+		baseDate = time.Date(baseDate.Year(), baseDate.Month(), baseDate.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
 	}
 
 	switch chore.FrequencyType {
@@ -58,9 +69,15 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 		baseDate = baseDate.AddDate(1, 0, 0)
 	case "adaptive":
 		// TODO: Implement a more sophisticated adaptive logic
+		if chore.NextDueDate == nil {
+			return nil, fmt.Errorf("adaptive requires a due date")
+		}
 		diff := completedDate.UTC().Sub(chore.NextDueDate.UTC())
-		baseDate = completedDate.UTC().Add(diff)
+		baseDate = completedDate.In(loc).Add(diff)
 	case "interval":
+		if chore.FrequencyMetadataV2.Unit == nil {
+			return nil, fmt.Errorf("interval requires a frequency unit")
+		}
 		switch *chore.FrequencyMetadataV2.Unit {
 		case "hours":
 			baseDate = baseDate.Add(time.Duration(chore.Frequency) * time.Hour)
@@ -85,6 +102,7 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 			nextDay := strings.ToLower(nextDueDate.Weekday().String())
 			for _, day := range chore.FrequencyMetadataV2.Days {
 				if strings.ToLower(*day) == nextDay {
+					nextDueDate = nextDueDate.UTC()
 					return &nextDueDate, nil
 				}
 			}
@@ -96,7 +114,7 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 		// if task due every 15 of jan, and you completed it on the 13 of jan( before the due date ) if we schedule from due date
 		// we will go back to 15 of jan. so we need to pick the highest between the two dates specifically for day of the month
 		if chore.IsRolling && chore.NextDueDate != nil {
-			secondAfterDueDate := chore.NextDueDate.UTC().Add(time.Second)
+			secondAfterDueDate := chore.NextDueDate.In(loc).Add(time.Second)
 			if completedDate.Before(secondAfterDueDate) {
 				baseDate = secondAfterDueDate
 			}
@@ -113,7 +131,7 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 		currentMonth := int(baseDate.Month())
 
 		var startFrom int
-		if chore.NextDueDate != nil && baseDate.Month() == chore.NextDueDate.Month() {
+		if chore.NextDueDate != nil && baseDate.Month() == chore.NextDueDate.In(loc).Month() {
 			startFrom = 1
 		}
 
@@ -125,27 +143,404 @@ func scheduleNextDueDate(ctx context.Context, chore *chModel.Chore, completedDat
 			}
 
 			// Ensure the target day exists in the month (e.g., Feb 30th is invalid)
-			lastDayOfMonth := time.Date(nextDueDate.Year(), time.Month(nextMonth+1), 0, 0, 0, 0, 0, time.UTC).Day()
+			lastDayOfMonth := time.Date(nextDueDate.Year(), time.Month(nextMonth+1), 0, 0, 0, 0, 0, loc).Day()
 			targetDay := chore.Frequency
 			if targetDay > lastDayOfMonth {
 				targetDay = lastDayOfMonth
 			}
 
-			nextDueDate = time.Date(nextDueDate.Year(), time.Month(nextMonth), targetDay, nextDueDate.Hour(), nextDueDate.Minute(), 0, 0, time.UTC)
+			nextDueDate = time.Date(nextDueDate.Year(), time.Month(nextMonth), targetDay, nextDueDate.Hour(), nextDueDate.Minute(), 0, 0, loc)
 
 			for _, month := range chore.FrequencyMetadataV2.Months {
 				if strings.ToLower(*month) == strings.ToLower(time.Month(nextMonth).String()) {
+					nextDueDate = nextDueDate.UTC()
 					return &nextDueDate, nil
 				}
 			}
 		}
 		return nil, fmt.Errorf("no matching month found")
+	case "nth_day_of_the_month":
+		// same as day_of_the_month, a rolling chore completed before its due date should not land on that due date again
+		if chore.IsRolling && chore.NextDueDate != nil {
+			secondAfterDueDate := chore.NextDueDate.In(loc).Add(time.Second)
+			if completedDate.Before(secondAfterDueDate) {
+				baseDate = secondAfterDueDate
+			}
+		}
+		if len(chore.FrequencyMetadataV2.Occurrences) == 0 {
+			return nil, fmt.Errorf("nth_day_of_the_month requires at least one occurrence")
+		}
+		// some occurrences only exist in a few months (e.g. the 5th Friday of February) so look far enough ahead
+		for i := 0; i < maxMonthsToScan; i++ {
+			monthStart := time.Date(baseDate.Year(), baseDate.Month()+time.Month(i), 1, baseDate.Hour(), baseDate.Minute(), baseDate.Second(), 0, loc)
+			if !monthSelected(chore.FrequencyMetadataV2.Months, monthStart.Month()) {
+				continue
+			}
+			var nextDueDate *time.Time
+			for _, occurrence := range chore.FrequencyMetadataV2.Occurrences {
+				day, ok := resolveMonthlyOccurrence(monthStart.Year(), monthStart.Month(), occurrence, loc)
+				if !ok {
+					continue
+				}
+				candidate := time.Date(monthStart.Year(), monthStart.Month(), day, baseDate.Hour(), baseDate.Minute(), baseDate.Second(), 0, loc)
+				if candidate.After(baseDate) && (nextDueDate == nil || candidate.Before(*nextDueDate)) {
+					nextDueDate = &candidate
+				}
+			}
+			if nextDueDate != nil {
+				next := nextDueDate.UTC()
+				return &next, nil
+			}
+		}
+		return nil, fmt.Errorf("no matching occurrence found")
+	case "rrule":
+		if chore.FrequencyMetadataV2 == nil {
+			return nil, fmt.Errorf("rrule requires frequency metadata")
+		}
+		set, err := parseRRuleSet(chore.FrequencyMetadataV2.RRule, baseDate, loc)
+		if err != nil {
+			return nil, err
+		}
+		nextDueDate := set.After(baseDate, false)
+		if nextDueDate.IsZero() {
+			// the rule has run out of occurrences (COUNT/UNTIL reached), so the chore does not repeat anymore
+			return nil, nil
+		}
+		nextDueDate = nextDueDate.UTC()
+		return &nextDueDate, nil
 	default:
 		return nil, fmt.Errorf("invalid frequency type: %s", chore.FrequencyType)
 	}
 
+	baseDate = baseDate.UTC()
 	return &baseDate, nil
 }
+
+// maxMonthsToScan bounds the search for the next nth_day_of_the_month occurrence.
+const maxMonthsToScan = 12 * 30
+
+// monthSelected reports whether month is part of the months filter. An empty filter selects every month.
+func monthSelected(months []*string, month time.Month) bool {
+	if len(months) == 0 {
+		return true
+	}
+	for _, m := range months {
+		if m != nil && strings.EqualFold(*m, month.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveMonthlyOccurrence returns the day of the month the occurrence falls on, or false when the month
+// does not have it (e.g. a 5th Monday).
+func resolveMonthlyOccurrence(year int, month time.Month, occurrence *chModel.MonthlyOccurrence, loc *time.Location) (int, bool) {
+	if occurrence == nil || occurrence.Ordinal == 0 {
+		return 0, false
+	}
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+
+	if occurrence.Weekday == nil || *occurrence.Weekday == "" {
+		day := occurrence.Ordinal
+		if day < 0 {
+			day = lastDay + day + 1
+		}
+		if day < 1 || day > lastDay {
+			return 0, false
+		}
+		return day, true
+	}
+
+	weekday, ok := parseWeekday(*occurrence.Weekday)
+	if !ok {
+		return 0, false
+	}
+	var day int
+	if occurrence.Ordinal > 0 {
+		firstWeekday := time.Date(year, month, 1, 0, 0, 0, 0, loc).Weekday()
+		day = 1 + (int(weekday)-int(firstWeekday)+7)%7 + (occurrence.Ordinal-1)*7
+	} else {
+		lastWeekday := time.Date(year, month, lastDay, 0, 0, 0, 0, loc).Weekday()
+		day = lastDay - (int(lastWeekday)-int(weekday)+7)%7 + (occurrence.Ordinal+1)*7
+	}
+	if day < 1 || day > lastDay {
+		return 0, false
+	}
+	return day, true
+}
+
+func parseWeekday(value string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(value, d.String()) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+func validateMonthlyOccurrences(metadata *chModel.FrequencyMetadata) error {
+	if len(metadata.Occurrences) == 0 {
+		return fmt.Errorf("nth_day_of_the_month requires at least one occurrence")
+	}
+	for _, occurrence := range metadata.Occurrences {
+		if occurrence == nil {
+			return fmt.Errorf("occurrence cannot be empty")
+		}
+		if occurrence.Weekday == nil || *occurrence.Weekday == "" {
+			if occurrence.Ordinal == 0 || occurrence.Ordinal > 31 || occurrence.Ordinal < -31 {
+				return fmt.Errorf("invalid day of the month: %d", occurrence.Ordinal)
+			}
+			continue
+		}
+		if _, ok := parseWeekday(*occurrence.Weekday); !ok {
+			return fmt.Errorf("invalid weekday: %s", *occurrence.Weekday)
+		}
+		if occurrence.Ordinal == 0 || occurrence.Ordinal > 5 || occurrence.Ordinal < -5 {
+			return fmt.Errorf("invalid weekday ordinal: %d", occurrence.Ordinal)
+		}
+	}
+	for _, month := range metadata.Months {
+		valid := false
+		for m := time.January; m <= time.December; m++ {
+			if month != nil && strings.EqualFold(*month, m.String()) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid month in frequency metadata")
+		}
+	}
+	return nil
+}
+
+// frequencyLocation returns the timezone the chore recurrence is calculated in. Chores without a
+// (valid) timezone in their frequency metadata are scheduled in fallbackTimezone, usually the timezone
+// of their assignee, and in UTC when that isn't valid either.
+func frequencyLocation(ctx context.Context, chore *chModel.Chore, fallbackTimezone string) *time.Location {
+	if chore.FrequencyMetadataV2 != nil && chore.FrequencyMetadataV2.Timezone != "" {
+		loc, err := time.LoadLocation(chore.FrequencyMetadataV2.Timezone)
+		if err == nil {
+			return loc
+		}
+		logging.FromContext(ctx).Warnw("invalid timezone in frequency metadata, falling back to the user timezone", "chore_id", chore.ID, "timezone", chore.FrequencyMetadataV2.Timezone, "error", err)
+	}
+	if fallbackTimezone != "" {
+		if loc, err := time.LoadLocation(fallbackTimezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// applyTimezoneFallback sets the timezone of the frequency metadata to the user's timezone when the
+// chore does not carry one, so the recurrence keeps following the local time of whoever set it up.
+func applyTimezoneFallback(metadata *chModel.FrequencyMetadata, userTimezone string) *chModel.FrequencyMetadata {
+	if userTimezone == "" || !utils.IsValidTimezone(userTimezone) {
+		return metadata
+	}
+	if metadata == nil {
+		return &chModel.FrequencyMetadata{Timezone: userTimezone}
+	}
+	if metadata.Timezone == "" {
+		metadata.Timezone = userTimezone
+	}
+	return metadata
+}
+
+// previewOccurrences simulates completing the chore on time, over and over, and returns the next count due dates
+// together with the assignee each one would get. It runs the same scheduling and assignment logic as completion
+// without touching the chore or its history. fallbackTimezone is the user timezone completions would use, see userTimezone.
+func previewOccurrences(ctx context.Context, chore *chModel.Chore, history []*chModel.ChoreHistory, count int, fallbackTimezone string) ([]chModel.ChoreOccurrence, error) {
+	simulated := *chore
+	simulatedHistory := make([]*chModel.ChoreHistory, len(history))
+	copy(simulatedHistory, history)
+
+	occurrences := make([]chModel.ChoreOccurrence, 0, count)
+	dueDate := simulated.NextDueDate
+	if dueDate == nil {
+		// without a due date the first occurrence is scheduled as if the chore was completed now
+		var next *time.Time
+		var err error
+		if simulated.FrequencyType == chModel.FrequencyTypeAdaptive {
+			next, err = scheduleAdaptiveNextDueDate(&simulated, time.Now().UTC(), simulatedHistory)
+		} else {
+			next, err = scheduleNextDueDate(ctx, &simulated, time.Now().UTC(), fallbackTimezone)
+		}
+		if err != nil {
+			return nil, err
+		}
+		dueDate = next
+	}
+
+	for dueDate != nil && len(occurrences) < count {
+		occurrences = append(occurrences, chModel.ChoreOccurrence{
+			DueDate:    dueDate.UTC(),
+			AssignedTo: simulated.AssignedTo,
+		})
+		simulated.NextDueDate = dueDate
+
+		var next *time.Time
+		var err error
+		if simulated.FrequencyType == chModel.FrequencyTypeAdaptive {
+			next, err = scheduleAdaptiveNextDueDate(&simulated, *dueDate, simulatedHistory)
+		} else {
+			next, err = scheduleNextDueDate(ctx, &simulated, *dueDate, fallbackTimezone)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		nextAssignee := simulated.AssignedTo
+		if len(simulated.Assignees) > 1 {
+			nextAssignee, err = checkNextAssignee(&simulated, simulatedHistory, simulated.AssignedTo)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		performedAt := *dueDate
+		simulatedHistory = append([]*chModel.ChoreHistory{{
+			ChoreID:     simulated.ID,
+			PerformedAt: &performedAt,
+			CompletedBy: simulated.AssignedTo,
+			AssignedTo:  simulated.AssignedTo,
+			DueDate:     &performedAt,
+			Status:      chModel.ChoreHistoryStatusCompleted,
+		}}, simulatedHistory...)
+
+		if next == nil || !next.After(*dueDate) {
+			// the chore does not repeat (or can not be predicted any further)
+			break
+		}
+		simulated.AssignedTo = nextAssignee
+		dueDate = next
+	}
+	return occurrences, nil
+}
+
+// validateFrequency checks the recurrence settings of a chore request before it is persisted or previewed,
+// so a broken rule is rejected up front instead of failing the first time the chore is completed. Every
+// frequency type gets the metadata the scheduler reads for it.
+func validateFrequency(frequencyType chModel.FrequencyType, metadata *chModel.FrequencyMetadata) error {
+	if metadata != nil && metadata.Timezone != "" && !utils.IsValidTimezone(metadata.Timezone) {
+		return fmt.Errorf("invalid timezone: %s", metadata.Timezone)
+	}
+	switch frequencyType {
+	case chModel.FrequencyTypeInterval:
+		if metadata == nil || metadata.Unit == nil {
+			return fmt.Errorf("interval requires a frequency unit")
+		}
+		switch *metadata.Unit {
+		case "hours", "days", "weeks", "months", "years":
+		default:
+			return fmt.Errorf("invalid frequency unit: %s", *metadata.Unit)
+		}
+	case chModel.FrequencyTypeDayOfTheWeek:
+		if metadata == nil || len(metadata.Days) == 0 {
+			return fmt.Errorf("days_of_the_week requires at least one day")
+		}
+		for _, day := range metadata.Days {
+			if day == nil {
+				return fmt.Errorf("day cannot be empty")
+			}
+		}
+	case chModel.FrequencyTypeDayOfTheMonth:
+		if metadata == nil || len(metadata.Months) == 0 {
+			return fmt.Errorf("day_of_the_month requires at least one month")
+		}
+		for _, month := range metadata.Months {
+			if month == nil {
+				return fmt.Errorf("month cannot be empty")
+			}
+		}
+	case chModel.FrequencyTypeRRule:
+		if metadata == nil {
+			return fmt.Errorf("rrule requires frequency metadata")
+		}
+		if _, err := parseRRuleSet(metadata.RRule, time.Now().UTC(), time.UTC); err != nil {
+			return err
+		}
+	case chModel.FrequencyTypeNthDayOfMonth:
+		if metadata == nil {
+			return fmt.Errorf("nth_day_of_the_month requires frequency metadata")
+		}
+		return validateMonthlyOccurrences(metadata)
+	}
+	return nil
+}
+
+// anchorRRule adds a DTSTART to an rrule with COUNT that has none, at the first due date of the chore or
+// now when it has none. Without it the rule is anchored to the current due date every time the chore is
+// completed, so its COUNT never runs out. Call it with validated metadata.
+func anchorRRule(frequencyType chModel.FrequencyType, metadata *chModel.FrequencyMetadata, dueDate *time.Time) error {
+	if frequencyType != chModel.FrequencyTypeRRule || metadata == nil {
+		return nil
+	}
+	for _, line := range strings.FieldsFunc(metadata.RRule, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(line)), "DTSTART") {
+			return nil
+		}
+	}
+	dtstart := time.Now().UTC()
+	if dueDate != nil {
+		dtstart = *dueDate
+	}
+	loc := time.UTC
+	if metadata.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(metadata.Timezone); err != nil {
+			return err
+		}
+	}
+	set, err := parseRRuleSet(metadata.RRule, dtstart, loc)
+	if err != nil {
+		return err
+	}
+	if set.GetRRule().OrigOptions.Count == 0 {
+		return nil
+	}
+	start := "DTSTART:" + dtstart.UTC().Format("20060102T150405Z")
+	if loc != time.UTC {
+		start = "DTSTART;TZID=" + loc.String() + ":" + dtstart.In(loc).Format("20060102T150405")
+	}
+	metadata.RRule = start + "\n" + strings.TrimSpace(metadata.RRule)
+	return nil
+}
+
+// parseRRuleSet parses an RFC 5545 recurrence. The value can be a bare rule ("FREQ=MONTHLY;BYDAY=2SA,4SA")
+// or a multi-line set with DTSTART, RRULE and EXDATE properties. When DTSTART is missing the rule is
+// anchored to dtstart, which is usually the current due date of the chore. Floating times (no TZID)
+// are read in loc.
+func parseRRuleSet(value string, dtstart time.Time, loc *time.Location) (*rrule.Set, error) {
+	var lines []string
+	for _, line := range strings.FieldsFunc(value, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToUpper(line), "FREQ=") {
+			line = "RRULE:" + line
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("rrule is required")
+	}
+
+	set, err := rrule.StrSliceToRRuleSetInLoc(lines, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rrule: %w", err)
+	}
+	if set.GetRRule() == nil {
+		return nil, fmt.Errorf("invalid rrule: missing RRULE property")
+	}
+	if set.GetDTStart().IsZero() {
+		set.DTStart(dtstart.In(loc))
+	}
+	return set, nil
+}
+
 func scheduleAdaptiveNextDueDate(chore *chModel.Chore, completedDate time.Time, history []*chModel.ChoreHistory) (*time.Time, error) {
 
 	history = append([]*chModel.ChoreHistory{
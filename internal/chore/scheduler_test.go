@@ -3,6 +3,7 @@ package chore
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +17,8 @@ type scheduleTest struct {
 	want          *time.Time
 	wantErr       bool
 	wantErrMsg    string
+	// fallbackTimezone is the user timezone for chores without one
+	fallbackTimezone string
 }
 
 func TestScheduleNextDueDateBasicTests(t *testing.T) {
@@ -295,6 +298,413 @@ func TestScheduleNextDueDateDayOfMonth(t *testing.T) {
 
 }
 
+func TestScheduleNextDueDateRRule(t *testing.T) {
+	location, err := time.LoadLocation("UTC")
+	if err != nil {
+		t.Fatalf("error loading location: %v", err)
+	}
+
+	now := time.Date(2025, 1, 2, 0, 15, 0, 0, location)
+	tests := []scheduleTest{
+		{
+			name: "RRule - 2nd and 4th Saturday",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2025, 1, 11, 9, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "FREQ=MONTHLY;BYDAY=2SA,4SA",
+				},
+			},
+			completedDate: now.AddDate(0, 0, 10),
+			want:          timePtr(time.Date(2025, 1, 25, 9, 0, 0, 0, location)),
+		},
+		{
+			name: "RRule - 2nd and 4th Saturday rolls into next month",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2025, 1, 25, 9, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "RRULE:FREQ=MONTHLY;BYDAY=2SA,4SA",
+				},
+			},
+			completedDate: now.AddDate(0, 0, 23),
+			want:          timePtr(time.Date(2025, 2, 8, 9, 0, 0, 0, location)),
+		},
+		{
+			name: "RRule - last weekday of the quarter",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2024, 12, 31, 17, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "FREQ=MONTHLY;BYMONTH=3,6,9,12;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1",
+				},
+			},
+			completedDate: now,
+			want:          timePtr(time.Date(2025, 3, 31, 17, 0, 0, 0, location)),
+		},
+		{
+			name: "RRule - EXDATE skips an occurrence",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2025, 1, 1, 9, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "DTSTART:20250101T090000Z\nRRULE:FREQ=WEEKLY;BYDAY=WE\nEXDATE:20250108T090000Z",
+				},
+			},
+			completedDate: now,
+			want:          timePtr(time.Date(2025, 1, 15, 9, 0, 0, 0, location)),
+		},
+		{
+			name: "RRule - IsRolling schedules from completion",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				IsRolling:     true,
+				NextDueDate:   timePtr(time.Date(2025, 1, 1, 9, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "DTSTART:20250101T090000Z\nRRULE:FREQ=WEEKLY;BYDAY=WE",
+				},
+			},
+			completedDate: time.Date(2025, 1, 9, 12, 0, 0, 0, location),
+			want:          timePtr(time.Date(2025, 1, 15, 9, 0, 0, 0, location)),
+		},
+		{
+			name: "RRule - COUNT exhausted",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2025, 1, 2, 9, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "DTSTART:20250101T090000Z\nRRULE:FREQ=DAILY;COUNT=2",
+				},
+			},
+			completedDate: now,
+			want:          nil,
+		},
+		{
+			name: "RRule - missing rule",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeRRule,
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{},
+			},
+			completedDate: now,
+			wantErr:       true,
+			wantErrMsg:    "rrule is required",
+		},
+	}
+	executeTestTable(t, tests)
+}
+
+func TestScheduleNextDueDateNthDayOfMonth(t *testing.T) {
+	location, err := time.LoadLocation("UTC")
+	if err != nil {
+		t.Fatalf("error loading location: %v", err)
+	}
+
+	now := time.Date(2025, 1, 2, 0, 15, 0, 0, location)
+	occurrence := func(ordinal int, weekday string) *chModel.MonthlyOccurrence {
+		o := &chModel.MonthlyOccurrence{Ordinal: ordinal}
+		if weekday != "" {
+			o.Weekday = jsonPtr(weekday)
+		}
+		return o
+	}
+	tests := []scheduleTest{
+		{
+			name: "Nth day - first Monday",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeNthDayOfMonth,
+				NextDueDate:   timePtr(time.Date(2025, 1, 6, 9, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:        "2025-01-06T09:00:00Z",
+					Occurrences: []*chModel.MonthlyOccurrence{occurrence(1, "monday")},
+				},
+			},
+			completedDate: now.AddDate(0, 0, 4),
+			want:          timePtr(time.Date(2025, 2, 3, 9, 0, 0, 0, location)),
+		},
+		{
+			name: "Nth day - third Thursday without due date",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeNthDayOfMonth,
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:        "2025-01-06T09:00:00Z",
+					Occurrences: []*chModel.MonthlyOccurrence{occurrence(3, "thursday")},
+				},
+			},
+			completedDate: now,
+			want:          timePtr(time.Date(2025, 1, 16, 9, 0, 0, 0, location)),
+		},
+		{
+			name: "Nth day - last Friday",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeNthDayOfMonth,
+				NextDueDate:   timePtr(time.Date(2025, 1, 31, 18, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:        "2025-01-31T18:00:00Z",
+					Occurrences: []*chModel.MonthlyOccurrence{occurrence(-1, "friday")},
+				},
+			},
+			completedDate: now.AddDate(0, 0, 29),
+			want:          timePtr(time.Date(2025, 2, 28, 18, 0, 0, 0, location)),
+		},
+		{
+			name: "Nth day - last day of the month in a leap year",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeNthDayOfMonth,
+				NextDueDate:   timePtr(time.Date(2024, 1, 31, 8, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:        "2024-01-31T08:00:00Z",
+					Occurrences: []*chModel.MonthlyOccurrence{occurrence(-1, "")},
+				},
+			},
+			completedDate: time.Date(2024, 1, 31, 10, 0, 0, 0, location),
+			want:          timePtr(time.Date(2024, 2, 29, 8, 0, 0, 0, location)),
+		},
+		{
+			name: "Nth day - fifth Friday skips months without one",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeNthDayOfMonth,
+				NextDueDate:   timePtr(time.Date(2025, 1, 31, 18, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:        "2025-01-31T18:00:00Z",
+					Occurrences: []*chModel.MonthlyOccurrence{occurrence(5, "friday")},
+				},
+			},
+			completedDate: now.AddDate(0, 0, 29),
+			want:          timePtr(time.Date(2025, 5, 30, 18, 0, 0, 0, location)),
+		},
+		{
+			name: "Nth day - first Monday of March and September",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeNthDayOfMonth,
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:        "2025-01-06T09:00:00Z",
+					Months:      []*string{jsonPtr("march"), jsonPtr("september")},
+					Occurrences: []*chModel.MonthlyOccurrence{occurrence(1, "monday")},
+				},
+			},
+			completedDate: now,
+			want:          timePtr(time.Date(2025, 3, 3, 9, 0, 0, 0, location)),
+		},
+		{
+			name: "Nth day - second and fourth Tuesday",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeNthDayOfMonth,
+				NextDueDate:   timePtr(time.Date(2025, 1, 14, 9, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:        "2025-01-14T09:00:00Z",
+					Occurrences: []*chModel.MonthlyOccurrence{occurrence(4, "tuesday"), occurrence(2, "tuesday")},
+				},
+			},
+			completedDate: now.AddDate(0, 0, 12),
+			want:          timePtr(time.Date(2025, 1, 28, 9, 0, 0, 0, location)),
+		},
+		{
+			name: "Nth day - missing occurrences",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeNthDayOfMonth,
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time: "2025-01-14T09:00:00Z",
+				},
+			},
+			completedDate: now,
+			wantErr:       true,
+			wantErrMsg:    "nth_day_of_the_month requires at least one occurrence",
+		},
+	}
+	executeTestTable(t, tests)
+}
+
+func TestValidateFrequencyNthDayOfMonth(t *testing.T) {
+	valid := &chModel.FrequencyMetadata{
+		Months:      []*string{jsonPtr("january")},
+		Occurrences: []*chModel.MonthlyOccurrence{{Ordinal: -1, Weekday: jsonPtr("friday")}, {Ordinal: -1}},
+	}
+	if err := validateFrequency(chModel.FrequencyTypeNthDayOfMonth, valid); err != nil {
+		t.Errorf("validateFrequency() unexpected error = %v", err)
+	}
+	invalid := []*chModel.FrequencyMetadata{
+		{},
+		{Occurrences: []*chModel.MonthlyOccurrence{{Ordinal: 6, Weekday: jsonPtr("monday")}}},
+		{Occurrences: []*chModel.MonthlyOccurrence{{Ordinal: 1, Weekday: jsonPtr("someday")}}},
+		{Occurrences: []*chModel.MonthlyOccurrence{{Ordinal: 0}}},
+		{Months: []*string{jsonPtr("smarch")}, Occurrences: []*chModel.MonthlyOccurrence{{Ordinal: 1}}},
+	}
+	for _, metadata := range invalid {
+		if err := validateFrequency(chModel.FrequencyTypeNthDayOfMonth, metadata); err == nil {
+			t.Errorf("validateFrequency(%+v) expected error", metadata)
+		}
+	}
+}
+
+func TestScheduleNextDueDateTimezone(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("error loading location: %v", err)
+	}
+
+	tests := []scheduleTest{
+		{
+			name: "Daily - keeps 7am across DST start",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeDaily,
+				NextDueDate:   timePtr(time.Date(2025, 3, 8, 7, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Timezone: "America/New_York",
+				},
+			},
+			completedDate: time.Date(2025, 3, 8, 8, 0, 0, 0, location),
+			want:          timePtr(time.Date(2025, 3, 9, 11, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "Interval - 1 week keeps 7am across DST end",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeInterval,
+				Frequency:     1,
+				NextDueDate:   timePtr(time.Date(2025, 10, 27, 7, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:     "2025-01-01T07:00:00-05:00",
+					Unit:     jsonPtr("weeks"),
+					Timezone: "America/New_York",
+				},
+			},
+			completedDate: time.Date(2025, 10, 27, 8, 0, 0, 0, location),
+			want:          timePtr(time.Date(2025, 11, 3, 12, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "Days of the week - uses the local weekday",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeDayOfTheWeek,
+				NextDueDate:   timePtr(time.Date(2025, 1, 6, 21, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Days:     []*string{jsonPtr("monday")},
+					Time:     "2025-01-06T21:00:00-05:00",
+					Timezone: "America/New_York",
+				},
+			},
+			completedDate: time.Date(2025, 1, 6, 22, 0, 0, 0, location),
+			want:          timePtr(time.Date(2025, 1, 14, 2, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "Day of the month - local time after DST start",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeDayOfTheMonth,
+				Frequency:     15,
+				NextDueDate:   timePtr(time.Date(2025, 2, 15, 9, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Time:     "2025-02-15T09:00:00-05:00",
+					Months:   []*string{jsonPtr("february"), jsonPtr("march")},
+					Timezone: "America/New_York",
+				},
+			},
+			completedDate: time.Date(2025, 2, 15, 10, 0, 0, 0, location),
+			want:          timePtr(time.Date(2025, 3, 15, 13, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "RRule - weekly Sunday keeps 7am across DST start",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   timePtr(time.Date(2025, 3, 2, 7, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule:    "FREQ=WEEKLY;BYDAY=SU",
+					Timezone: "America/New_York",
+				},
+			},
+			completedDate: time.Date(2025, 3, 2, 8, 0, 0, 0, location),
+			want:          timePtr(time.Date(2025, 3, 9, 11, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "Invalid timezone falls back to UTC",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeDaily,
+				NextDueDate:   timePtr(time.Date(2025, 3, 8, 12, 0, 0, 0, time.UTC)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Timezone: "Mars/Olympus_Mons",
+				},
+			},
+			completedDate: time.Date(2025, 3, 8, 13, 0, 0, 0, time.UTC),
+			want:          timePtr(time.Date(2025, 3, 9, 12, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "No timezone uses the user timezone across DST start",
+			chore: chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeDaily,
+				NextDueDate:         timePtr(time.Date(2025, 3, 8, 7, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{},
+			},
+			completedDate:    time.Date(2025, 3, 8, 8, 0, 0, 0, location),
+			fallbackTimezone: "America/New_York",
+			want:             timePtr(time.Date(2025, 3, 9, 11, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "Days of the week - no metadata timezone uses the user's weekday",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeDayOfTheWeek,
+				NextDueDate:   timePtr(time.Date(2025, 1, 6, 21, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Days: []*string{jsonPtr("monday")},
+					Time: "2025-01-06T21:00:00-05:00",
+				},
+			},
+			completedDate:    time.Date(2025, 1, 6, 22, 0, 0, 0, location),
+			fallbackTimezone: "America/New_York",
+			want:             timePtr(time.Date(2025, 1, 14, 2, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "Invalid timezone uses the user timezone",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeDaily,
+				NextDueDate:   timePtr(time.Date(2025, 3, 8, 7, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Timezone: "Mars/Olympus_Mons",
+				},
+			},
+			completedDate:    time.Date(2025, 3, 8, 8, 0, 0, 0, location),
+			fallbackTimezone: "America/New_York",
+			want:             timePtr(time.Date(2025, 3, 9, 11, 0, 0, 0, time.UTC)),
+		},
+		{
+			name: "Chore timezone wins over the user timezone",
+			chore: chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeDaily,
+				NextDueDate:   timePtr(time.Date(2025, 3, 8, 7, 0, 0, 0, location)),
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					Timezone: "America/New_York",
+				},
+			},
+			completedDate:    time.Date(2025, 3, 8, 8, 0, 0, 0, location),
+			fallbackTimezone: "Europe/Berlin",
+			want:             timePtr(time.Date(2025, 3, 9, 11, 0, 0, 0, time.UTC)),
+		},
+	}
+	executeTestTable(t, tests)
+}
+
+func TestApplyTimezoneFallback(t *testing.T) {
+	metadata := applyTimezoneFallback(nil, "Europe/Berlin")
+	if metadata == nil || metadata.Timezone != "Europe/Berlin" {
+		t.Errorf("applyTimezoneFallback() = %v, want timezone Europe/Berlin", metadata)
+	}
+	metadata = applyTimezoneFallback(&chModel.FrequencyMetadata{Timezone: "Asia/Tokyo"}, "Europe/Berlin")
+	if metadata.Timezone != "Asia/Tokyo" {
+		t.Errorf("applyTimezoneFallback() overrode chore timezone, got %s", metadata.Timezone)
+	}
+	if metadata := applyTimezoneFallback(nil, "not/a_zone"); metadata != nil {
+		t.Errorf("applyTimezoneFallback() = %v, want nil for invalid user timezone", metadata)
+	}
+}
+
+func TestValidateFrequencyRRule(t *testing.T) {
+	if err := validateFrequency(chModel.FrequencyTypeRRule, &chModel.FrequencyMetadata{RRule: "FREQ=MONTHLY;BYDAY=2SA,4SA"}); err != nil {
+		t.Errorf("validateFrequency() unexpected error = %v", err)
+	}
+	if err := validateFrequency(chModel.FrequencyTypeRRule, &chModel.FrequencyMetadata{RRule: "FREQ=SOMETIMES"}); err == nil {
+		t.Errorf("validateFrequency() expected error for invalid FREQ")
+	}
+	if err := validateFrequency(chModel.FrequencyTypeRRule, nil); err == nil {
+		t.Errorf("validateFrequency() expected error for missing metadata")
+	}
+}
+
 func TestScheduleNextDueDateErrors(t *testing.T) {
 	// location, err := time.LoadLocation("America/New_York")
 	location, err := time.LoadLocation("UTC")
@@ -326,7 +736,7 @@ func executeTestTable(t *testing.T, tests []scheduleTest) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := scheduleNextDueDate(context.TODO(), &tt.chore, tt.completedDate)
+			got, err := scheduleNextDueDate(context.TODO(), &tt.chore, tt.completedDate, tt.fallbackTimezone)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("testcase: %s", tt.name)
 				t.Errorf("scheduleNextDueDate() error = %v, wantErr %v", err, tt.wantErr)
@@ -370,3 +780,164 @@ func jsonPtr(s string) *string {
 func truncateToDay(t time.Time) time.Time {
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
 }
+
+func TestPreviewOccurrences(t *testing.T) {
+	dueDate := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	chore := &chModel.Chore{
+		FrequencyType:  chModel.FrequencyTypeWeekly,
+		NextDueDate:    &dueDate,
+		AssignStrategy: chModel.AssignmentStrategyRoundRobin,
+		AssignedTo:     1,
+		Assignees:      []chModel.ChoreAssignees{{UserID: 1}, {UserID: 2}},
+	}
+
+	got, err := previewOccurrences(context.TODO(), chore, nil, 3, "")
+	if err != nil {
+		t.Fatalf("previewOccurrences() error = %v", err)
+	}
+	want := []chModel.ChoreOccurrence{
+		{DueDate: dueDate, AssignedTo: 1},
+		{DueDate: dueDate.AddDate(0, 0, 7), AssignedTo: 2},
+		{DueDate: dueDate.AddDate(0, 0, 14), AssignedTo: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("previewOccurrences() returned %d occurrences, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].DueDate.Equal(want[i].DueDate) || got[i].AssignedTo != want[i].AssignedTo {
+			t.Errorf("occurrence %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+	if !chore.NextDueDate.Equal(dueDate) || chore.AssignedTo != 1 {
+		t.Errorf("previewOccurrences() modified the chore")
+	}
+}
+
+func TestPreviewOccurrencesStopsWhenChoreEnds(t *testing.T) {
+	dueDate := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		chore *chModel.Chore
+		want  int
+	}{
+		{
+			name:  "Once",
+			chore: &chModel.Chore{FrequencyType: chModel.FrequencyTypeOnce, NextDueDate: &dueDate, AssignedTo: 1},
+			want:  1,
+		},
+		{
+			name: "RRule with COUNT",
+			chore: &chModel.Chore{
+				FrequencyType: chModel.FrequencyTypeRRule,
+				NextDueDate:   &dueDate,
+				AssignedTo:    1,
+				FrequencyMetadataV2: &chModel.FrequencyMetadata{
+					RRule: "DTSTART:20250101T090000Z\nRRULE:FREQ=DAILY;COUNT=3",
+				},
+			},
+			want: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := previewOccurrences(context.TODO(), tt.chore, nil, 10, "")
+			if err != nil {
+				t.Fatalf("previewOccurrences() error = %v", err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("previewOccurrences() returned %d occurrences, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFrequencyMetadata(t *testing.T) {
+	hours := "hours"
+	tests := []struct {
+		name          string
+		frequencyType chModel.FrequencyType
+		metadata      *chModel.FrequencyMetadata
+		wantErr       bool
+	}{
+		{name: "interval without metadata", frequencyType: chModel.FrequencyTypeInterval, wantErr: true},
+		{name: "interval without unit", frequencyType: chModel.FrequencyTypeInterval, metadata: &chModel.FrequencyMetadata{Timezone: "UTC"}, wantErr: true},
+		{name: "interval with invalid unit", frequencyType: chModel.FrequencyTypeInterval, metadata: &chModel.FrequencyMetadata{Unit: jsonPtr("fortnights")}, wantErr: true},
+		{name: "interval", frequencyType: chModel.FrequencyTypeInterval, metadata: &chModel.FrequencyMetadata{Unit: &hours}},
+		{name: "days of the week without metadata", frequencyType: chModel.FrequencyTypeDayOfTheWeek, wantErr: true},
+		{name: "days of the week with an empty day", frequencyType: chModel.FrequencyTypeDayOfTheWeek, metadata: &chModel.FrequencyMetadata{Days: []*string{nil}}, wantErr: true},
+		{name: "days of the week", frequencyType: chModel.FrequencyTypeDayOfTheWeek, metadata: &chModel.FrequencyMetadata{Days: []*string{jsonPtr("monday")}}},
+		{name: "day of the month without metadata", frequencyType: chModel.FrequencyTypeDayOfTheMonth, wantErr: true},
+		{name: "day of the month with an empty month", frequencyType: chModel.FrequencyTypeDayOfTheMonth, metadata: &chModel.FrequencyMetadata{Months: []*string{nil}}, wantErr: true},
+		{name: "day of the month", frequencyType: chModel.FrequencyTypeDayOfTheMonth, metadata: &chModel.FrequencyMetadata{Months: []*string{jsonPtr("january")}}},
+		{name: "nth day of the month without metadata", frequencyType: chModel.FrequencyTypeNthDayOfMonth, wantErr: true},
+		{name: "weekly without metadata", frequencyType: chModel.FrequencyTypeWeekly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFrequency(tt.frequencyType, tt.metadata)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateFrequency() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPreviewOccurrencesWithoutFrequencyMetadata(t *testing.T) {
+	// chores saved before their metadata was validated get an error instead of a panic
+	dueDate := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	for _, chore := range []*chModel.Chore{
+		{FrequencyType: chModel.FrequencyTypeInterval, Frequency: 2, NextDueDate: &dueDate, AssignedTo: 1},
+		{FrequencyType: chModel.FrequencyTypeInterval, Frequency: 2, FrequencyMetadataV2: &chModel.FrequencyMetadata{}, NextDueDate: &dueDate, AssignedTo: 1},
+		{FrequencyType: chModel.FrequencyTypeDayOfTheWeek, NextDueDate: &dueDate, AssignedTo: 1},
+	} {
+		if _, err := previewOccurrences(context.TODO(), chore, nil, 3, ""); err == nil {
+			t.Errorf("previewOccurrences(%s, %+v) expected error", chore.FrequencyType, chore.FrequencyMetadataV2)
+		}
+	}
+}
+
+func TestRRuleCountRunsOutAfterCompletions(t *testing.T) {
+	dueDate := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	for _, timezone := range []string{"", "Europe/Berlin"} {
+		t.Run("timezone "+timezone, func(t *testing.T) {
+			metadata := &chModel.FrequencyMetadata{RRule: "FREQ=DAILY;COUNT=2", Timezone: timezone}
+			if err := validateFrequency(chModel.FrequencyTypeRRule, metadata); err != nil {
+				t.Fatalf("validateFrequency() error = %v", err)
+			}
+			if err := anchorRRule(chModel.FrequencyTypeRRule, metadata, &dueDate); err != nil {
+				t.Fatalf("anchorRRule() error = %v", err)
+			}
+			if !strings.HasPrefix(metadata.RRule, "DTSTART") {
+				t.Fatalf("anchorRRule() rule = %q, want a DTSTART", metadata.RRule)
+			}
+			chore := &chModel.Chore{
+				FrequencyType:       chModel.FrequencyTypeRRule,
+				FrequencyMetadataV2: metadata,
+				NextDueDate:         &dueDate,
+			}
+
+			// the first completion moves to the second and last occurrence, the ones after that end the chore
+			want := []*time.Time{timePtr(dueDate.AddDate(0, 0, 1)), nil, nil}
+			completedDate := dueDate
+			for i, wantNext := range want {
+				next, err := scheduleNextDueDate(context.TODO(), chore, completedDate, "")
+				if err != nil {
+					t.Fatalf("completion %d: scheduleNextDueDate() error = %v", i+1, err)
+				}
+				if (next == nil) != (wantNext == nil) || (next != nil && !next.Equal(*wantNext)) {
+					t.Fatalf("completion %d: scheduleNextDueDate() = %v, want %v", i+1, next, wantNext)
+				}
+				chore.NextDueDate = next
+				completedDate = completedDate.AddDate(0, 0, 1)
+			}
+		})
+	}
+
+	// rules that have a DTSTART or no COUNT are kept as they are
+	for _, rule := range []string{"DTSTART:20240101T090000Z\nRRULE:FREQ=DAILY;COUNT=2", "FREQ=WEEKLY;BYDAY=MO"} {
+		metadata := &chModel.FrequencyMetadata{RRule: rule}
+		if err := anchorRRule(chModel.FrequencyTypeRRule, metadata, &dueDate); err != nil || metadata.RRule != rule {
+			t.Errorf("anchorRRule(%q) = %q, %v, want the rule unchanged", rule, metadata.RRule, err)
+		}
+	}
+}
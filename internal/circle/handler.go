@@ -1,6 +1,8 @@
 package circle
 
 import (
+	"context"
+	"fmt"
 	"log"
 
 	"strconv"
@@ -11,6 +13,9 @@ import (
 	chRepo "donetick.com/core/internal/chore/repo"
 	cModel "donetick.com/core/internal/circle/model"
 	cRepo "donetick.com/core/internal/circle/repo"
+	"donetick.com/core/internal/events"
+	nModel "donetick.com/core/internal/notifier/model"
+	nps "donetick.com/core/internal/notifier/service"
 	pRepo "donetick.com/core/internal/points/repo"
 	uModel "donetick.com/core/internal/user/model"
 	uRepo "donetick.com/core/internal/user/repo"
@@ -20,18 +25,22 @@ import (
 )
 
 type Handler struct {
-	circleRepo *cRepo.CircleRepository
-	userRepo   *uRepo.UserRepository
-	choreRepo  *chRepo.ChoreRepository
-	pointRepo  *pRepo.PointsRepository
+	circleRepo    *cRepo.CircleRepository
+	userRepo      *uRepo.UserRepository
+	choreRepo     *chRepo.ChoreRepository
+	pointRepo     *pRepo.PointsRepository
+	inbox         *nps.Inbox
+	eventProducer *events.EventsProducer
 }
 
-func NewHandler(cr *cRepo.CircleRepository, ur *uRepo.UserRepository, c *chRepo.ChoreRepository, pr *pRepo.PointsRepository) *Handler {
+func NewHandler(cr *cRepo.CircleRepository, ur *uRepo.UserRepository, c *chRepo.ChoreRepository, pr *pRepo.PointsRepository, inbox *nps.Inbox, ep *events.EventsProducer) *Handler {
 	return &Handler{
-		circleRepo: cr,
-		userRepo:   ur,
-		choreRepo:  c,
-		pointRepo:  pr,
+		circleRepo:    cr,
+		userRepo:      ur,
+		choreRepo:     c,
+		pointRepo:     pr,
+		inbox:         inbox,
+		eventProducer: ep,
 	}
 }
 
@@ -93,13 +102,18 @@ func (h *Handler) JoinCircle(c *gin.Context) {
 	}
 
 	// Add the user to the circle
-	err = h.circleRepo.AddUserToCircle(c, &cModel.UserCircle{
+	membership := &cModel.UserCircle{
 		CircleID: circle.ID,
 		UserID:   currentUser.ID,
 		Role:     "member",
 		IsActive: false,
+	}
+	err = h.circleRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.circleRepo.AddUserToCircle(ctx, membership); err != nil {
+			return err
+		}
+		return h.publishMemberChange(ctx, circle.ID, events.EventTypeCircleMemberRequested, &currentUser.User, currentUser.ID, nil, events.NewSnapshot(membership))
 	})
-
 	if err != nil {
 		log.Error("Error adding user to circle:", err)
 		c.JSON(500, gin.H{
@@ -141,6 +155,8 @@ func (h *Handler) LeaveCircle(c *gin.Context) {
 		return
 	}
 
+	before := h.memberSnapshot(c, circleID, currentUser.ID)
+
 	// START : HANDLE USER LEAVING CIRCLE
 	// bulk update chores:
 	if err := handleUserLeavingCircle(h, c, &currentUser.User, orginalCircleID); err != nil {
@@ -153,7 +169,15 @@ func (h *Handler) LeaveCircle(c *gin.Context) {
 
 	// END: HANDLE USER LEAVING CIRCLE
 
-	err = h.circleRepo.LeaveCircleByUserID(c, circleID, currentUser.ID)
+	err = h.circleRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.circleRepo.LeaveCircleByUserID(ctx, circleID, currentUser.ID); err != nil {
+			return err
+		}
+		if err := h.userRepo.UpdateUserCircle(ctx, currentUser.ID, orginalCircleID); err != nil {
+			return fmt.Errorf("updating user circle: %w", err)
+		}
+		return h.publishMemberChange(ctx, circleID, events.EventTypeCircleMemberLeft, &currentUser.User, currentUser.ID, before, nil)
+	})
 	if err != nil {
 		log.Error("Error leaving circle:", err)
 		c.JSON(500, gin.H{
@@ -162,13 +186,6 @@ func (h *Handler) LeaveCircle(c *gin.Context) {
 		return
 	}
 
-	if err := h.userRepo.UpdateUserCircle(c, currentUser.ID, orginalCircleID); err != nil {
-		log.Error("Error updating user circle:", err)
-		c.JSON(500, gin.H{
-			"error": "Error updating user circle",
-		})
-		return
-	}
 	c.JSON(200, gin.H{
 		"res": "User left circle successfully",
 	})
@@ -245,6 +262,7 @@ func (h *Handler) DeleteCircleMember(c *gin.Context) {
 		})
 		return
 	}
+	before := h.memberSnapshot(c, circleID, memberIDToDeleted)
 	orginalCircleID, err := h.circleRepo.GetUserOriginalCircle(c, memberIDToDeleted)
 	if handleUserLeavingCircle(h, c, &uModel.User{ID: memberIDToDeleted, CircleID: circleID}, orginalCircleID) != nil {
 		log.Error("Error handling user leaving circle:", err)
@@ -254,7 +272,12 @@ func (h *Handler) DeleteCircleMember(c *gin.Context) {
 		return
 	}
 
-	err = h.circleRepo.DeleteMemberByID(c, circleID, memberIDToDeleted)
+	err = h.circleRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.circleRepo.DeleteMemberByID(ctx, circleID, memberIDToDeleted); err != nil {
+			return err
+		}
+		return h.publishMemberChange(ctx, circleID, events.EventTypeCircleMemberRemoved, &currentUser.User, memberIDToDeleted, before, nil)
+	})
 	if err != nil {
 		log.Error("Error deleting circle member:", err)
 		c.JSON(500, gin.H{
@@ -262,6 +285,7 @@ func (h *Handler) DeleteCircleMember(c *gin.Context) {
 		})
 		return
 	}
+
 	c.JSON(200, gin.H{
 		"res": "User deleted from circle successfully",
 	})
@@ -405,7 +429,16 @@ func (h *Handler) AcceptJoinRequest(c *gin.Context) {
 		return
 	}
 
-	err = h.circleRepo.AcceptJoinRequest(c, currentUser.CircleID, requestID)
+	err = h.circleRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.circleRepo.AcceptJoinRequest(ctx, currentUser.CircleID, requestID); err != nil {
+			return err
+		}
+		if err := h.userRepo.UpdateUserCircle(ctx, requestedCircle.UserID, currentUser.CircleID); err != nil {
+			return fmt.Errorf("updating user circle: %w", err)
+		}
+		return h.publishMemberChange(ctx, currentUser.CircleID, events.EventTypeCircleMemberJoined, &currentUser.User, requestedCircle.UserID,
+			events.NewSnapshot(requestedCircle), h.memberSnapshot(ctx, currentUser.CircleID, requestedCircle.UserID))
+	})
 	if err != nil {
 		log.Error("Error accepting join request:", err)
 		c.JSON(500, gin.H{
@@ -414,14 +447,6 @@ func (h *Handler) AcceptJoinRequest(c *gin.Context) {
 		return
 	}
 
-	if err := h.userRepo.UpdateUserCircle(c, requestedCircle.UserID, currentUser.CircleID); err != nil {
-		log.Error("Error updating user circle:", err)
-		c.JSON(500, gin.H{
-			"error": "Error updating user circle",
-		})
-		return
-	}
-
 	c.JSON(200, gin.H{
 		"res": "Join request accepted successfully",
 	})
@@ -519,7 +544,14 @@ func (h *Handler) RedeemPoints(c *gin.Context) {
 		return
 	}
 
-	err = h.circleRepo.RedeemPoints(c, currentUser.CircleID, redeemReq.UserID, redeemReq.Points, currentUser.ID)
+	before := events.NewSnapshot(member)
+	err = h.circleRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.circleRepo.RedeemPoints(ctx, currentUser.CircleID, redeemReq.UserID, redeemReq.Points, currentUser.ID); err != nil {
+			return err
+		}
+		return h.publishMemberChange(ctx, currentUser.CircleID, events.EventTypeCirclePointsRedeemed, &currentUser.User, redeemReq.UserID,
+			before, h.memberSnapshot(ctx, currentUser.CircleID, redeemReq.UserID))
+	})
 	if err != nil {
 		log.Error("Error redeeming points:", err)
 		c.JSON(500, gin.H{
@@ -528,6 +560,19 @@ func (h *Handler) RedeemPoints(c *gin.Context) {
 		return
 	}
 
+	redeemedAt := time.Now().UTC()
+	if err := h.inbox.Add(c, &nModel.InboxItem{
+		UserID:    redeemReq.UserID,
+		CircleID:  currentUser.CircleID,
+		EventType: nModel.EventTypeRedemption,
+		Key:       fmt.Sprintf("%s:points:%d", nModel.EventTypeRedemption, redeemedAt.UnixNano()),
+		Text:      fmt.Sprintf("🎁 **%s** redeemed %d of your points", currentUser.DisplayName, redeemReq.Points),
+		Data:      nModel.JSONB{"points": redeemReq.Points, "redeemedBy": currentUser.ID},
+		CreatedAt: redeemedAt,
+	}); err != nil {
+		log.Error("Error adding redemption to inbox:", err)
+	}
+
 	c.JSON(200, gin.H{
 		"res": "Points redeemed successfully",
 	})
@@ -573,6 +618,7 @@ func (h *Handler) ChangeMemberRole(c *gin.Context) {
 	isAdmin := false
 	memberFound := false
 	adminCount := 0
+	var before events.Snapshot
 	for _, user := range users {
 		if user.Role == "admin" {
 			adminCount++
@@ -582,6 +628,7 @@ func (h *Handler) ChangeMemberRole(c *gin.Context) {
 		}
 		if user.UserID == req.MemberID {
 			memberFound = true
+			before = events.NewSnapshot(user)
 		}
 	}
 	if !isAdmin {
@@ -597,7 +644,13 @@ func (h *Handler) ChangeMemberRole(c *gin.Context) {
 		return
 	}
 
-	err = h.circleRepo.ChangeUserRole(c, currentUser.CircleID, req.MemberID, req.Role)
+	err = h.circleRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.circleRepo.ChangeUserRole(ctx, currentUser.CircleID, req.MemberID, req.Role); err != nil {
+			return err
+		}
+		return h.publishMemberChange(ctx, currentUser.CircleID, events.EventTypeCircleMemberRoleChanged, &currentUser.User, req.MemberID,
+			before, h.memberSnapshot(ctx, currentUser.CircleID, req.MemberID))
+	})
 	if err != nil {
 		log.Error("Error changing member role:", err)
 		c.JSON(500, gin.H{
@@ -612,6 +665,30 @@ func (h *Handler) ChangeMemberRole(c *gin.Context) {
 
 }
 
+// memberSnapshot captures the membership of the user in the circle, nil when the user isn't a member
+func (h *Handler) memberSnapshot(c context.Context, circleID int, userID int) events.Snapshot {
+	members, err := h.circleRepo.GetCircleUsers(c, circleID)
+	if err != nil {
+		return nil
+	}
+	for _, member := range members {
+		if member.UserID == userID {
+			return events.NewSnapshot(member)
+		}
+	}
+	return nil
+}
+
+// publishMemberChange publishes the lifecycle webhook of a membership to the circle it belongs to, which is
+// not always the actor's current circle. call it in the transaction of the change
+func (h *Handler) publishMemberChange(c context.Context, circleID int, eventType events.EventType, actor *uModel.User, memberID int, before, after events.Snapshot) error {
+	circle, err := h.circleRepo.GetCircleByID(c, circleID)
+	if err != nil {
+		return fmt.Errorf("getting circle for webhook: %w", err)
+	}
+	return h.eventProducer.ResourceChanged(c, circleID, circle.WebhookURL, eventType, events.ResourceCircleMember, memberID, actor, before, after)
+}
+
 func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
 	log.Println("Registering routes")
 
@@ -0,0 +1,54 @@
+// Package dbtx carries a database transaction in a context so that writes of different repositories can be
+// committed together, e.g. a completed chore and the event it publishes
+package dbtx
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+type txState struct {
+	tx          *gorm.DB
+	afterCommit []func()
+}
+
+// Run calls fn in a transaction that is carried by the context passed to fn. repositories that get their
+// connection from DB join it, and calls of Run inside fn join the outer transaction
+func Run(c context.Context, db *gorm.DB, fn func(c context.Context) error) error {
+	if _, ok := c.Value(txKey{}).(*txState); ok {
+		return fn(c)
+	}
+	state := &txState{}
+	err := db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		state.tx = tx
+		return fn(context.WithValue(c, txKey{}, state))
+	})
+	if err != nil {
+		return err
+	}
+	for _, callback := range state.afterCommit {
+		callback()
+	}
+	return nil
+}
+
+// DB returns the transaction of the context, or db when the context has none
+func DB(c context.Context, db *gorm.DB) *gorm.DB {
+	if state, ok := c.Value(txKey{}).(*txState); ok {
+		return state.tx.WithContext(c)
+	}
+	return db.WithContext(c)
+}
+
+// AfterCommit calls fn once the transaction of the context is committed, right away when the context has
+// no transaction. it's never called when the transaction rolls back
+func AfterCommit(c context.Context, fn func()) {
+	if state, ok := c.Value(txKey{}).(*txState); ok {
+		state.afterCommit = append(state.afterCommit, fn)
+		return
+	}
+	fn()
+}
@@ -0,0 +1,88 @@
+package dbtx
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type record struct {
+	ID   int
+	Name string
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "dbtx.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&record{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func countRecords(t *testing.T, db *gorm.DB) int64 {
+	var count int64
+	if err := db.Model(&record{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestRun(t *testing.T) {
+	db := newTestDB(t)
+	errRollback := errors.New("rollback")
+
+	committed := 0
+	err := Run(context.Background(), db, func(c context.Context) error {
+		AfterCommit(c, func() { committed++ })
+		if err := DB(c, db).Create(&record{Name: "kept"}).Error; err != nil {
+			return err
+		}
+		// nested calls join the outer transaction
+		return Run(c, db, func(c context.Context) error {
+			return DB(c, db).Create(&record{Name: "nested"}).Error
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := countRecords(t, db); got != 2 {
+		t.Errorf("got %d records after commit, want 2", got)
+	}
+	if committed != 1 {
+		t.Errorf("after commit callback called %d times, want 1", committed)
+	}
+
+	err = Run(context.Background(), db, func(c context.Context) error {
+		AfterCommit(c, func() { committed++ })
+		if err := DB(c, db).Create(&record{Name: "dropped"}).Error; err != nil {
+			return err
+		}
+		return Run(c, db, func(c context.Context) error {
+			return errRollback
+		})
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Run() error = %v, want %v", err, errRollback)
+	}
+	if got := countRecords(t, db); got != 2 {
+		t.Errorf("got %d records after rollback, want 2", got)
+	}
+	if committed != 1 {
+		t.Errorf("after commit callback called on rollback")
+	}
+
+	AfterCommit(context.Background(), func() { committed++ })
+	if committed != 2 {
+		t.Errorf("after commit callback without transaction wasn't called right away")
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"donetick.com/core/config"
 	chModel "donetick.com/core/internal/chore/model"
 	cModel "donetick.com/core/internal/circle/model"
+	evModel "donetick.com/core/internal/events/model"
 	nModel "donetick.com/core/internal/notifier/model"
 	pModel "donetick.com/core/internal/points"
 	rModel "donetick.com/core/internal/rewards/model"
@@ -38,8 +39,19 @@ func Migration(db *gorm.DB) error {
 		tModel.ThingHistory{},
 		uModel.APIToken{},
 		uModel.UserNotificationTarget{},
+		uModel.NotificationTarget{},
+		uModel.NotificationSettings{},
+		uModel.PushSubscription{},
+		uModel.TelegramLinkCode{},
+		nModel.VAPIDKey{},
+		nModel.MessageTemplate{},
+		nModel.InboxItem{},
 		chModel.Label{},
 		chModel.ChoreLabels{},
+		chModel.EscalationPolicy{},
+		evModel.WebhookDelivery{},
+		evModel.WebhookSubscription{},
+		evModel.OutboxEvent{},
 		migrations.Migration{},
 		pModel.PointsHistory{},
 		stModel.SubTask{},
@@ -485,6 +485,28 @@ func (es *EmailSender) SendResetPasswordEmail(c context.Context, to, code string
 
 }
 
+// IsConfigured reports whether an SMTP host is set up
+func (es *EmailSender) IsConfigured() bool {
+	return es != nil && es.client.Host != ""
+}
+
+// AppHost is the base URL of the frontend, used to build links in emails
+func (es *EmailSender) AppHost() string {
+	return es.appHost
+}
+
+// SendNotificationEmail sends a multipart email with a plain text body and an HTML alternative
+func (es *EmailSender) SendNotificationEmail(c context.Context, to, subject, textBody, htmlBody string) error {
+	msg := gomail.NewMessage()
+	msg.SetHeader("From", es.client.Username)
+	msg.SetHeader("To", to)
+	msg.SetHeader("Subject", subject)
+	msg.SetBody("text/plain", textBody)
+	msg.AddAlternative("text/html", htmlBody)
+
+	return es.client.DialAndSend(msg)
+}
+
 // func (es *EmailSender) SendFeedbackRequestEmail(to, code string) error {
 // 	// msg := []byte(fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", to, subject, body))
 // 	msg := gomail.NewMessage()
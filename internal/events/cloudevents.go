@@ -0,0 +1,100 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	evModel "donetick.com/core/internal/events/model"
+)
+
+const (
+	CloudEventsSpecVersion = "1.0"
+	// CloudEventsTypePrefix makes the event types reverse-DNS names as the spec recommends, task.completed
+	// is sent as com.donetick.task.completed
+	CloudEventsTypePrefix = "com.donetick."
+	// CloudEventsSchemaBase is the default base of the dataschema of the events, the schema of the data of
+	// an event type in a payload version is <base>/v<version>/<type>.json
+	CloudEventsSchemaBase = "https://donetick.com/schemas/webhooks"
+
+	CONTENT_TYPE_CLOUDEVENTS_JSON = "application/cloudevents+json; charset=utf-8"
+)
+
+// CloudEvent is the structured mode JSON envelope of an event
+type CloudEvent struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            time.Time       `json:"time"`
+	DataContentType string          `json:"datacontenttype"`
+	DataSchema      string          `json:"dataschema"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// NewCloudEvent returns the CloudEvent of a published event. the source is the circle of the event under
+// sourcePrefix, the id is the idempotency key of the event and the dataschema is under schemaBase in the
+// payload version of the event
+func NewCloudEvent(event *evModel.OutboxEvent, sourcePrefix string, schemaBase string) (*CloudEvent, error) {
+	var published struct {
+		Version   int             `json:"version"`
+		Timestamp time.Time       `json:"timestamp"`
+		Data      json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(event.Payload), &published); err != nil {
+		return nil, err
+	}
+	data := published.Data
+	if len(data) == 0 {
+		data = json.RawMessage("null")
+	}
+	return &CloudEvent{
+		SpecVersion:     CloudEventsSpecVersion,
+		ID:              event.IdempotencyKey,
+		Source:          fmt.Sprintf("%s/circles/%d", strings.TrimRight(sourcePrefix, "/"), event.CircleID),
+		Type:            CloudEventsTypePrefix + event.EventType,
+		Subject:         event.Subject,
+		Time:            published.Timestamp.UTC(),
+		DataContentType: CONTENT_TYPE_JSON,
+		DataSchema:      fmt.Sprintf("%s/v%d/%s.json", strings.TrimRight(schemaBase, "/"), published.Version, event.EventType),
+		Data:            data,
+	}, nil
+}
+
+// encodeEvent returns the body and the extra headers of a delivery of the event in the given format
+func encodeEvent(event *evModel.OutboxEvent, format evModel.WebhookFormat, sourcePrefix string, schemaBase string) (string, evModel.WebhookHeaders, error) {
+	switch format {
+	case evModel.WebhookFormatCloudEventsStructured:
+		cloudEvent, err := NewCloudEvent(event, sourcePrefix, schemaBase)
+		if err != nil {
+			return "", nil, err
+		}
+		body, err := json.Marshal(cloudEvent)
+		if err != nil {
+			return "", nil, err
+		}
+		return string(body), evModel.WebhookHeaders{HEAD_CONTENT_TYPE: CONTENT_TYPE_CLOUDEVENTS_JSON}, nil
+	case evModel.WebhookFormatCloudEventsBinary:
+		cloudEvent, err := NewCloudEvent(event, sourcePrefix, schemaBase)
+		if err != nil {
+			return "", nil, err
+		}
+		headers := evModel.WebhookHeaders{
+			HEAD_CONTENT_TYPE: cloudEvent.DataContentType,
+			"ce-specversion":  cloudEvent.SpecVersion,
+			"ce-id":           cloudEvent.ID,
+			"ce-source":       cloudEvent.Source,
+			"ce-type":         cloudEvent.Type,
+			"ce-time":         cloudEvent.Time.Format(time.RFC3339Nano),
+			"ce-dataschema":   cloudEvent.DataSchema,
+		}
+		if cloudEvent.Subject != "" {
+			headers["ce-subject"] = cloudEvent.Subject
+		}
+		return string(cloudEvent.Data), headers, nil
+	default:
+		return event.Payload, nil, nil
+	}
+}
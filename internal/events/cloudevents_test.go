@@ -0,0 +1,121 @@
+package events
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"donetick.com/core/config"
+	evModel "donetick.com/core/internal/events/model"
+)
+
+func TestEncodeEvent(t *testing.T) {
+	event := &evModel.OutboxEvent{
+		IdempotencyKey: "evt_1",
+		CircleID:       7,
+		EventType:      string(EventTypeTaskCompleted),
+		Subject:        "task/12",
+		Payload:        `{"id":"evt_1","type":"task.completed","version":1,"timestamp":"2023-11-14T22:13:20Z","data":{"chore":{"id":12}}}`,
+	}
+
+	payload, headers, err := encodeEvent(event, evModel.WebhookFormatDonetick, "", CloudEventsSchemaBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload != event.Payload || headers != nil {
+		t.Errorf("donetick format changed the payload to %s with headers %v", payload, headers)
+	}
+
+	payload, headers, err = encodeEvent(event, evModel.WebhookFormatCloudEventsStructured, "https://donetick.example.com/", CloudEventsSchemaBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers[HEAD_CONTENT_TYPE] != CONTENT_TYPE_CLOUDEVENTS_JSON {
+		t.Errorf("structured content type = %q", headers[HEAD_CONTENT_TYPE])
+	}
+	var structured map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &structured); err != nil {
+		t.Fatal(err)
+	}
+	wantAttributes := map[string]string{
+		"specversion":     "1.0",
+		"id":              "evt_1",
+		"source":          "https://donetick.example.com/circles/7",
+		"type":            "com.donetick.task.completed",
+		"subject":         "task/12",
+		"time":            "2023-11-14T22:13:20Z",
+		"datacontenttype": "application/json",
+		"dataschema":      "https://donetick.com/schemas/webhooks/v1/task.completed.json",
+	}
+	for attribute, want := range wantAttributes {
+		if structured[attribute] != want {
+			t.Errorf("structured %s = %v, want %s", attribute, structured[attribute], want)
+		}
+	}
+	data, _ := json.Marshal(structured["data"])
+	if string(data) != `{"chore":{"id":12}}` {
+		t.Errorf("structured data = %s", data)
+	}
+
+	payload, headers, err = encodeEvent(event, evModel.WebhookFormatCloudEventsBinary, "https://donetick.example.com", "https://schemas.example.com/donetick/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload != `{"chore":{"id":12}}` {
+		t.Errorf("binary body = %s, want the data of the event", payload)
+	}
+	wantHeaders := map[string]string{
+		HEAD_CONTENT_TYPE: "application/json",
+		"ce-specversion":  "1.0",
+		"ce-id":           "evt_1",
+		"ce-source":       "https://donetick.example.com/circles/7",
+		"ce-type":         "com.donetick.task.completed",
+		"ce-subject":      "task/12",
+		"ce-time":         "2023-11-14T22:13:20Z",
+		"ce-dataschema":   "https://schemas.example.com/donetick/v1/task.completed.json",
+	}
+	for name, want := range wantHeaders {
+		if headers[name] != want {
+			t.Errorf("binary header %s = %q, want %q", name, headers[name], want)
+		}
+	}
+}
+
+func TestEventSourceIsAbsolute(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want string
+	}{
+		{
+			name: "configured event source",
+			cfg: config.Config{
+				WebhookConfig: config.WebhookConfig{EventSource: "https://events.example.com"},
+				Server:        config.ServerConfig{PublicHost: "donetick.example.com"},
+			},
+			want: "https://events.example.com",
+		},
+		{
+			name: "public host",
+			cfg:  config.Config{Server: config.ServerConfig{PublicHost: "donetick.example.com"}},
+			want: "https://donetick.example.com",
+		},
+		{
+			name: "public host with a scheme",
+			cfg:  config.Config{Server: config.ServerConfig{PublicHost: "http://donetick.lan:2021"}},
+			want: "http://donetick.lan:2021",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eventSource(&tt.cfg); got != tt.want {
+				t.Errorf("eventSource() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	source, err := url.Parse(eventSource(&config.Config{Server: config.ServerConfig{Port: 2021}}))
+	if err != nil || !source.IsAbs() || source.Host == "" {
+		t.Errorf("eventSource() without a public host = %v, want an absolute URI", source)
+	}
+}
@@ -0,0 +1,375 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	evModel "donetick.com/core/internal/events/model"
+	"donetick.com/core/internal/utils"
+)
+
+const (
+	maxDeliveryAttempts = 6
+	baseRetryDelay      = time.Minute
+	maxRetryDelay       = 6 * time.Hour
+
+	retryJobInterval  = 30 * time.Second
+	retryBatchSize    = 50
+	deliveryRetention = 30 * 24 * time.Hour
+
+	// responseSnippetSize is how much of the receiver's response is kept in the delivery log
+	responseSnippetSize = 1024
+
+	// SignatureTolerance is how old a signed delivery can be before receivers should reject it
+	SignatureTolerance = 5 * time.Minute
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrSubscriptionNotFound is returned when the subscription of a delivery was deleted
+	ErrSubscriptionNotFound = errors.New("webhook subscription not found")
+	// errSubscriptionDisabled stops the retries of a delivery whose subscription was disabled
+	errSubscriptionDisabled = errors.New("webhook subscription disabled")
+)
+
+// newDeliveries returns a delivery of the outbox event for the circle webhook URL and for every
+// subscription that subscribed to its type, encoded in the format of the subscription. sourcePrefix is
+// the prefix of the CloudEvents source and schemaBase the base of their dataschema
+func newDeliveries(event *evModel.OutboxEvent, subscriptions []*evModel.WebhookSubscription, sourcePrefix string, schemaBase string) ([]*evModel.WebhookDelivery, error) {
+	var deliveries []*evModel.WebhookDelivery
+	if event.URL != "" {
+		deliveries = append(deliveries, &evModel.WebhookDelivery{
+			CircleID:  event.CircleID,
+			EventType: event.EventType,
+			URL:       event.URL,
+			Payload:   event.Payload,
+			Status:    evModel.DeliveryStatusPending,
+			EventID:   event.IdempotencyKey,
+			Format:    evModel.WebhookFormatDonetick,
+		})
+	}
+	for _, subscription := range subscriptions {
+		if !subscription.Enabled || !subscription.EventTypes.Matches(event.EventType) {
+			continue
+		}
+		format := subscription.Format
+		if format == "" {
+			format = evModel.WebhookFormatDonetick
+		}
+		payload, headers, err := encodeEvent(event, format, sourcePrefix, schemaBase)
+		if err != nil {
+			return nil, err
+		}
+		subscriptionID := subscription.ID
+		deliveries = append(deliveries, &evModel.WebhookDelivery{
+			CircleID:       event.CircleID,
+			EventType:      event.EventType,
+			URL:            subscription.URL,
+			Payload:        payload,
+			Status:         evModel.DeliveryStatusPending,
+			SubscriptionID: &subscriptionID,
+			EventID:        event.IdempotencyKey,
+			Format:         format,
+			Headers:        headers,
+		})
+	}
+	return deliveries, nil
+}
+
+// deliver makes one attempt and schedules the next one when it fails, the outcome is saved to the log
+func (p *EventsProducer) deliver(c context.Context, delivery *evModel.WebhookDelivery) {
+	p.logger.Debugw("Sending webhook event", "type", delivery.EventType, "url", delivery.URL, "delivery_id", delivery.ID)
+
+	secret, err := p.deliverySecret(c, delivery)
+	if err != nil {
+		p.logger.Errorw("Failed to get webhook secret", "circle_id", delivery.CircleID, "delivery_id", delivery.ID, "error", err)
+		p.recordAttempt(c, delivery, 0, "", 0, err)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(c, METHOD_POST, delivery.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		p.recordAttempt(c, delivery, 0, "", 0, err)
+		return
+	}
+	req.Header.Set(HEAD_CONTENT_TYPE, CONTENT_TYPE_JSON)
+	req.Header.Set(HEAD_EVENT, delivery.EventType)
+	req.Header.Set(HEAD_DELIVERY, strconv.Itoa(delivery.ID))
+	if delivery.EventID != "" {
+		req.Header.Set(HEAD_IDEMPOTENCY_KEY, delivery.EventID)
+	}
+	for name, value := range delivery.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set(HEAD_SIGNATURE, Sign(secret, time.Now(), SignedPayload(req.Header, []byte(delivery.Payload))))
+
+	start := time.Now()
+	resp, err := p.client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		p.recordAttempt(c, delivery, 0, "", latency, err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, responseSnippetSize))
+
+	var sendErr error
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		sendErr = fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	p.recordAttempt(c, delivery, resp.StatusCode, string(body), latency, sendErr)
+}
+
+func (p *EventsProducer) recordAttempt(c context.Context, delivery *evModel.WebhookDelivery, status int, body string, latency time.Duration, sendErr error) {
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	delivery.LatencyMs = latency.Milliseconds()
+	delivery.NextAttemptAt = nil
+	delivery.LastError = nil
+
+	switch {
+	case sendErr == nil:
+		delivery.Status = evModel.DeliveryStatusSucceeded
+	case delivery.Attempts >= maxDeliveryAttempts, errors.Is(sendErr, ErrSubscriptionNotFound), errors.Is(sendErr, errSubscriptionDisabled):
+		lastError := sendErr.Error()
+		delivery.LastError = &lastError
+		delivery.Status = evModel.DeliveryStatusFailed
+		p.logger.Errorw("Giving up on webhook delivery", "delivery_id", delivery.ID, "circle_id", delivery.CircleID, "attempts", delivery.Attempts, "error", sendErr)
+	default:
+		lastError := sendErr.Error()
+		delivery.LastError = &lastError
+		delivery.Status = evModel.DeliveryStatusPending
+		nextAttemptAt := now.Add(utils.RetryBackoff(delivery.Attempts, baseRetryDelay, maxRetryDelay))
+		delivery.NextAttemptAt = &nextAttemptAt
+		p.logger.Warnw("Webhook delivery failed, will retry", "delivery_id", delivery.ID, "circle_id", delivery.CircleID, "attempts", delivery.Attempts, "next_attempt_at", nextAttemptAt, "error", sendErr)
+	}
+
+	if err := p.webhookRepo.UpdateDelivery(c, delivery); err != nil {
+		p.logger.Errorw("Failed to save webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// retryDeliveries retries the failed deliveries whose backoff has passed and drops old log entries
+func (p *EventsProducer) retryDeliveries(c context.Context) {
+	ticker := time.NewTicker(retryJobInterval)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		}
+		now := time.Now().UTC()
+		deliveries, err := p.webhookRepo.GetDueDeliveries(c, now, retryBatchSize)
+		if err != nil {
+			p.logger.Errorw("Failed to get webhook deliveries to retry", "error", err)
+			continue
+		}
+		for _, delivery := range deliveries {
+			p.deliver(c, delivery)
+		}
+
+		if now.Sub(lastCleanup) > 24*time.Hour {
+			lastCleanup = now
+			if err := p.webhookRepo.DeleteDeliveriesBefore(c, now.Add(-deliveryRetention)); err != nil {
+				p.logger.Errorw("Failed to clean up webhook deliveries", "error", err)
+			}
+			if err := p.webhookRepo.DeleteDispatchedOutboxEventsBefore(c, now.Add(-outboxRetention)); err != nil {
+				p.logger.Errorw("Failed to clean up webhook events", "error", err)
+			}
+		}
+	}
+}
+
+// Redeliver sends the payload of a logged delivery again to the current URL of its endpoint as a new
+// delivery with the same idempotency key, the original is kept in the log unchanged
+func (p *EventsProducer) Redeliver(c context.Context, original *evModel.WebhookDelivery) (*evModel.WebhookDelivery, error) {
+	url := original.URL
+	if original.SubscriptionID != nil {
+		subscription, err := p.webhookRepo.GetSubscription(c, original.CircleID, *original.SubscriptionID)
+		if err != nil {
+			return nil, err
+		}
+		if subscription == nil {
+			return nil, ErrSubscriptionNotFound
+		}
+		url = subscription.URL
+	} else {
+		circle, err := p.circleRepo.GetCircleByID(c, original.CircleID)
+		if err != nil {
+			return nil, err
+		}
+		if circle.WebhookURL != nil && *circle.WebhookURL != "" {
+			url = *circle.WebhookURL
+		}
+	}
+	originalID := original.ID
+	leasedUntil := time.Now().UTC().Add(deliveryLease)
+	delivery := &evModel.WebhookDelivery{
+		CircleID:       original.CircleID,
+		EventType:      original.EventType,
+		URL:            url,
+		Payload:        original.Payload,
+		Status:         evModel.DeliveryStatusPending,
+		RedeliveryOf:   &originalID,
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		Format:         original.Format,
+		Headers:        original.Headers,
+		NextAttemptAt:  &leasedUntil,
+	}
+	if err := p.webhookRepo.CreateDelivery(c, delivery); err != nil {
+		return nil, err
+	}
+	// the request context ends with the response so the attempt runs until the producer stops, the retry
+	// job takes over when it fails
+	attempt := *delivery
+	go p.deliver(p.ctx, &attempt)
+	return delivery, nil
+}
+
+// deliverySecret returns the secret the delivery is signed with. deliveries of a subscription follow its
+// current URL, so fixing a wrong URL also fixes the pending retries
+func (p *EventsProducer) deliverySecret(c context.Context, delivery *evModel.WebhookDelivery) (string, error) {
+	if delivery.SubscriptionID == nil {
+		return p.WebhookSecret(c, delivery.CircleID)
+	}
+	subscription, err := p.webhookRepo.GetSubscription(c, delivery.CircleID, *delivery.SubscriptionID)
+	if err != nil {
+		return "", err
+	}
+	if subscription == nil {
+		return "", ErrSubscriptionNotFound
+	}
+	if !subscription.Enabled {
+		return "", errSubscriptionDisabled
+	}
+	delivery.URL = subscription.URL
+	return subscription.Secret, nil
+}
+
+// WebhookSecret returns the circle's signing secret. the secret is created with the webhook URL, circles
+// whose URL was set before get one here that concurrent callers agree on
+func (p *EventsProducer) WebhookSecret(c context.Context, circleID int) (string, error) {
+	circle, err := p.circleRepo.GetCircleByID(c, circleID)
+	if err != nil {
+		return "", err
+	}
+	if circle.WebhookSecret != nil && *circle.WebhookSecret != "" {
+		return *circle.WebhookSecret, nil
+	}
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := p.circleRepo.SetWebhookSecretIfEmpty(c, circleID, secret); err != nil {
+		return "", err
+	}
+	circle, err = p.circleRepo.GetCircleByID(c, circleID)
+	if err != nil {
+		return "", err
+	}
+	if circle.WebhookSecret == nil || *circle.WebhookSecret == "" {
+		return "", fmt.Errorf("circle %d has no webhook secret", circleID)
+	}
+	return *circle.WebhookSecret, nil
+}
+
+// RotateWebhookSecret replaces the circle's signing secret, deliveries are signed with the new one right away
+func (p *EventsProducer) RotateWebhookSecret(c context.Context, circleID int) (string, error) {
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := p.circleRepo.SetWebhookSecret(c, circleID, secret); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// Sign returns the X-Donetick-Signature header of a payload sent at timestamp: t=<unix seconds>,v1=<hex
+// HMAC-SHA256 of "<t>.<payload>" keyed with the circle's secret>. the payload of a delivery is its
+// SignedPayload
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + signature(secret, t, payload)
+}
+
+// SignedPayload returns the payload the signature of a delivery covers. binary CloudEvents carry the id, type,
+// subject and the other attributes in ce-* headers, they are signed together with the body so a captured
+// delivery can't be replayed as another event: every ce-* header as "<lowercase name>:<value>\n" in name
+// order, then the body. the payload of deliveries without ce-* headers is the body
+func SignedPayload(headers http.Header, body []byte) []byte {
+	var names []string
+	for name := range headers {
+		if strings.HasPrefix(strings.ToLower(name), "ce-") {
+			names = append(names, strings.ToLower(name))
+		}
+	}
+	if len(names) == 0 {
+		return body
+	}
+	sort.Strings(names)
+	var payload bytes.Buffer
+	for _, name := range names {
+		payload.WriteString(name + ":" + headers.Get(name) + "\n")
+	}
+	payload.Write(body)
+	return payload.Bytes()
+}
+
+// VerifySignature checks a X-Donetick-Signature header the way receivers should, including that it was
+// signed within tolerance of now
+func VerifySignature(secret string, header string, payload []byte, now time.Time, tolerance time.Duration) error {
+	var t string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+	expected := signature(secret, t, payload)
+	for _, candidate := range signatures {
+		if hmac.Equal([]byte(candidate), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func signature(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,128 @@
+package events
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	cModel "donetick.com/core/internal/circle/model"
+	cRepo "donetick.com/core/internal/circle/repo"
+	evModel "donetick.com/core/internal/events/model"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := "whsec_test"
+	payload := []byte(`{"type":"task.completed","data":{"id":1}}`)
+	signedAt := time.Unix(1700000000, 0)
+	header := Sign(secret, signedAt, payload)
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		payload []byte
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "valid", secret: secret, header: header, payload: payload, now: signedAt},
+		{name: "within tolerance", secret: secret, header: header, payload: payload, now: signedAt.Add(SignatureTolerance)},
+		{name: "too old", secret: secret, header: header, payload: payload, now: signedAt.Add(SignatureTolerance + time.Second), wantErr: true},
+		{name: "from the future", secret: secret, header: header, payload: payload, now: signedAt.Add(-SignatureTolerance - time.Second), wantErr: true},
+		{name: "wrong secret", secret: "whsec_other", header: header, payload: payload, now: signedAt, wantErr: true},
+		{name: "tampered payload", secret: secret, header: header, payload: []byte(`{"type":"task.completed","data":{"id":2}}`), now: signedAt, wantErr: true},
+		{name: "rotated secret among signatures", secret: secret, header: header + ",v1=deadbeef", payload: payload, now: signedAt},
+		{name: "missing timestamp", secret: secret, header: "v1=" + signature(secret, "1700000000", payload), payload: payload, now: signedAt, wantErr: true},
+		{name: "empty header", secret: secret, header: "", payload: payload, now: signedAt, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(tt.secret, tt.header, tt.payload, tt.now, SignatureTolerance)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignedPayloadCoversCloudEventsAttributes(t *testing.T) {
+	secret := "whsec_test"
+	signedAt := time.Unix(1700000000, 0)
+	encode := func(eventType EventType) (http.Header, []byte) {
+		event := &evModel.OutboxEvent{
+			IdempotencyKey: "evt_" + string(eventType),
+			CircleID:       7,
+			EventType:      string(eventType),
+			Subject:        "task/12",
+			Payload:        `{"version":1,"timestamp":"2023-11-14T22:13:20Z","data":{"chore":{"id":12}}}`,
+		}
+		body, headers, err := encodeEvent(event, evModel.WebhookFormatCloudEventsBinary, "https://donetick.example.com", CloudEventsSchemaBase)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header := http.Header{}
+		for name, value := range headers {
+			header.Set(name, value)
+		}
+		return header, []byte(body)
+	}
+
+	completedHeaders, completedBody := encode(EventTypeTaskCompleted)
+	skippedHeaders, skippedBody := encode(EventTypeTaskSkipped)
+	if string(completedBody) != string(skippedBody) {
+		t.Fatalf("binary bodies differ, the test needs events with the same data")
+	}
+	completedSignature := Sign(secret, signedAt, SignedPayload(completedHeaders, completedBody))
+	if completedSignature == Sign(secret, signedAt, SignedPayload(skippedHeaders, skippedBody)) {
+		t.Errorf("a completion and a skip with the same data have the same signature")
+	}
+	if err := VerifySignature(secret, completedSignature, SignedPayload(completedHeaders, completedBody), signedAt, SignatureTolerance); err != nil {
+		t.Errorf("VerifySignature() of the delivery = %v", err)
+	}
+	completedHeaders.Set("ce-type", skippedHeaders.Get("ce-type"))
+	if err := VerifySignature(secret, completedSignature, SignedPayload(completedHeaders, completedBody), signedAt, SignatureTolerance); err == nil {
+		t.Errorf("VerifySignature() accepted the delivery replayed with another ce-type")
+	}
+
+	body := []byte(`{"type":"task.completed"}`)
+	if string(SignedPayload(http.Header{"Content-Type": {"application/json"}}, body)) != string(body) {
+		t.Errorf("SignedPayload() of a delivery without ce-* headers is not its body")
+	}
+}
+
+func TestWebhookSecretIsKeptOnceSet(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "circles.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&cModel.Circle{}); err != nil {
+		t.Fatal(err)
+	}
+	circleRepo := cRepo.NewCircleRepository(db)
+	producer := NewEventsProducer(&config.Config{}, nil, circleRepo)
+	circle := &cModel.Circle{Name: "home"}
+	if err := db.Create(circle).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// a circle whose URL was set before it had a secret gets one on first use
+	secret, err := producer.WebhookSecret(ctx, circle.ID)
+	if err != nil || secret == "" {
+		t.Fatalf("WebhookSecret() = %q, %v, want a new secret", secret, err)
+	}
+	// a secret created concurrently doesn't replace the one deliveries were signed with
+	if err := circleRepo.SetWebhookSecretIfEmpty(ctx, circle.ID, "whsec_other"); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := producer.WebhookSecret(ctx, circle.ID); err != nil || again != secret {
+		t.Errorf("WebhookSecret() = %q, %v, want the first secret %q", again, err, secret)
+	}
+}
@@ -0,0 +1,340 @@
+package events
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	cRepo "donetick.com/core/internal/circle/repo"
+	evModel "donetick.com/core/internal/events/model"
+	evRepo "donetick.com/core/internal/events/repo"
+	"donetick.com/core/logging"
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultDeliveryPageSize = 20
+	maxDeliveryPageSize     = 100
+)
+
+type Handler struct {
+	producer    *EventsProducer
+	webhookRepo *evRepo.WebhookRepository
+	circleRepo  *cRepo.CircleRepository
+}
+
+func NewHandler(ep *EventsProducer, wr *evRepo.WebhookRepository, cr *cRepo.CircleRepository) *Handler {
+	return &Handler{
+		producer:    ep,
+		webhookRepo: wr,
+		circleRepo:  cr,
+	}
+}
+
+// requireCircleAdmin writes the error response and returns false when the current user can't manage the circle webhook
+func (h *Handler) requireCircleAdmin(c *gin.Context, userID, circleID int) bool {
+	log := logging.FromContext(c)
+	members, err := h.circleRepo.GetCircleUsers(c, circleID)
+	if err != nil {
+		log.Error("Error getting circle members:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting circle members",
+		})
+		return false
+	}
+	for _, member := range members {
+		if member.UserID == userID && member.Role == "admin" {
+			return true
+		}
+	}
+	c.JSON(403, gin.H{
+		"error": "You are not an admin of this circle",
+	})
+	return false
+}
+
+// queryInt reads an optional non-negative integer query parameter
+func queryInt(c *gin.Context, name string, fallback int) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// getWebhook returns the circle's webhook URL and the secret its deliveries are signed with
+func (h *Handler) getWebhook(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+	circle, err := h.circleRepo.GetCircleByID(c, currentUser.CircleID)
+	if err != nil {
+		log.Error("Error getting circle:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting webhook",
+		})
+		return
+	}
+	secret, err := h.producer.WebhookSecret(c, currentUser.CircleID)
+	if err != nil {
+		log.Error("Error getting webhook secret:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting webhook",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{
+			"url":    circle.WebhookURL,
+			"secret": secret,
+		},
+	})
+}
+
+// rotateWebhookSecret replaces the signing secret, receivers have to be updated with the new one
+func (h *Handler) rotateWebhookSecret(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+	secret, err := h.producer.RotateWebhookSecret(c, currentUser.CircleID)
+	if err != nil {
+		logging.FromContext(c).Error("Error rotating webhook secret:", err)
+		c.JSON(500, gin.H{
+			"error": "Error rotating webhook secret",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{
+			"secret": secret,
+		},
+	})
+}
+
+// getDeliveries returns a page of the circle's webhook deliveries, newest first. status filters them
+// by pending, succeeded or failed and subscriptionId by endpoint
+func (h *Handler) getDeliveries(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+
+	limit, ok := queryInt(c, "limit", defaultDeliveryPageSize)
+	if !ok || limit == 0 {
+		c.JSON(400, gin.H{
+			"error": "Invalid limit",
+		})
+		return
+	}
+	if limit > maxDeliveryPageSize {
+		limit = maxDeliveryPageSize
+	}
+	offset, ok := queryInt(c, "offset", 0)
+	if !ok {
+		c.JSON(400, gin.H{
+			"error": "Invalid offset",
+		})
+		return
+	}
+	status := evModel.DeliveryStatus(c.Query("status"))
+	switch status {
+	case "", evModel.DeliveryStatusPending, evModel.DeliveryStatusSucceeded, evModel.DeliveryStatusFailed:
+	default:
+		c.JSON(400, gin.H{
+			"error": "Invalid status",
+		})
+		return
+	}
+
+	var subscriptionID *int
+	if raw := c.Query("subscriptionId"); raw != "" {
+		id, err := strconv.Atoi(raw)
+		if err != nil {
+			c.JSON(400, gin.H{
+				"error": "Invalid subscription ID",
+			})
+			return
+		}
+		subscriptionID = &id
+	}
+
+	deliveries, total, err := h.webhookRepo.GetDeliveries(c, currentUser.CircleID, subscriptionID, status, limit, offset)
+	if err != nil {
+		logging.FromContext(c).Error("Error getting webhook deliveries:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting webhook deliveries",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{
+			"items":  deliveries,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+func (h *Handler) getDelivery(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+	delivery, ok := h.findDelivery(c, currentUser.CircleID)
+	if !ok {
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": delivery,
+	})
+}
+
+// redeliverDelivery queues the payload of a logged delivery again, the new delivery is returned
+func (h *Handler) redeliverDelivery(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+	original, ok := h.findDelivery(c, currentUser.CircleID)
+	if !ok {
+		return
+	}
+	delivery, err := h.producer.Redeliver(c, original)
+	if errors.Is(err, ErrSubscriptionNotFound) {
+		c.JSON(409, gin.H{
+			"error": "The subscription of this delivery was deleted",
+		})
+		return
+	}
+	if err != nil {
+		logging.FromContext(c).Error("Error redelivering webhook:", err)
+		c.JSON(500, gin.H{
+			"error": "Error redelivering webhook",
+		})
+		return
+	}
+	c.JSON(202, gin.H{
+		"res": delivery,
+	})
+}
+
+// findDelivery loads the delivery of the request, writing the error response when the circle has no such delivery
+func (h *Handler) findDelivery(c *gin.Context, circleID int) (*evModel.WebhookDelivery, bool) {
+	deliveryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid delivery ID",
+		})
+		return nil, false
+	}
+	delivery, err := h.webhookRepo.GetDelivery(c, circleID, deliveryID)
+	if err != nil {
+		logging.FromContext(c).Error("Error getting webhook delivery:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting webhook delivery",
+		})
+		return nil, false
+	}
+	if delivery == nil {
+		c.JSON(404, gin.H{
+			"error": "Delivery not found",
+		})
+		return nil, false
+	}
+	return delivery, true
+}
+
+// getOutboxHealth reports how many events wait for the dispatcher and how long the oldest has waited. the
+// counts cover every circle, so only circle admins can read them
+func (h *Handler) getOutboxHealth(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+	stats, err := h.producer.OutboxStats(c)
+	if err != nil {
+		logging.FromContext(c).Error("Error getting webhook outbox stats:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting webhook outbox stats",
+		})
+		return
+	}
+	var oldestPendingAge float64
+	if stats.OldestPendingAt != nil {
+		oldestPendingAge = time.Since(*stats.OldestPendingAt).Seconds()
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{
+			"pending":                 stats.Pending,
+			"oldestPendingAt":         stats.OldestPendingAt,
+			"oldestPendingAgeSeconds": oldestPendingAge,
+		},
+	})
+}
+
+func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
+	webhookRoutes := router.Group("api/v1/webhooks")
+	webhookRoutes.Use(auth.MiddlewareFunc())
+	{
+		webhookRoutes.GET("", h.getWebhook)
+		webhookRoutes.POST("/secret", h.rotateWebhookSecret)
+		webhookRoutes.GET("/deliveries", h.getDeliveries)
+		webhookRoutes.GET("/deliveries/:id", h.getDelivery)
+		webhookRoutes.POST("/deliveries/:id/redeliver", h.redeliverDelivery)
+		webhookRoutes.GET("/event-types", h.getEventTypes)
+		// Outbox health is authenticated and admin only, unlike the real-time health check it reveals server-wide load
+		webhookRoutes.GET("/outbox/health", h.getOutboxHealth)
+		webhookRoutes.GET("/subscriptions", h.getSubscriptions)
+		webhookRoutes.POST("/subscriptions", h.createSubscription)
+		webhookRoutes.GET("/subscriptions/:id", h.getSubscription)
+		webhookRoutes.PUT("/subscriptions/:id", h.updateSubscription)
+		webhookRoutes.DELETE("/subscriptions/:id", h.deleteSubscription)
+		webhookRoutes.POST("/subscriptions/:id/secret", h.rotateSubscriptionSecret)
+	}
+}
@@ -0,0 +1,143 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	uModel "donetick.com/core/internal/user/model"
+)
+
+// ResourceType is the kind of record a lifecycle event is about
+type ResourceType string
+
+const (
+	ResourceTask             ResourceType = "task"
+	ResourceTaskHistory      ResourceType = "task_history"
+	ResourceTimeSession      ResourceType = "time_session"
+	ResourceEscalationPolicy ResourceType = "escalation_policy"
+	ResourceReward           ResourceType = "reward"
+	ResourceRedemption       ResourceType = "redemption"
+	ResourceGoal             ResourceType = "goal"
+	ResourceCircleMember     ResourceType = "circle_member"
+	ResourceThing            ResourceType = "thing"
+)
+
+// subject returns the CloudEvents subject of an event about a record, e.g. task/12
+func subject(resource ResourceType, resourceID int) string {
+	if resourceID == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s/%d", resource, resourceID)
+}
+
+// ignoredDiffFields change on every write and would make every diff non-empty
+var ignoredDiffFields = map[string]bool{
+	"updatedAt":  true,
+	"updated_at": true,
+}
+
+// Snapshot is the JSON form of a record at one point in time. it's taken before a handler changes the
+// record so later changes to the same struct don't leak into the before side of the diff
+type Snapshot map[string]interface{}
+
+// NewSnapshot captures v, nil values give a nil snapshot
+func NewSnapshot(v interface{}) Snapshot {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(raw, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+type Actor struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"`
+}
+
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// LifecycleData is the data of every lifecycle event. before is null for created records and after for
+// deleted ones, changes lists the top level fields that differ between them
+type LifecycleData struct {
+	Resource   ResourceType  `json:"resource"`
+	ResourceID int           `json:"resource_id"`
+	Actor      *Actor        `json:"actor,omitempty"`
+	Before     Snapshot      `json:"before"`
+	After      Snapshot      `json:"after"`
+	Changes    []FieldChange `json:"changes"`
+}
+
+// Diff returns the top level fields that differ between two snapshots, sorted by field name. it's empty
+// when a record is created or deleted
+func Diff(before, after Snapshot) []FieldChange {
+	changes := []FieldChange{}
+	if before == nil || after == nil {
+		return changes
+	}
+	fields := make(map[string]bool, len(before)+len(after))
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+	for field := range fields {
+		if ignoredDiffFields[field] {
+			continue
+		}
+		if !reflect.DeepEqual(before[field], after[field]) {
+			changes = append(changes, FieldChange{Field: field, Before: before[field], After: after[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes
+}
+
+// ResourceChanged publishes a lifecycle event of a record of the circle. updates that leave every field
+// as it was are not published. callers publish in the transaction of the change, see dbtx.Run
+func (p *EventsProducer) ResourceChanged(ctx context.Context, circleID int, url *string, eventType EventType, resource ResourceType, resourceID int, actor *uModel.User, before, after Snapshot) error {
+	changes := Diff(before, after)
+	if before != nil && after != nil && len(changes) == 0 {
+		return nil
+	}
+	data := LifecycleData{
+		Resource:   resource,
+		ResourceID: resourceID,
+		Before:     before,
+		After:      after,
+		Changes:    changes,
+	}
+	if actor != nil {
+		data.Actor = &Actor{
+			ID:          actor.ID,
+			Username:    actor.Username,
+			DisplayName: actor.DisplayName,
+		}
+	}
+	return p.publishEvent(ctx, Event{
+		Type:      eventType,
+		URL:       urlOrEmpty(url),
+		CircleID:  circleID,
+		Subject:   subject(resource, resourceID),
+		Timestamp: time.Now(),
+		Data:      data,
+	})
+}
@@ -0,0 +1,61 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+type testRecord struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Priority  int       `json:"priority"`
+	Labels    []string  `json:"labels"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func TestNewSnapshot(t *testing.T) {
+	var missing *testRecord
+	if snapshot := NewSnapshot(missing); snapshot != nil {
+		t.Errorf("NewSnapshot(nil pointer) = %v, want nil", snapshot)
+	}
+	if snapshot := NewSnapshot(nil); snapshot != nil {
+		t.Errorf("NewSnapshot(nil) = %v, want nil", snapshot)
+	}
+
+	record := &testRecord{ID: 1, Name: "Dishes", Labels: []string{"kitchen"}}
+	snapshot := NewSnapshot(record)
+	record.Name = "Laundry"
+	record.Labels[0] = "bathroom"
+	if snapshot["name"] != "Dishes" {
+		t.Errorf("snapshot name = %v, want the value at the time it was taken", snapshot["name"])
+	}
+	if labels := snapshot["labels"].([]interface{}); labels[0] != "kitchen" {
+		t.Errorf("snapshot labels = %v, want [kitchen]", labels)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	before := NewSnapshot(testRecord{ID: 1, Name: "Dishes", Priority: 2, Labels: []string{"kitchen"}, UpdatedAt: time.Unix(1, 0)})
+	after := NewSnapshot(testRecord{ID: 1, Name: "Dishes", Priority: 1, Labels: []string{"kitchen", "daily"}, UpdatedAt: time.Unix(2, 0)})
+
+	changes := Diff(before, after)
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes %+v, want labels and priority", len(changes), changes)
+	}
+	if changes[0].Field != "labels" || changes[1].Field != "priority" {
+		t.Errorf("changed fields = %s, %s, want labels, priority", changes[0].Field, changes[1].Field)
+	}
+	if changes[1].Before != float64(2) || changes[1].After != float64(1) {
+		t.Errorf("priority change = %v -> %v, want 2 -> 1", changes[1].Before, changes[1].After)
+	}
+
+	if changes := Diff(before, before); len(changes) != 0 {
+		t.Errorf("Diff of equal snapshots = %+v, want none", changes)
+	}
+	if changes := Diff(nil, after); changes == nil || len(changes) != 0 {
+		t.Errorf("Diff of a created record = %+v, want an empty list", changes)
+	}
+	if changes := Diff(before, nil); changes == nil || len(changes) != 0 {
+		t.Errorf("Diff of a deleted record = %+v, want an empty list", changes)
+	}
+}
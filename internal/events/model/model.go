@@ -0,0 +1,170 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type DeliveryStatus string
+
+const (
+	// DeliveryStatusPending is a delivery waiting for its first attempt or a retry
+	DeliveryStatusPending DeliveryStatus = "pending"
+	// DeliveryStatusSucceeded is a delivery the receiver answered with a 2xx status
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	// DeliveryStatusFailed is a delivery that ran out of attempts
+	DeliveryStatusFailed DeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event sent to a circle webhook together with the outcome of its last attempt
+type WebhookDelivery struct {
+	ID             int            `json:"id" gorm:"primary_key"`
+	CircleID       int            `json:"circleId" gorm:"column:circle_id;index"`
+	EventType      string         `json:"eventType" gorm:"column:event_type"`
+	URL            string         `json:"url" gorm:"column:url"`
+	Payload        string         `json:"payload" gorm:"column:payload;type:text"` // The exact body that is signed and sent
+	Status         DeliveryStatus `json:"status" gorm:"column:status;index"`
+	Attempts       int            `json:"attempts" gorm:"column:attempts;default:0"`
+	ResponseStatus int            `json:"responseStatus,omitempty" gorm:"column:response_status"`
+	ResponseBody   string         `json:"responseBody,omitempty" gorm:"column:response_body;type:text"` // The start of the last response body
+	LatencyMs      int64          `json:"latencyMs" gorm:"column:latency_ms"`                           // Duration of the last attempt
+	LastError      *string        `json:"lastError,omitempty" gorm:"column:last_error"`
+	LastAttemptAt  *time.Time     `json:"lastAttemptAt,omitempty" gorm:"column:last_attempt_at"`
+	NextAttemptAt  *time.Time     `json:"nextAttemptAt,omitempty" gorm:"column:next_attempt_at;index"`
+	RedeliveryOf   *int           `json:"redeliveryOf,omitempty" gorm:"column:redelivery_of"`           // The delivery this one was redelivered from
+	SubscriptionID *int           `json:"subscriptionId,omitempty" gorm:"column:subscription_id;index"` // Nil for deliveries to the circle webhook URL
+	EventID        string         `json:"eventId" gorm:"column:event_id;index"`                         // The idempotency key of the outbox event, shared by its redeliveries
+	Format         WebhookFormat  `json:"format" gorm:"column:format"`
+	Headers        WebhookHeaders `json:"headers,omitempty" gorm:"column:headers;type:json"` // Sent besides the standard headers, e.g. the ce-* headers of binary CloudEvents
+	CreatedAt      time.Time      `json:"createdAt" gorm:"column:created_at;index"`
+	UpdatedAt      time.Time      `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+type OutboxStatus string
+
+const (
+	// OutboxStatusPending is an event whose deliveries weren't created yet
+	OutboxStatusPending OutboxStatus = "pending"
+	// OutboxStatusDispatched is an event whose deliveries were created
+	OutboxStatusDispatched OutboxStatus = "dispatched"
+	// OutboxStatusFailed is an event the dispatcher gave up on after too many failed attempts, it no
+	// longer holds back the events published after it
+	OutboxStatusFailed OutboxStatus = "failed"
+)
+
+// OutboxEvent is an event saved in the transaction of the change that caused it. the dispatcher turns it
+// into deliveries, so events survive restarts and are never sent for changes that were rolled back
+type OutboxEvent struct {
+	ID             int          `json:"id" gorm:"primary_key"`
+	IdempotencyKey string       `json:"idempotencyKey" gorm:"column:idempotency_key;uniqueIndex"` // Sent with every delivery so receivers can drop duplicates
+	CircleID       int          `json:"circleId" gorm:"column:circle_id;index"`
+	EventType      string       `json:"eventType" gorm:"column:event_type"`
+	URL            string       `json:"url" gorm:"column:url"`         // The circle webhook URL when the event was published
+	Subject        string       `json:"subject" gorm:"column:subject"` // The record the event is about, e.g. task/12
+	Payload        string       `json:"payload" gorm:"column:payload;type:text"`
+	Status         OutboxStatus `json:"status" gorm:"column:status;index"`
+	Attempts       int          `json:"attempts" gorm:"column:attempts;default:0"` // Failed dispatches
+	LastError      *string      `json:"lastError,omitempty" gorm:"column:last_error"`
+	CreatedAt      time.Time    `json:"createdAt" gorm:"column:created_at;index"`
+	DispatchedAt   *time.Time   `json:"dispatchedAt,omitempty" gorm:"column:dispatched_at"`
+}
+
+// OutboxStats describes the events waiting for the dispatcher
+type OutboxStats struct {
+	Pending         int64      `json:"pending"`
+	OldestPendingAt *time.Time `json:"oldestPendingAt"`
+	Failed          int64      `json:"failed"`
+}
+
+// WebhookEventTypeAll subscribes to every event type, including ones added later
+const WebhookEventTypeAll = "*"
+
+// MaxWebhookSubscriptions limits the number of webhook subscriptions of a circle
+const MaxWebhookSubscriptions = 10
+
+type WebhookFormat string
+
+const (
+	// WebhookFormatDonetick sends the event as is, it's the format of the circle webhook URL
+	WebhookFormatDonetick WebhookFormat = "donetick"
+	// WebhookFormatCloudEventsStructured sends a CloudEvents 1.0 JSON envelope with the event as its data
+	WebhookFormatCloudEventsStructured WebhookFormat = "cloudevents-structured"
+	// WebhookFormatCloudEventsBinary sends the data of the event as the body and its CloudEvents 1.0
+	// attributes as ce-* headers
+	WebhookFormatCloudEventsBinary WebhookFormat = "cloudevents-binary"
+)
+
+// WebhookSubscription is an endpoint that receives the circle events of the types it subscribed to
+type WebhookSubscription struct {
+	ID          int               `json:"id" gorm:"primary_key"`
+	CircleID    int               `json:"circleId" gorm:"column:circle_id;index"`
+	URL         string            `json:"url" gorm:"column:url"`
+	Description string            `json:"description" gorm:"column:description"`
+	Secret      string            `json:"-" gorm:"column:secret"` // Signs the deliveries to this endpoint, only returned on create and rotate
+	Enabled     bool              `json:"enabled" gorm:"column:enabled;default:true"`
+	EventTypes  WebhookEventTypes `json:"eventTypes" gorm:"column:event_types;type:json"`
+	Format      WebhookFormat     `json:"format" gorm:"column:format;default:donetick"` // How events are encoded in the deliveries
+	CreatedBy   int               `json:"createdBy" gorm:"column:created_by"`
+	CreatedAt   time.Time         `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt   time.Time         `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+type WebhookEventTypes []string
+
+// Matches reports whether an event of the given type should be sent to the subscription
+func (t WebhookEventTypes) Matches(eventType string) bool {
+	for _, subscribed := range t {
+		if subscribed == WebhookEventTypeAll || subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (t WebhookEventTypes) Value() (driver.Value, error) {
+	if t == nil {
+		return json.Marshal(WebhookEventTypes{})
+	}
+	return json.Marshal(t)
+}
+
+func (t *WebhookEventTypes) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, t)
+	case string:
+		return json.Unmarshal([]byte(v), t)
+	default:
+		return errors.New("type assertion to []byte or string failed")
+	}
+}
+
+type WebhookHeaders map[string]string
+
+func (h WebhookHeaders) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return json.Marshal(h)
+}
+
+func (h *WebhookHeaders) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	default:
+		return errors.New("type assertion to []byte or string failed")
+	}
+}
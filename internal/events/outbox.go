@@ -0,0 +1,128 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	evModel "donetick.com/core/internal/events/model"
+)
+
+const (
+	defaultOutboxPollInterval = 5 * time.Second
+	defaultOutboxBatchSize    = 100
+	defaultOutboxMaxAttempts  = 10
+
+	// deliveryLease keeps the retry job away from the deliveries of a dispatched event until the dispatcher
+	// made their first attempt, when the dispatcher stops before that the retry job sends them
+	deliveryLease = 5 * time.Minute
+
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// newEventID returns the idempotency key of a new event
+func newEventID() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return "evt_" + hex.EncodeToString(key), nil
+}
+
+// wakeDispatcher makes the dispatcher look at the outbox without waiting for its next poll
+func (p *EventsProducer) wakeDispatcher() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+		// already woken up
+	}
+}
+
+// dispatchOutbox turns pending outbox events into deliveries. it's woken up by published events and polls
+// for the ones left over by a restart or published by another instance
+func (p *EventsProducer) dispatchOutbox(c context.Context) {
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Done():
+			return
+		case <-ticker.C:
+		case <-p.wake:
+		}
+		// keep going while there may be more events than fit in a batch
+		for p.dispatchBatch(c) == p.batchSize {
+		}
+	}
+}
+
+// dispatchBatch dispatches the oldest pending outbox events and makes the first attempt of their
+// deliveries, it returns how many events were dispatched
+func (p *EventsProducer) dispatchBatch(c context.Context) int {
+	outboxEvents, err := p.webhookRepo.GetPendingOutboxEvents(c, p.batchSize)
+	if err != nil {
+		p.logger.Errorw("Failed to get pending webhook events", "error", err)
+		return 0
+	}
+	dispatched := 0
+	for _, outboxEvent := range outboxEvents {
+		deliveries, err := p.dispatch(c, outboxEvent)
+		if err != nil {
+			failed := outboxEvent.Attempts+1 >= p.maxAttempts
+			p.logger.Errorw("Failed to dispatch webhook event", "event_id", outboxEvent.IdempotencyKey, "circle_id", outboxEvent.CircleID, "attempt", outboxEvent.Attempts+1, "gave_up", failed, "error", err)
+			if err := p.webhookRepo.RecordOutboxFailure(c, outboxEvent.ID, err.Error(), failed); err != nil {
+				p.logger.Errorw("Failed to save webhook event", "event_id", outboxEvent.IdempotencyKey, "error", err)
+			}
+			continue
+		}
+		dispatched++
+		for _, delivery := range deliveries {
+			p.deliver(c, delivery)
+		}
+	}
+	return dispatched
+}
+
+// dispatch marks the outbox event dispatched and creates its deliveries in one transaction, so an event
+// gets its deliveries exactly once even when several dispatchers look at the same outbox. an event that
+// was dispatched in the meantime gets no deliveries
+func (p *EventsProducer) dispatch(c context.Context, outboxEvent *evModel.OutboxEvent) ([]*evModel.WebhookDelivery, error) {
+	var deliveries []*evModel.WebhookDelivery
+	err := p.webhookRepo.Transaction(c, func(c context.Context) error {
+		now := time.Now().UTC()
+		claimed, err := p.webhookRepo.MarkOutboxEventDispatched(c, outboxEvent.ID, now)
+		if err != nil || !claimed {
+			return err
+		}
+		var subscriptions []*evModel.WebhookSubscription
+		if outboxEvent.CircleID != 0 {
+			subscriptions, err = p.webhookRepo.GetEnabledSubscriptions(c, outboxEvent.CircleID)
+			if err != nil {
+				return err
+			}
+		}
+		eventDeliveries, err := newDeliveries(outboxEvent, subscriptions, p.eventSource, p.dataSchemaBase)
+		if err != nil {
+			return err
+		}
+		leasedUntil := now.Add(deliveryLease)
+		for _, delivery := range eventDeliveries {
+			delivery.NextAttemptAt = &leasedUntil
+			if err := p.webhookRepo.CreateDelivery(c, delivery); err != nil {
+				return err
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// OutboxStats returns the number of events waiting for the dispatcher and when the oldest was published
+func (p *EventsProducer) OutboxStats(c context.Context) (*evModel.OutboxStats, error) {
+	return p.webhookRepo.GetOutboxStats(c)
+}
@@ -0,0 +1,178 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"donetick.com/core/config"
+	evModel "donetick.com/core/internal/events/model"
+	evRepo "donetick.com/core/internal/events/repo"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestProducer(t *testing.T) (*EventsProducer, *evRepo.WebhookRepository) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "events.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&evModel.OutboxEvent{}, &evModel.WebhookDelivery{}, &evModel.WebhookSubscription{}); err != nil {
+		t.Fatal(err)
+	}
+	repo := evRepo.NewWebhookRepository(db)
+	return NewEventsProducer(&config.Config{}, repo, nil), repo
+}
+
+func TestOutboxDispatch(t *testing.T) {
+	ctx := context.Background()
+	producer, repo := newTestProducer(t)
+
+	type received struct {
+		idempotencyKey string
+		payload        []byte
+	}
+	requests := make(chan received, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		requests <- received{idempotencyKey: r.Header.Get(HEAD_IDEMPOTENCY_KEY), payload: payload}
+	}))
+	defer receiver.Close()
+
+	if err := repo.CreateSubscription(ctx, &evModel.WebhookSubscription{
+		CircleID:   7,
+		URL:        receiver.URL,
+		Secret:     "whsec_test",
+		Enabled:    true,
+		EventTypes: evModel.WebhookEventTypes{evModel.WebhookEventTypeAll},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a rolled back change leaves no event behind
+	errRollback := errors.New("rollback")
+	err := repo.Transaction(ctx, func(c context.Context) error {
+		if err := producer.ThingsUpdated(c, 7, 1, nil, map[string]interface{}{"id": 1}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("Transaction() error = %v, want %v", err, errRollback)
+	}
+	if stats, err := producer.OutboxStats(ctx); err != nil || stats.Pending != 0 {
+		t.Fatalf("OutboxStats() = %+v, %v after rollback, want no pending events", stats, err)
+	}
+	if len(producer.wake) != 0 {
+		t.Errorf("dispatcher woken up for a rolled back event")
+	}
+
+	err = repo.Transaction(ctx, func(c context.Context) error {
+		return producer.ThingsUpdated(c, 7, 1, nil, map[string]interface{}{"id": 1})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := producer.OutboxStats(ctx)
+	if err != nil || stats.Pending != 1 || stats.OldestPendingAt == nil {
+		t.Fatalf("OutboxStats() = %+v, %v after commit, want one pending event", stats, err)
+	}
+	if len(producer.wake) != 1 {
+		t.Errorf("dispatcher not woken up after commit")
+	}
+
+	if dispatched := producer.dispatchBatch(ctx); dispatched != 1 {
+		t.Fatalf("dispatchBatch() = %d, want 1", dispatched)
+	}
+	request := <-requests
+	var event Event
+	if err := json.Unmarshal(request.payload, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.ID == "" || request.idempotencyKey != event.ID {
+		t.Errorf("idempotency key = %q, want the event id %q", request.idempotencyKey, event.ID)
+	}
+
+	deliveries, _, err := repo.GetDeliveries(ctx, 7, nil, "", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != evModel.DeliveryStatusSucceeded || deliveries[0].EventID != event.ID {
+		t.Fatalf("deliveries = %+v, want one succeeded delivery of the event", deliveries)
+	}
+
+	// dispatched events are not dispatched again
+	if dispatched := producer.dispatchBatch(ctx); dispatched != 0 {
+		t.Errorf("dispatchBatch() = %d after dispatch, want 0", dispatched)
+	}
+	if stats, err := producer.OutboxStats(ctx); err != nil || stats.Pending != 0 {
+		t.Errorf("OutboxStats() = %+v, %v after dispatch, want no pending events", stats, err)
+	}
+}
+
+func TestOutboxFailedEventDoesNotBlockNewerEvents(t *testing.T) {
+	ctx := context.Background()
+	producer, repo := newTestProducer(t)
+	producer.batchSize = 1
+	producer.maxAttempts = 3
+
+	requests := make(chan struct{}, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+	}))
+	defer receiver.Close()
+
+	if err := repo.CreateSubscription(ctx, &evModel.WebhookSubscription{
+		CircleID:   7,
+		URL:        receiver.URL,
+		Secret:     "whsec_test",
+		Enabled:    true,
+		EventTypes: evModel.WebhookEventTypes{evModel.WebhookEventTypeAll},
+		Format:     evModel.WebhookFormatCloudEventsStructured,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a payload that can't be turned into a CloudEvent makes every dispatch of the event fail
+	if err := repo.CreateOutboxEvent(ctx, &evModel.OutboxEvent{
+		IdempotencyKey: "evt_stuck",
+		CircleID:       7,
+		EventType:      string(EventTypeThingChanged),
+		Payload:        "not json",
+		Status:         evModel.OutboxStatusPending,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.ThingsUpdated(ctx, 7, 1, nil, map[string]interface{}{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	for attempt := 1; attempt <= producer.maxAttempts; attempt++ {
+		if dispatched := producer.dispatchBatch(ctx); dispatched != 0 {
+			t.Fatalf("dispatchBatch() = %d on attempt %d, want the stuck event to fail", dispatched, attempt)
+		}
+	}
+	stats, err := producer.OutboxStats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Failed != 1 || stats.Pending != 1 {
+		t.Fatalf("OutboxStats() = %+v after %d attempts, want one failed and one pending event", stats, producer.maxAttempts)
+	}
+
+	if dispatched := producer.dispatchBatch(ctx); dispatched != 1 {
+		t.Fatalf("dispatchBatch() = %d after the stuck event failed, want the newer event dispatched", dispatched)
+	}
+	<-requests
+	if stats, err := producer.OutboxStats(ctx); err != nil || stats.Pending != 0 || stats.Failed != 1 {
+		t.Errorf("OutboxStats() = %+v, %v after dispatch, want no pending and one failed event", stats, err)
+	}
+}
@@ -1,15 +1,20 @@
 package events
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"donetick.com/core/config"
 	chModel "donetick.com/core/internal/chore/model"
+	cRepo "donetick.com/core/internal/circle/repo"
+	"donetick.com/core/internal/database/dbtx"
+	evModel "donetick.com/core/internal/events/model"
+	evRepo "donetick.com/core/internal/events/repo"
 	uModel "donetick.com/core/internal/user/model"
 	"donetick.com/core/logging"
 	"go.uber.org/zap"
@@ -19,25 +24,69 @@ const (
 	METHOD_POST       = "POST"
 	HEAD_CONTENT_TYPE = "Content-Type"
 	CONTENT_TYPE_JSON = "application/json"
+	HEAD_SIGNATURE    = "X-Donetick-Signature"
+	HEAD_EVENT        = "X-Donetick-Event"
+	HEAD_DELIVERY     = "X-Donetick-Delivery"
+	// HEAD_IDEMPOTENCY_KEY is the same for every attempt and redelivery of an event to an endpoint, receivers
+	// drop deliveries whose key they have seen
+	HEAD_IDEMPOTENCY_KEY = "X-Donetick-Idempotency-Key"
 )
 
+// EventSchemaVersion is sent as the version of every payload. it's bumped when a payload changes in a way
+// that breaks receivers, new fields and new event types keep the version
+const EventSchemaVersion = 1
+
 type EventType string
 
 const (
-	EventTypeUnknown EventType = ""
-	// EventTypeTaskCreated    EventType = "task.created"
-	EventTypeTaskReminder EventType = "task.reminder"
-	// EventTypeTaskUpdated    EventType = "task.updated"
+	EventTypeUnknown          EventType = ""
+	EventTypeTaskCreated      EventType = "task.created"
+	EventTypeTaskReminder     EventType = "task.reminder"
+	EventTypeTaskUpdated      EventType = "task.updated"
 	EventTypeTaskCompleted    EventType = "task.completed"
 	EventTypeSubTaskCompleted EventType = "subtask.completed"
-	// EventTypeTaskReassigned EventType = "task.reassigned"
-	EventTypeTaskSkipped  EventType = "task.skipped"
-	EventTypeThingChanged EventType = "thing.changed"
+	EventTypeTaskReassigned   EventType = "task.reassigned"
+	EventTypeTaskSkipped      EventType = "task.skipped"
+	EventTypeTaskOverdue      EventType = "task.overdue"
+	EventTypeThingChanged     EventType = "thing.changed"
+
+	// EventTypeTaskEscalated is sent for every escalation step applied to an overdue task
+	EventTypeTaskEscalated EventType = "task.escalated"
+
+	// lifecycle events, their data is a LifecycleData
+	EventTypeTaskDeleted             EventType = "task.deleted"
+	EventTypeTaskArchived            EventType = "task.archived"
+	EventTypeTaskUnarchived          EventType = "task.unarchived"
+	EventTypeTaskDueDateChanged      EventType = "task.due_date_changed"
+	EventTypeTaskTimerStarted        EventType = "task.timer_started"
+	EventTypeTaskTimerPaused         EventType = "task.timer_paused"
+	EventTypeTaskTimerReset          EventType = "task.timer_reset"
+	EventTypeTaskTimeSessionUpdated  EventType = "task.time_session_updated"
+	EventTypeTaskTimeSessionDeleted  EventType = "task.time_session_deleted"
+	EventTypeTaskHistoryUpdated      EventType = "task.history_updated"
+	EventTypeTaskHistoryDeleted      EventType = "task.history_deleted"
+	EventTypeEscalationPolicyUpdated EventType = "escalation_policy.updated"
+	EventTypeEscalationPolicyDeleted EventType = "escalation_policy.deleted"
+	EventTypeRewardCreated           EventType = "reward.created"
+	EventTypeRewardRedeemed          EventType = "reward.redeemed"
+	EventTypeRewardRedemptionUpdated EventType = "reward.redemption_updated"
+	EventTypeGoalCreated             EventType = "goal.created"
+	EventTypeGoalProgressUpdated     EventType = "goal.progress_updated"
+	EventTypeCircleMemberRequested   EventType = "circle.member_requested"
+	EventTypeCircleMemberJoined      EventType = "circle.member_joined"
+	EventTypeCircleMemberLeft        EventType = "circle.member_left"
+	EventTypeCircleMemberRemoved     EventType = "circle.member_removed"
+	EventTypeCircleMemberRoleChanged EventType = "circle.member_role_changed"
+	EventTypeCirclePointsRedeemed    EventType = "circle.points_redeemed"
 )
 
 type Event struct {
+	ID        string      `json:"id"` // The idempotency key of the event
 	Type      EventType   `json:"type"`
+	Version   int         `json:"version"`
 	URL       string      `json:"-"`
+	CircleID  int         `json:"-"`
+	Subject   string      `json:"-"` // The record the event is about, the CloudEvents subject
 	Timestamp time.Time   `json:"timestamp"`
 	Data      interface{} `json:"data"`
 }
@@ -49,144 +98,253 @@ type ChoreData struct {
 	Note        string         `json:"note"`
 }
 
+type EscalationData struct {
+	Chore  *chModel.Chore           `json:"chore"`
+	Action chModel.EscalationAction `json:"action"`
+	Note   string                   `json:"note"`
+}
+
 type EventsProducer struct {
-	client *http.Client
-	queue  chan Event
-	logger *zap.SugaredLogger
+	// ctx lives from Start to Stop, deliveries made in the background of a request use it
+	ctx          context.Context
+	cancel       context.CancelFunc
+	client       *http.Client
+	wake         chan struct{}
+	pollInterval time.Duration
+	batchSize    int
+	maxAttempts  int
+	eventSource  string
+	// dataSchemaBase is the base of the dataschema of CloudEvents deliveries
+	dataSchemaBase string
+	logger         *zap.SugaredLogger
+	webhookRepo    *evRepo.WebhookRepository
+	circleRepo     *cRepo.CircleRepository
 }
 
 func (p *EventsProducer) Start(ctx context.Context) {
 
 	p.logger = logging.FromContext(ctx)
+	p.ctx, p.cancel = context.WithCancel(ctx)
 
-	go func() {
-		for event := range p.queue {
-			p.processEvent(event)
-		}
-	}()
+	go p.dispatchOutbox(p.ctx)
+	go p.retryDeliveries(p.ctx)
 }
 
-func NewEventsProducer(cfg *config.Config) *EventsProducer {
+// Stop ends the dispatcher, the retry job and the deliveries in flight, the ones that didn't finish are
+// retried after the next start
+func (p *EventsProducer) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+func NewEventsProducer(cfg *config.Config, wr *evRepo.WebhookRepository, cr *cRepo.CircleRepository) *EventsProducer {
+	pollInterval := cfg.WebhookConfig.OutboxPollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultOutboxPollInterval
+	}
+	batchSize := cfg.WebhookConfig.OutboxBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultOutboxBatchSize
+	}
+	dataSchemaBase := cfg.WebhookConfig.DataSchemaBase
+	if dataSchemaBase == "" {
+		dataSchemaBase = CloudEventsSchemaBase
+	}
+	maxAttempts := cfg.WebhookConfig.OutboxMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultOutboxMaxAttempts
+	}
 	return &EventsProducer{
 		client: &http.Client{
 			Timeout: cfg.WebhookConfig.Timeout,
 		},
-		queue: make(chan Event, cfg.WebhookConfig.QueueSize),
+		ctx:            context.Background(),
+		wake:           make(chan struct{}, 1),
+		pollInterval:   pollInterval,
+		batchSize:      batchSize,
+		maxAttempts:    maxAttempts,
+		eventSource:    eventSource(cfg),
+		dataSchemaBase: dataSchemaBase,
+		webhookRepo:    wr,
+		circleRepo:     cr,
+		logger:         logging.DefaultLogger(),
 	}
 }
 
-func (p *EventsProducer) publishEvent(event Event) {
-	select {
-	case p.queue <- event:
-		// Successfully added to queue
-	default:
-		log.Println("Webhook queue is full, dropping event")
+// eventSource returns the absolute URI the CloudEvents sources are under, the configured event source or
+// the public host of the server, else the hostname of the machine on the port of the server
+func eventSource(cfg *config.Config) string {
+	if cfg.WebhookConfig.EventSource != "" {
+		return cfg.WebhookConfig.EventSource
 	}
+	host := cfg.Server.PublicHost
+	if host == "" {
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			hostname = "localhost"
+		}
+		host = hostname
+		if cfg.Server.Port != 0 {
+			host = fmt.Sprintf("%s:%d", hostname, cfg.Server.Port)
+		}
+		return "http://" + host
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	return host
 }
 
-func (p *EventsProducer) processEvent(event Event) {
-	p.logger.Debugw("Sending webhook event", "type", event.Type, "url", event.URL)
-
-	eventJSON, err := json.Marshal(event)
-	if err != nil {
-		p.logger.Errorw("Failed to marshal webhook event", "error", err)
-		return
+// publishEvent saves the event to the outbox, in the transaction of the context when it has one so the
+// event is only sent when the change that caused it is committed. circles without a webhook URL or an
+// enabled subscription don't get events
+func (p *EventsProducer) publishEvent(c context.Context, event Event) error {
+	event.Version = EventSchemaVersion
+	if event.URL == "" {
+		if event.CircleID == 0 {
+			return nil
+		}
+		subscriptions, err := p.webhookRepo.GetEnabledSubscriptions(c, event.CircleID)
+		if err != nil {
+			p.logger.Errorw("Failed to get webhook subscriptions", "circle_id", event.CircleID, "error", err)
+			return err
+		}
+		if len(subscriptions) == 0 {
+			p.logger.Debugw("No subscribers for circle, skipping webhook", "circle_id", event.CircleID, "type", event.Type)
+			return nil
+		}
 	}
 
-	// Pring the event and the url:
-	p.logger.Debug("Sending event to webhook", "url", event.URL, "event", event)
-	p.logger.Debug("Event: ", event)
-
-	req, err := http.NewRequest(METHOD_POST, event.URL, bytes.NewBuffer(eventJSON))
+	id, err := newEventID()
 	if err != nil {
-		p.logger.Errorw("Failed to create webhook request", "error", err)
-		return
+		p.logger.Errorw("Failed to generate webhook event ID", "error", err)
+		return err
 	}
-	req.Header.Set(HEAD_CONTENT_TYPE, CONTENT_TYPE_JSON)
-
-	resp, err := p.client.Do(req)
+	event.ID = id
+	payload, err := json.Marshal(event)
 	if err != nil {
-		p.logger.Debugw("Failed to send webhook event", "error", err)
-		return
+		p.logger.Errorw("Failed to marshal webhook event", "type", event.Type, "error", err)
+		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode >= 400 {
-		p.logger.Errorw("Webhook request failed", "status", resp.StatusCode)
-		return
+	outboxEvent := &evModel.OutboxEvent{
+		IdempotencyKey: event.ID,
+		CircleID:       event.CircleID,
+		EventType:      string(event.Type),
+		URL:            event.URL,
+		Subject:        event.Subject,
+		Payload:        string(payload),
+		Status:         evModel.OutboxStatusPending,
 	}
-}
-
-func (p *EventsProducer) ChoreCompleted(ctx context.Context, webhookURL *string, chore *chModel.Chore, performer *uModel.User) {
-	if webhookURL == nil {
-		p.logger.Debug("No subscribers for circle, skipping webhook")
-		return
+	if err := p.webhookRepo.CreateOutboxEvent(c, outboxEvent); err != nil {
+		p.logger.Errorw("Failed to save webhook event", "circle_id", event.CircleID, "type", event.Type, "error", err)
+		return err
 	}
+	dbtx.AfterCommit(c, p.wakeDispatcher)
+	return nil
+}
 
+// ChoreCompleted publishes task.completed, the error is only useful to callers that publish in a transaction
+func (p *EventsProducer) ChoreCompleted(ctx context.Context, webhookURL *string, chore *chModel.Chore, performer *uModel.User) error {
 	event := Event{
 		Type:      EventTypeTaskCompleted,
-		URL:       *webhookURL,
+		URL:       urlOrEmpty(webhookURL),
+		CircleID:  chore.CircleID,
+		Subject:   subject(ResourceTask, chore.ID),
 		Timestamp: time.Now(),
 		Data: ChoreData{Chore: chore,
 			Username:    performer.Username,
 			DisplayName: performer.DisplayName,
 		},
 	}
-	p.publishEvent(event)
+	return p.publishEvent(ctx, event)
 }
 
-func (p *EventsProducer) ChoreSkipped(ctx context.Context, webhookURL *string, chore *chModel.Chore, performer *uModel.User) {
-	if webhookURL == nil {
-		p.logger.Debug("No Webhook URL for circle, skipping webhook")
-		return
-	}
-
+// ChoreSkipped publishes task.skipped, the error is only useful to callers that publish in a transaction
+func (p *EventsProducer) ChoreSkipped(ctx context.Context, webhookURL *string, chore *chModel.Chore, performer *uModel.User) error {
 	event := Event{
 		Type:      EventTypeTaskSkipped,
-		URL:       *webhookURL,
+		URL:       urlOrEmpty(webhookURL),
+		CircleID:  chore.CircleID,
+		Subject:   subject(ResourceTask, chore.ID),
 		Timestamp: time.Now(),
 		Data: ChoreData{Chore: chore,
 			Username:    performer.Username,
 			DisplayName: performer.DisplayName,
 		},
 	}
-	p.publishEvent(event)
+	return p.publishEvent(ctx, event)
 }
 
-func (p *EventsProducer) NotificationEvent(ctx context.Context, url string, event interface{}) {
-	// print the event and the url :
+func (p *EventsProducer) NotificationEvent(ctx context.Context, circleID int, choreID int, url *string, event interface{}) error {
 	p.logger.Debug("Sending notification event")
 
-	p.publishEvent(Event{
-		URL:       url,
+	return p.publishEvent(ctx, Event{
+		URL:       urlOrEmpty(url),
+		CircleID:  circleID,
+		Subject:   subject(ResourceTask, choreID),
 		Type:      EventTypeTaskReminder,
 		Timestamp: time.Now(),
 		Data:      event,
 	})
 }
 
-func (p *EventsProducer) ThingsUpdated(ctx context.Context, url *string, data interface{}) {
-	if url == nil {
-		p.logger.Debug("No subscribers for circle, skipping webhook")
-		return
-	}
-	p.publishEvent(Event{
-		URL:       *url,
+func (p *EventsProducer) ChoreOverdue(ctx context.Context, circleID int, choreID int, url *string, event interface{}) error {
+	p.logger.Debug("Sending overdue event")
+
+	return p.publishEvent(ctx, Event{
+		URL:       urlOrEmpty(url),
+		CircleID:  circleID,
+		Subject:   subject(ResourceTask, choreID),
+		Type:      EventTypeTaskOverdue,
+		Timestamp: time.Now(),
+		Data:      event,
+	})
+}
+
+// ThingsUpdated publishes thing.changed, the error is only useful to callers that publish in a transaction
+func (p *EventsProducer) ThingsUpdated(ctx context.Context, circleID int, thingID int, url *string, data interface{}) error {
+	return p.publishEvent(ctx, Event{
+		URL:       urlOrEmpty(url),
+		CircleID:  circleID,
+		Subject:   subject(ResourceThing, thingID),
 		Type:      EventTypeThingChanged,
 		Timestamp: time.Now(),
 		Data:      data,
 	})
 }
 
-func (p *EventsProducer) SubtaskUpdated(ctx context.Context, url *string, data interface{}) {
-	if url == nil {
-		p.logger.Debug("No subscribers for circle, skipping webhook")
-		return
-	}
-	p.publishEvent(Event{
-		URL:       *url,
+func (p *EventsProducer) SubtaskUpdated(ctx context.Context, circleID int, choreID int, url *string, data interface{}) error {
+	return p.publishEvent(ctx, Event{
+		URL:       urlOrEmpty(url),
+		CircleID:  circleID,
+		Subject:   subject(ResourceTask, choreID),
 		Type:      EventTypeSubTaskCompleted,
 		Timestamp: time.Now(),
 		Data:      data,
 	})
 }
+
+func (p *EventsProducer) ChoreEscalated(ctx context.Context, url *string, chore *chModel.Chore, action chModel.EscalationAction, note string) error {
+	return p.publishEvent(ctx, Event{
+		URL:       urlOrEmpty(url),
+		CircleID:  chore.CircleID,
+		Subject:   subject(ResourceTask, chore.ID),
+		Type:      EventTypeTaskEscalated,
+		Timestamp: time.Now(),
+		Data: EscalationData{
+			Chore:  chore,
+			Action: action,
+			Note:   note,
+		},
+	})
+}
+
+// urlOrEmpty returns the circle webhook URL, empty when the circle has none and only its subscriptions
+// receive the event
+func urlOrEmpty(url *string) string {
+	if url == nil {
+		return ""
+	}
+	return *url
+}
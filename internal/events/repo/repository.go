@@ -0,0 +1,222 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"donetick.com/core/internal/database/dbtx"
+	evModel "donetick.com/core/internal/events/model"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db}
+}
+
+// Transaction calls fn in a transaction carried by its context, see dbtx.Run
+func (r *WebhookRepository) Transaction(c context.Context, fn func(c context.Context) error) error {
+	return dbtx.Run(c, r.db, fn)
+}
+
+func (r *WebhookRepository) CreateDelivery(c context.Context, delivery *evModel.WebhookDelivery) error {
+	return dbtx.DB(c, r.db).Create(delivery).Error
+}
+
+func (r *WebhookRepository) UpdateDelivery(c context.Context, delivery *evModel.WebhookDelivery) error {
+	return r.db.WithContext(c).Save(delivery).Error
+}
+
+// GetDueDeliveries returns the pending deliveries whose retry is due, oldest first
+func (r *WebhookRepository) GetDueDeliveries(c context.Context, now time.Time, limit int) ([]*evModel.WebhookDelivery, error) {
+	var deliveries []*evModel.WebhookDelivery
+	if err := r.db.WithContext(c).
+		Where("status = ? AND next_attempt_at IS NOT NULL AND next_attempt_at <= ?", evModel.DeliveryStatusPending, now).
+		Order("next_attempt_at asc").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// GetDeliveries returns a page of the circle's deliveries, newest first. an empty status returns all of them
+// and a nil subscriptionID the deliveries of every endpoint
+func (r *WebhookRepository) GetDeliveries(c context.Context, circleID int, subscriptionID *int, status evModel.DeliveryStatus, limit int, offset int) ([]*evModel.WebhookDelivery, int64, error) {
+	query := r.db.WithContext(c).Model(&evModel.WebhookDelivery{}).Where("circle_id = ?", circleID)
+	if subscriptionID != nil {
+		query = query.Where("subscription_id = ?", *subscriptionID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []*evModel.WebhookDelivery
+	if err := query.Order("created_at desc, id desc").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// GetDelivery returns the circle's delivery, nil when there is none
+func (r *WebhookRepository) GetDelivery(c context.Context, circleID int, deliveryID int) (*evModel.WebhookDelivery, error) {
+	var deliveries []*evModel.WebhookDelivery
+	if err := r.db.WithContext(c).Where("id = ? AND circle_id = ?", deliveryID, circleID).Limit(1).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+	return deliveries[0], nil
+}
+
+// DeleteDeliveriesBefore removes the deliveries created before the given time, pending ones included
+func (r *WebhookRepository) DeleteDeliveriesBefore(c context.Context, before time.Time) error {
+	return r.db.WithContext(c).Where("created_at < ?", before).Delete(&evModel.WebhookDelivery{}).Error
+}
+
+func (r *WebhookRepository) GetSubscriptions(c context.Context, circleID int) ([]*evModel.WebhookSubscription, error) {
+	var subscriptions []*evModel.WebhookSubscription
+	if err := r.db.WithContext(c).Where("circle_id = ?", circleID).Order("id asc").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// GetEnabledSubscriptions returns the circle's subscriptions that receive events
+func (r *WebhookRepository) GetEnabledSubscriptions(c context.Context, circleID int) ([]*evModel.WebhookSubscription, error) {
+	var subscriptions []*evModel.WebhookSubscription
+	if err := dbtx.DB(c, r.db).Where("circle_id = ? AND enabled = ?", circleID, true).Order("id asc").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// GetSubscription returns the circle's subscription, nil when there is none
+func (r *WebhookRepository) GetSubscription(c context.Context, circleID int, subscriptionID int) (*evModel.WebhookSubscription, error) {
+	var subscriptions []*evModel.WebhookSubscription
+	if err := r.db.WithContext(c).Where("id = ? AND circle_id = ?", subscriptionID, circleID).Limit(1).Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	if len(subscriptions) == 0 {
+		return nil, nil
+	}
+	return subscriptions[0], nil
+}
+
+func (r *WebhookRepository) CountSubscriptions(c context.Context, circleID int) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(c).Model(&evModel.WebhookSubscription{}).Where("circle_id = ?", circleID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *WebhookRepository) CreateSubscription(c context.Context, subscription *evModel.WebhookSubscription) error {
+	return r.db.WithContext(c).Create(subscription).Error
+}
+
+// UpdateSubscription saves every field of the subscription, including a disabled flag
+func (r *WebhookRepository) UpdateSubscription(c context.Context, subscription *evModel.WebhookSubscription) error {
+	return r.db.WithContext(c).Select("*").Omit("created_at").Updates(subscription).Error
+}
+
+// DeleteSubscription removes the subscription and gives up on its pending deliveries, the delivery log is kept
+func (r *WebhookRepository) DeleteSubscription(c context.Context, circleID int, subscriptionID int) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND circle_id = ?", subscriptionID, circleID).Delete(&evModel.WebhookSubscription{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		if deleted == 0 {
+			return nil
+		}
+		return tx.Model(&evModel.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", subscriptionID, evModel.DeliveryStatusPending).
+			Updates(map[string]interface{}{
+				"status":          evModel.DeliveryStatusFailed,
+				"next_attempt_at": nil,
+				"last_error":      "subscription deleted",
+			}).Error
+	})
+	return deleted, err
+}
+
+// CreateOutboxEvent saves the event in the transaction of the context when it has one
+func (r *WebhookRepository) CreateOutboxEvent(c context.Context, event *evModel.OutboxEvent) error {
+	return dbtx.DB(c, r.db).Create(event).Error
+}
+
+// GetPendingOutboxEvents returns the events waiting for the dispatcher, oldest first. failed events are
+// left out so they don't take the place of newer ones in a batch
+func (r *WebhookRepository) GetPendingOutboxEvents(c context.Context, limit int) ([]*evModel.OutboxEvent, error) {
+	var events []*evModel.OutboxEvent
+	if err := r.db.WithContext(c).Where("status = ?", evModel.OutboxStatusPending).Order("id asc").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// MarkOutboxEventDispatched marks a pending event dispatched, false when it was dispatched already
+func (r *WebhookRepository) MarkOutboxEventDispatched(c context.Context, eventID int, dispatchedAt time.Time) (bool, error) {
+	result := dbtx.DB(c, r.db).Model(&evModel.OutboxEvent{}).
+		Where("id = ? AND status = ?", eventID, evModel.OutboxStatusPending).
+		Updates(map[string]interface{}{
+			"status":        evModel.OutboxStatusDispatched,
+			"dispatched_at": dispatchedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RecordOutboxFailure saves why a dispatch of the event failed. the event stays pending for the next
+// dispatch unless failed is set, then it's marked failed and no longer dispatched
+func (r *WebhookRepository) RecordOutboxFailure(c context.Context, eventID int, lastError string, failed bool) error {
+	updates := map[string]interface{}{
+		"attempts":   gorm.Expr("attempts + 1"),
+		"last_error": lastError,
+	}
+	if failed {
+		updates["status"] = evModel.OutboxStatusFailed
+	}
+	return r.db.WithContext(c).Model(&evModel.OutboxEvent{}).Where("id = ? AND status = ?", eventID, evModel.OutboxStatusPending).Updates(updates).Error
+}
+
+// GetOutboxStats returns the number of pending and failed events and when the oldest pending one was
+// published
+func (r *WebhookRepository) GetOutboxStats(c context.Context) (*evModel.OutboxStats, error) {
+	stats := &evModel.OutboxStats{}
+	if err := r.db.WithContext(c).Model(&evModel.OutboxEvent{}).Where("status = ?", evModel.OutboxStatusFailed).Count(&stats.Failed).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(c).Model(&evModel.OutboxEvent{}).Where("status = ?", evModel.OutboxStatusPending).Count(&stats.Pending).Error; err != nil {
+		return nil, err
+	}
+	if stats.Pending == 0 {
+		return stats, nil
+	}
+	var oldest []*evModel.OutboxEvent
+	if err := r.db.WithContext(c).Where("status = ?", evModel.OutboxStatusPending).Order("id asc").Limit(1).Find(&oldest).Error; err != nil {
+		return nil, err
+	}
+	if len(oldest) > 0 {
+		stats.OldestPendingAt = &oldest[0].CreatedAt
+	}
+	return stats, nil
+}
+
+// DeleteDispatchedOutboxEventsBefore removes the dispatched events published before the given time, their
+// deliveries are kept
+func (r *WebhookRepository) DeleteDispatchedOutboxEventsBefore(c context.Context, before time.Time) error {
+	return r.db.WithContext(c).Where("status = ? AND created_at < ?", evModel.OutboxStatusDispatched, before).Delete(&evModel.OutboxEvent{}).Error
+}
@@ -0,0 +1,381 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	auth "donetick.com/core/internal/authorization"
+	evModel "donetick.com/core/internal/events/model"
+	"donetick.com/core/internal/utils"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// SubscribableEventTypes are the event types a webhook subscription can subscribe to, besides "*" for all of them
+var SubscribableEventTypes = []EventType{
+	EventTypeTaskCompleted,
+	EventTypeTaskSkipped,
+	EventTypeTaskReminder,
+	EventTypeTaskOverdue,
+	EventTypeTaskEscalated,
+	EventTypeSubTaskCompleted,
+	EventTypeThingChanged,
+	EventTypeTaskCreated,
+	EventTypeTaskUpdated,
+	EventTypeTaskReassigned,
+	EventTypeTaskDeleted,
+	EventTypeTaskArchived,
+	EventTypeTaskUnarchived,
+	EventTypeTaskDueDateChanged,
+	EventTypeTaskTimerStarted,
+	EventTypeTaskTimerPaused,
+	EventTypeTaskTimerReset,
+	EventTypeTaskTimeSessionUpdated,
+	EventTypeTaskTimeSessionDeleted,
+	EventTypeTaskHistoryUpdated,
+	EventTypeTaskHistoryDeleted,
+	EventTypeEscalationPolicyUpdated,
+	EventTypeEscalationPolicyDeleted,
+	EventTypeRewardCreated,
+	EventTypeRewardRedeemed,
+	EventTypeRewardRedemptionUpdated,
+	EventTypeGoalCreated,
+	EventTypeGoalProgressUpdated,
+	EventTypeCircleMemberRequested,
+	EventTypeCircleMemberJoined,
+	EventTypeCircleMemberLeft,
+	EventTypeCircleMemberRemoved,
+	EventTypeCircleMemberRoleChanged,
+	EventTypeCirclePointsRedeemed,
+}
+
+type subscriptionRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Description string   `json:"description"`
+	EventTypes  []string `json:"eventTypes" binding:"required"`
+	Enabled     *bool    `json:"enabled"`
+	Format      string   `json:"format"`
+}
+
+// createdSubscription is the create response, besides rotating it's the only time the secret is returned
+type createdSubscription struct {
+	*evModel.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+func (r subscriptionRequest) validate() error {
+	endpoint, err := url.Parse(r.URL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return fmt.Errorf("url must be an absolute http or https URL")
+	}
+	if len(r.EventTypes) == 0 {
+		return fmt.Errorf("at least one event type is required")
+	}
+	for _, eventType := range r.EventTypes {
+		if !isSubscribableEventType(eventType) {
+			return fmt.Errorf("unknown event type %q", eventType)
+		}
+	}
+	switch evModel.WebhookFormat(r.Format) {
+	case "", evModel.WebhookFormatDonetick, evModel.WebhookFormatCloudEventsStructured, evModel.WebhookFormatCloudEventsBinary:
+	default:
+		return fmt.Errorf("format must be %s, %s or %s", evModel.WebhookFormatDonetick, evModel.WebhookFormatCloudEventsStructured, evModel.WebhookFormatCloudEventsBinary)
+	}
+	return nil
+}
+
+func isSubscribableEventType(eventType string) bool {
+	if eventType == evModel.WebhookEventTypeAll {
+		return true
+	}
+	for _, subscribable := range SubscribableEventTypes {
+		if string(subscribable) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Handler) getEventTypes(c *gin.Context) {
+	c.JSON(200, gin.H{
+		"res": SubscribableEventTypes,
+	})
+}
+
+func (h *Handler) getSubscriptions(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+	subscriptions, err := h.webhookRepo.GetSubscriptions(c, currentUser.CircleID)
+	if err != nil {
+		logging.FromContext(c).Error("Error getting webhook subscriptions:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting webhook subscriptions",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": subscriptions,
+	})
+}
+
+func (h *Handler) getSubscription(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+	subscription, ok := h.findSubscription(c, currentUser.CircleID)
+	if !ok {
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": subscription,
+	})
+}
+
+// createSubscription adds an endpoint to the circle, the response carries the secret its deliveries are signed with
+func (h *Handler) createSubscription(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+
+	var req subscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	count, err := h.webhookRepo.CountSubscriptions(c, currentUser.CircleID)
+	if err != nil {
+		log.Error("Error counting webhook subscriptions:", err)
+		c.JSON(500, gin.H{
+			"error": "Error creating webhook subscription",
+		})
+		return
+	}
+	if count >= evModel.MaxWebhookSubscriptions {
+		c.JSON(400, gin.H{
+			"error": fmt.Sprintf("A circle can have at most %d webhook subscriptions", evModel.MaxWebhookSubscriptions),
+		})
+		return
+	}
+
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		log.Error("Error generating webhook secret:", err)
+		c.JSON(500, gin.H{
+			"error": "Error creating webhook subscription",
+		})
+		return
+	}
+	format := evModel.WebhookFormat(req.Format)
+	if format == "" {
+		format = evModel.WebhookFormatDonetick
+	}
+	subscription := &evModel.WebhookSubscription{
+		CircleID:    currentUser.CircleID,
+		URL:         req.URL,
+		Description: req.Description,
+		Secret:      secret,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		EventTypes:  req.EventTypes,
+		Format:      format,
+		CreatedBy:   currentUser.ID,
+	}
+	if err := h.webhookRepo.CreateSubscription(c, subscription); err != nil {
+		log.Error("Error creating webhook subscription:", err)
+		c.JSON(500, gin.H{
+			"error": "Error creating webhook subscription",
+		})
+		return
+	}
+	c.JSON(201, gin.H{
+		"res": createdSubscription{WebhookSubscription: subscription, Secret: secret},
+	})
+}
+
+// updateSubscription replaces the URL, description and event types, enabled and format are kept when
+// they're not sent
+func (h *Handler) updateSubscription(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+	subscription, ok := h.findSubscription(c, currentUser.CircleID)
+	if !ok {
+		return
+	}
+
+	var req subscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+	if err := req.validate(); err != nil {
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	subscription.URL = req.URL
+	subscription.Description = req.Description
+	subscription.EventTypes = req.EventTypes
+	if req.Enabled != nil {
+		subscription.Enabled = *req.Enabled
+	}
+	if req.Format != "" {
+		subscription.Format = evModel.WebhookFormat(req.Format)
+	}
+	if err := h.webhookRepo.UpdateSubscription(c, subscription); err != nil {
+		logging.FromContext(c).Error("Error updating webhook subscription:", err)
+		c.JSON(500, gin.H{
+			"error": "Error updating webhook subscription",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": subscription,
+	})
+}
+
+func (h *Handler) deleteSubscription(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+	subscriptionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid subscription ID",
+		})
+		return
+	}
+	deleted, err := h.webhookRepo.DeleteSubscription(c, currentUser.CircleID, subscriptionID)
+	if err != nil {
+		logging.FromContext(c).Error("Error deleting webhook subscription:", err)
+		c.JSON(500, gin.H{
+			"error": "Error deleting webhook subscription",
+		})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(404, gin.H{
+			"error": "Subscription not found",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"message": "Subscription deleted successfully",
+	})
+}
+
+// rotateSubscriptionSecret replaces the signing secret of a subscription, its receiver has to be updated with the new one
+func (h *Handler) rotateSubscriptionSecret(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+	subscription, ok := h.findSubscription(c, currentUser.CircleID)
+	if !ok {
+		return
+	}
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		log.Error("Error generating webhook secret:", err)
+		c.JSON(500, gin.H{
+			"error": "Error rotating webhook secret",
+		})
+		return
+	}
+	subscription.Secret = secret
+	if err := h.webhookRepo.UpdateSubscription(c, subscription); err != nil {
+		log.Error("Error rotating webhook subscription secret:", err)
+		c.JSON(500, gin.H{
+			"error": "Error rotating webhook secret",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{
+			"secret": secret,
+		},
+	})
+}
+
+// findSubscription loads the subscription of the request, writing the error response when the circle has no such subscription
+func (h *Handler) findSubscription(c *gin.Context, circleID int) (*evModel.WebhookSubscription, bool) {
+	subscriptionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid subscription ID",
+		})
+		return nil, false
+	}
+	subscription, err := h.webhookRepo.GetSubscription(c, circleID, subscriptionID)
+	if err != nil {
+		logging.FromContext(c).Error("Error getting webhook subscription:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting webhook subscription",
+		})
+		return nil, false
+	}
+	if subscription == nil {
+		c.JSON(404, gin.H{
+			"error": "Subscription not found",
+		})
+		return nil, false
+	}
+	return subscription, true
+}
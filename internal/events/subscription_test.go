@@ -0,0 +1,114 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	evModel "donetick.com/core/internal/events/model"
+)
+
+func TestSubscriptionRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     subscriptionRequest
+		wantErr bool
+	}{
+		{name: "valid", req: subscriptionRequest{URL: "https://example.com/hook", EventTypes: []string{"task.completed", "thing.changed"}}},
+		{name: "all events", req: subscriptionRequest{URL: "http://localhost:8080/hook", EventTypes: []string{evModel.WebhookEventTypeAll}}},
+		{name: "relative url", req: subscriptionRequest{URL: "/hook", EventTypes: []string{"task.completed"}}, wantErr: true},
+		{name: "unsupported scheme", req: subscriptionRequest{URL: "ftp://example.com/hook", EventTypes: []string{"task.completed"}}, wantErr: true},
+		{name: "no event types", req: subscriptionRequest{URL: "https://example.com/hook"}, wantErr: true},
+		{name: "unknown event type", req: subscriptionRequest{URL: "https://example.com/hook", EventTypes: []string{"task.renamed"}}, wantErr: true},
+		{name: "cloudevents format", req: subscriptionRequest{URL: "https://example.com/hook", EventTypes: []string{"task.completed"}, Format: "cloudevents-binary"}},
+		{name: "unknown format", req: subscriptionRequest{URL: "https://example.com/hook", EventTypes: []string{"task.completed"}, Format: "xml"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewDeliveriesFanOut(t *testing.T) {
+	subscriptions := []*evModel.WebhookSubscription{
+		{ID: 1, URL: "https://a.example.com", Enabled: true, EventTypes: evModel.WebhookEventTypes{"task.completed"}},
+		{ID: 2, URL: "https://b.example.com", Enabled: true, EventTypes: evModel.WebhookEventTypes{"thing.changed"}},
+		{ID: 3, URL: "https://c.example.com", Enabled: true, EventTypes: evModel.WebhookEventTypes{evModel.WebhookEventTypeAll}},
+		{ID: 4, URL: "https://d.example.com", Enabled: false, EventTypes: evModel.WebhookEventTypes{"task.completed"}},
+	}
+	event := &evModel.OutboxEvent{
+		IdempotencyKey: "evt_1",
+		EventType:      string(EventTypeTaskCompleted),
+		URL:            "https://circle.example.com",
+		CircleID:       7,
+		Payload:        `{"id":"evt_1","type":"task.completed"}`,
+		Status:         evModel.OutboxStatusPending,
+	}
+
+	deliveries, err := newDeliveries(event, subscriptions, "", CloudEventsSchemaBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantURLs := []string{"https://circle.example.com", "https://a.example.com", "https://c.example.com"}
+	if len(deliveries) != len(wantURLs) {
+		t.Fatalf("got %d deliveries, want %d", len(deliveries), len(wantURLs))
+	}
+	for i, delivery := range deliveries {
+		if delivery.URL != wantURLs[i] {
+			t.Errorf("delivery %d url = %s, want %s", i, delivery.URL, wantURLs[i])
+		}
+		if delivery.CircleID != 7 || delivery.Status != evModel.DeliveryStatusPending {
+			t.Errorf("delivery %d = %+v", i, delivery)
+		}
+		if delivery.Payload != event.Payload {
+			t.Errorf("delivery %d payload differs from the event payload", i)
+		}
+		if delivery.EventID != "evt_1" {
+			t.Errorf("delivery %d event id = %q, want the idempotency key of the event", i, delivery.EventID)
+		}
+	}
+	if deliveries[0].SubscriptionID != nil {
+		t.Errorf("circle webhook delivery has subscription %d", *deliveries[0].SubscriptionID)
+	}
+	if deliveries[1].SubscriptionID == nil || *deliveries[1].SubscriptionID != 1 {
+		t.Errorf("delivery 1 subscription = %v, want 1", deliveries[1].SubscriptionID)
+	}
+
+	event.URL = ""
+	event.EventType = string(EventTypeTaskSkipped)
+	deliveries, err = newDeliveries(event, subscriptions, "", CloudEventsSchemaBase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].URL != "https://c.example.com" {
+		t.Errorf("got %d deliveries for a circle without webhook URL, want only the wildcard subscription", len(deliveries))
+	}
+}
+
+func TestSubscriptionSecretOnlyInCreateResponse(t *testing.T) {
+	subscription := &evModel.WebhookSubscription{ID: 3, URL: "https://example.com/hook", Secret: "whsec_test"}
+
+	listed, err := json.Marshal([]*evModel.WebhookSubscription{subscription})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(listed), "whsec_test") {
+		t.Errorf("listed subscriptions = %s, want no secret", listed)
+	}
+
+	created, err := json.Marshal(createdSubscription{WebhookSubscription: subscription, Secret: subscription.Secret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res map[string]interface{}
+	if err := json.Unmarshal(created, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res["secret"] != "whsec_test" || res["url"] != "https://example.com/hook" {
+		t.Errorf("create response = %s, want the subscription with its secret", created)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	config "donetick.com/core/config"
 	chModel "donetick.com/core/internal/chore/model"
+	"donetick.com/core/internal/database/dbtx"
 	lModel "donetick.com/core/internal/label/model"
 	"donetick.com/core/logging"
 	"gorm.io/gorm"
@@ -22,14 +23,14 @@ func NewLabelRepository(db *gorm.DB, cfg *config.Config) *LabelRepository {
 
 func (r *LabelRepository) GetUserLabels(ctx context.Context, userID int, circleID int) ([]*lModel.Label, error) {
 	var labels []*lModel.Label
-	if err := r.db.WithContext(ctx).Where("created_by = ? OR circle_id = ? ", userID, circleID).Find(&labels).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).Where("created_by = ? OR circle_id = ? ", userID, circleID).Find(&labels).Error; err != nil {
 		return nil, err
 	}
 	return labels, nil
 }
 
 func (r *LabelRepository) CreateLabels(ctx context.Context, labels []*lModel.Label) error {
-	if err := r.db.WithContext(ctx).Create(&labels).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).Create(&labels).Error; err != nil {
 		return err
 	}
 	return nil
@@ -37,7 +38,7 @@ func (r *LabelRepository) CreateLabels(ctx context.Context, labels []*lModel.Lab
 
 func (r *LabelRepository) GetLabelsByIDs(ctx context.Context, ids []int) ([]*lModel.Label, error) {
 	var labels []*lModel.Label
-	if err := r.db.WithContext(ctx).Where("id IN (?)", ids).Find(&labels).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).Where("id IN (?)", ids).Find(&labels).Error; err != nil {
 		return nil, err
 	}
 	return labels, nil
@@ -49,7 +50,7 @@ func (r *LabelRepository) isLabelsAssignableByUser(ctx context.Context, userID i
 
 	log := logging.FromContext(ctx)
 	var count int64
-	if err := r.db.WithContext(ctx).Model(&lModel.Label{}).Where("id IN (?) AND (created_by = ?  OR circle_id = ?) ", labelIDs, userID, circleID).Count(&count).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).Model(&lModel.Label{}).Where("id IN (?) AND (created_by = ?  OR circle_id = ?) ", labelIDs, userID, circleID).Count(&count).Error; err != nil {
 		log.Error(err)
 		return false
 	}
@@ -72,14 +73,14 @@ func (r *LabelRepository) AssignLabelsToChore(ctx context.Context, choreID int,
 			UserID:  userID,
 		})
 	}
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
 		if len(toBeRemoved) > 0 {
-			if err := r.db.WithContext(ctx).Where("chore_id = ? AND user_id = ? AND label_id IN (?)", choreID, userID, toBeRemoved).Delete(&chModel.ChoreLabels{}).Error; err != nil {
+			if err := dbtx.DB(ctx, r.db).Where("chore_id = ? AND user_id = ? AND label_id IN (?)", choreID, userID, toBeRemoved).Delete(&chModel.ChoreLabels{}).Error; err != nil {
 				return err
 			}
 		}
 		if len(toBeAdded) > 0 {
-			if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+			if err := dbtx.DB(ctx, r.db).Clauses(clause.OnConflict{
 				Columns:   []clause.Column{{Name: "chore_id"}, {Name: "label_id"}, {Name: "user_id"}},
 				DoNothing: true,
 			}).Create(&choreLabels).Error; err != nil {
@@ -91,7 +92,7 @@ func (r *LabelRepository) AssignLabelsToChore(ctx context.Context, choreID int,
 }
 
 func (r *LabelRepository) DeassignLabelsFromChore(ctx context.Context, choreID int, userID int, labelIDs []int) error {
-	if err := r.db.WithContext(ctx).Where("chore_id = ? AND user_id = ? AND label_id IN (?)", choreID, userID, labelIDs).Delete(&chModel.ChoreLabels{}).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).Where("chore_id = ? AND user_id = ? AND label_id IN (?)", choreID, userID, labelIDs).Delete(&chModel.ChoreLabels{}).Error; err != nil {
 		return err
 	}
 	return nil
@@ -99,7 +100,7 @@ func (r *LabelRepository) DeassignLabelsFromChore(ctx context.Context, choreID i
 
 func (r *LabelRepository) DeassignLabelFromAllChoreAndDelete(ctx context.Context, userID int, labelID int) error {
 	// create one transaction to confirm if the label is owned by the user then delete all ChoreLabels record for this label:
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
 		log := logging.FromContext(ctx)
 		var labelCount int64
 		if err := tx.Model(&lModel.Label{}).Where("id = ? AND created_by = ?", labelID, userID).Count(&labelCount).Error; err != nil {
@@ -126,7 +127,7 @@ func (r *LabelRepository) DeassignLabelFromAllChoreAndDelete(ctx context.Context
 
 func (r *LabelRepository) isLabelsOwner(ctx context.Context, userID int, labelIDs []int) bool {
 	var count int64
-	r.db.WithContext(ctx).Model(&lModel.Label{}).Where("id IN (?) AND user_id = ?", labelIDs, userID).Count(&count)
+	dbtx.DB(ctx, r.db).Model(&lModel.Label{}).Where("id IN (?) AND user_id = ?", labelIDs, userID).Count(&count)
 	return count == 1
 }
 
@@ -136,7 +137,7 @@ func (r *LabelRepository) DeleteLabels(ctx context.Context, userID int, ids []in
 		return errors.New("labels are not owned by user")
 	}
 
-	tx := r.db.WithContext(ctx).Begin()
+	tx := dbtx.DB(ctx, r.db).Begin()
 
 	if err := tx.Where("label_id IN (?)", ids).Delete(&chModel.ChoreLabels{}).Error; err != nil {
 		tx.Rollback()
@@ -158,7 +159,7 @@ func (r *LabelRepository) DeleteLabels(ctx context.Context, userID int, ids []in
 
 func (r *LabelRepository) UpdateLabel(ctx context.Context, userID int, label *lModel.Label) error {
 
-	if err := r.db.WithContext(ctx).Model(&lModel.Label{}).Where("id = ? and created_by = ?", label.ID, userID).Updates(label).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).Model(&lModel.Label{}).Where("id = ? and created_by = ?", label.ID, userID).Updates(label).Error; err != nil {
 		return err
 	}
 	return nil
@@ -0,0 +1,258 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	nModel "donetick.com/core/internal/notifier/model"
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+)
+
+// digestContent is what goes into a digest, chores are the user's and completions the circle's
+type digestContent struct {
+	frequency uModel.DigestFrequency
+	now       time.Time
+	loc       *time.Location
+	overdue   []*chModel.Chore
+	due       []*chModel.Chore
+	completed []*chModel.CompletedChore
+	members   map[int]string
+}
+
+// generateDigestJob queues the digest of every user whose digest time has passed today. the
+// digest goes through the regular delivery loop, so retries and quiet hours apply to it as well
+func (s *Scheduler) generateDigestJob(c context.Context) (time.Duration, error) {
+	log := logging.FromContext(c)
+	startTime := time.Now()
+
+	subscribers, err := s.userRepo.GetDigestSubscribers(c)
+	if err != nil {
+		log.Error("Error getting digest subscribers", err)
+		return time.Since(startTime), err
+	}
+
+	now := time.Now().UTC()
+	for _, settings := range subscribers {
+		dueAt, due := settings.DigestDue(now)
+		if !due {
+			continue
+		}
+		notifications, err := s.buildDigestNotifications(c, settings, now)
+		if err != nil {
+			log.Errorw("Error building digest", "user_id", settings.UserID, "error", err)
+			continue
+		}
+		if len(notifications) > 0 {
+			if err := s.notificationRepo.BatchInsertNotifications(notifications); err != nil {
+				log.Errorw("Error inserting digest notifications", "user_id", settings.UserID, "error", err)
+				continue
+			}
+		}
+		if err := s.userRepo.UpdateLastDigestAt(c, settings.UserID, dueAt.UTC()); err != nil {
+			log.Errorw("Error updating last digest time", "user_id", settings.UserID, "error", err)
+		}
+	}
+	return time.Since(startTime), nil
+}
+
+func (s *Scheduler) buildDigestNotifications(c context.Context, settings *uModel.NotificationSettings, now time.Time) ([]*nModel.Notification, error) {
+	user, err := s.userRepo.GetUserByID(c, settings.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := settings.Location()
+	local := now.In(loc)
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	days := 1
+	if settings.Digest == uModel.DigestWeekly {
+		days = 7
+	}
+
+	content := &digestContent{frequency: settings.Digest, now: now, loc: loc, members: make(map[int]string)}
+	chores, err := s.choreRepo.GetAssignedChoresDueBefore(c, user.CircleID, user.ID, dayStart.AddDate(0, 0, days))
+	if err != nil {
+		return nil, err
+	}
+	for _, chore := range chores {
+		if chore.NextDueDate.Before(dayStart) {
+			content.overdue = append(content.overdue, chore)
+		} else {
+			content.due = append(content.due, chore)
+		}
+	}
+	content.completed, err = s.choreRepo.GetCompletedChores(c, user.CircleID, dayStart.AddDate(0, 0, -days), dayStart)
+	if err != nil {
+		return nil, err
+	}
+	members, err := s.circleRepo.GetCircleUsers(c, user.CircleID)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		content.members[member.UserID] = member.DisplayName
+	}
+
+	if content.empty() {
+		return nil, nil
+	}
+
+	targets, err := s.digestTargets(c, user)
+	if err != nil {
+		return nil, err
+	}
+	text, event := content.render()
+	notifications := make([]*nModel.Notification, 0, len(targets))
+	for _, target := range targets {
+		notifications = append(notifications, &nModel.Notification{
+			CircleID:     user.CircleID,
+			UserID:       user.ID,
+			TypeID:       target.Type,
+			TargetID:     target.TargetID,
+			EventType:    nModel.EventTypeDigest,
+			ScheduledFor: now,
+			CreatedAt:    now,
+			Text:         text,
+			RawEvent:     event,
+		})
+	}
+	return notifications, nil
+}
+
+// digestTargets returns the user's targets subscribed to digests, or their email address when there
+// is none and email is configured
+func (s *Scheduler) digestTargets(c context.Context, user *uModel.User) ([]*uModel.NotificationTarget, error) {
+	userTargets, err := s.userRepo.GetNotificationTargets(c, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]*uModel.NotificationTarget, 0, len(userTargets))
+	for _, target := range userTargets {
+		if target.Enabled && target.Type != nModel.NotificationPlatformNone && target.Events.Matches(nModel.EventTypeDigest) {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 && user.Email != "" {
+		if _, ok := s.notifier.providers.Get(nModel.NotificationPlatformEmail); ok {
+			targets = append(targets, &uModel.NotificationTarget{Type: nModel.NotificationPlatformEmail, TargetID: user.Email})
+		}
+	}
+	return targets, nil
+}
+
+func (d *digestContent) empty() bool {
+	return len(d.overdue) == 0 && len(d.due) == 0 && len(d.completed) == 0
+}
+
+// render builds the digest text and the raw event carried to webhook and email providers
+func (d *digestContent) render() (string, map[string]interface{}) {
+	local := d.now.In(d.loc)
+	var b strings.Builder
+	weekly := d.frequency == uModel.DigestWeekly
+	if weekly {
+		fmt.Fprintf(&b, "📊 *Weekly digest* for the week of %s\n", local.Format("Mon, Jan 2"))
+	} else {
+		fmt.Fprintf(&b, "☀️ *Daily digest* for %s\n", local.Format("Monday, Jan 2"))
+	}
+
+	if len(d.overdue) > 0 {
+		fmt.Fprintf(&b, "\n*Overdue (%d)*\n", len(d.overdue))
+		for _, chore := range d.overdue {
+			fmt.Fprintf(&b, "• %s, due %s\n", chore.Name, chore.NextDueDate.In(d.loc).Format("Jan 2"))
+		}
+	}
+
+	dueTitle, dueFormat := "Due today", "15:04"
+	if weekly {
+		dueTitle, dueFormat = "Due this week", "Mon 15:04"
+	}
+	if len(d.due) > 0 {
+		fmt.Fprintf(&b, "\n*%s (%d)*\n", dueTitle, len(d.due))
+		for _, chore := range d.due {
+			fmt.Fprintf(&b, "• %s at %s\n", chore.Name, chore.NextDueDate.In(d.loc).Format(dueFormat))
+		}
+	}
+
+	completedTitle := "Completed yesterday"
+	if weekly {
+		completedTitle = "Completed last week"
+	}
+	if len(d.completed) > 0 {
+		fmt.Fprintf(&b, "\n*%s (%d)*\n", completedTitle, len(d.completed))
+		if weekly {
+			// the week is summarised per member instead of listing every completion
+			for _, stat := range d.memberStats() {
+				fmt.Fprintf(&b, "• %s: %d\n", stat.name, stat.count)
+			}
+		} else {
+			for _, completed := range d.completed {
+				fmt.Fprintf(&b, "• %s by %s\n", completed.Name, d.memberName(completed.CompletedBy))
+			}
+		}
+	}
+
+	event := map[string]interface{}{
+		"type":      nModel.EventTypeDigest,
+		"frequency": string(d.frequency),
+		"overdue":   digestChores(d.overdue),
+		"due":       digestChores(d.due),
+		"completed": len(d.completed),
+	}
+	if weekly {
+		stats := make(map[string]int)
+		for _, stat := range d.memberStats() {
+			stats[stat.name] = stat.count
+		}
+		event["circle_stats"] = stats
+	}
+	return strings.TrimRight(b.String(), "\n"), event
+}
+
+type memberStat struct {
+	name  string
+	count int
+}
+
+// memberStats counts the completions per circle member, most completions first
+func (d *digestContent) memberStats() []memberStat {
+	counts := make(map[int]int)
+	for _, completed := range d.completed {
+		counts[completed.CompletedBy]++
+	}
+	stats := make([]memberStat, 0, len(counts))
+	for userID, count := range counts {
+		stats = append(stats, memberStat{name: d.memberName(userID), count: count})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].count != stats[j].count {
+			return stats[i].count > stats[j].count
+		}
+		return stats[i].name < stats[j].name
+	})
+	return stats
+}
+
+func (d *digestContent) memberName(userID int) string {
+	if name, ok := d.members[userID]; ok && name != "" {
+		return name
+	}
+	return "a former member"
+}
+
+func digestChores(chores []*chModel.Chore) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0, len(chores))
+	for _, chore := range chores {
+		items = append(items, map[string]interface{}{
+			"id":       chore.ID,
+			"name":     chore.Name,
+			"due_date": chore.NextDueDate,
+			"priority": chore.Priority,
+		})
+	}
+	return items
+}
@@ -0,0 +1,64 @@
+package notifier
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	uModel "donetick.com/core/internal/user/model"
+)
+
+func TestDigestContentRender(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 8, 0, 0, 0, time.UTC)
+	overdueDate := now.AddDate(0, 0, -2)
+	dueDate := now.Add(4 * time.Hour)
+	members := map[int]string{1: "Sam", 2: "Alex"}
+
+	t.Run("daily", func(t *testing.T) {
+		content := &digestContent{
+			frequency: uModel.DigestDaily,
+			now:       now,
+			loc:       time.UTC,
+			overdue:   []*chModel.Chore{{ID: 1, Name: "Water plants", NextDueDate: &overdueDate}},
+			due:       []*chModel.Chore{{ID: 2, Name: "Vacuum", NextDueDate: &dueDate}},
+			completed: []*chModel.CompletedChore{{ChoreID: 3, Name: "Dishes", CompletedBy: 2}},
+			members:   members,
+		}
+		text, event := content.render()
+		for _, want := range []string{"*Daily digest* for Tuesday, Mar 10", "*Overdue (1)*", "• Water plants, due Mar 8", "*Due today (1)*", "• Vacuum at 12:00", "• Dishes by Alex"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("digest is missing %q:\n%s", want, text)
+			}
+		}
+		if event["frequency"] != "daily" || event["completed"] != 1 || len(event["overdue"].([]map[string]interface{})) != 1 {
+			t.Errorf("unexpected event %v", event)
+		}
+		if _, ok := event["circle_stats"]; ok {
+			t.Error("daily digests should not carry circle stats")
+		}
+	})
+
+	t.Run("weekly", func(t *testing.T) {
+		content := &digestContent{
+			frequency: uModel.DigestWeekly,
+			now:       now,
+			loc:       time.UTC,
+			completed: []*chModel.CompletedChore{
+				{ChoreID: 3, Name: "Dishes", CompletedBy: 2},
+				{ChoreID: 4, Name: "Laundry", CompletedBy: 1},
+				{ChoreID: 3, Name: "Dishes", CompletedBy: 2},
+				{ChoreID: 5, Name: "Trash", CompletedBy: 9},
+			},
+			members: members,
+		}
+		text, event := content.render()
+		if !strings.Contains(text, "*Completed last week (4)*\n• Alex: 2\n• Sam: 1\n• a former member: 1") {
+			t.Errorf("unexpected weekly digest:\n%s", text)
+		}
+		stats, _ := event["circle_stats"].(map[string]int)
+		if stats["Alex"] != 2 || stats["Sam"] != 1 {
+			t.Errorf("unexpected circle stats %v", event["circle_stats"])
+		}
+	})
+}
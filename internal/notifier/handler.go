@@ -0,0 +1,129 @@
+package notifier
+
+import (
+	auth "donetick.com/core/internal/authorization"
+	cRepo "donetick.com/core/internal/circle/repo"
+	nRepo "donetick.com/core/internal/notifier/repo"
+	"donetick.com/core/internal/notifier/templates"
+	"donetick.com/core/logging"
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+)
+
+type RequeueReq struct {
+	IDs []int `json:"ids" binding:"required,min=1"`
+}
+
+type Handler struct {
+	notificationRepo *nRepo.NotificationRepository
+	circleRepo       *cRepo.CircleRepository
+	renderer         *templates.Renderer
+}
+
+func NewHandler(nr *nRepo.NotificationRepository, cr *cRepo.CircleRepository, renderer *templates.Renderer) *Handler {
+	return &Handler{
+		notificationRepo: nr,
+		circleRepo:       cr,
+		renderer:         renderer,
+	}
+}
+
+// requireCircleAdmin writes the error response and returns false when the current user can't manage deliveries
+func (h *Handler) requireCircleAdmin(c *gin.Context, userID, circleID int) bool {
+	log := logging.FromContext(c)
+	members, err := h.circleRepo.GetCircleUsers(c, circleID)
+	if err != nil {
+		log.Error("Error getting circle members:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting circle members",
+		})
+		return false
+	}
+	for _, member := range members {
+		if member.UserID == userID && member.Role == "admin" {
+			return true
+		}
+	}
+	c.JSON(403, gin.H{
+		"error": "You are not an admin of this circle",
+	})
+	return false
+}
+
+func (h *Handler) getFailedNotifications(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+
+	notifications, err := h.notificationRepo.GetFailedNotifications(c, currentUser.CircleID)
+	if err != nil {
+		log.Error("Error getting failed notifications:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting failed notifications",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": notifications,
+	})
+}
+
+func (h *Handler) requeueFailedNotifications(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	var req RequeueReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+
+	requeued, err := h.notificationRepo.RequeueFailedNotifications(c, currentUser.CircleID, req.IDs)
+	if err != nil {
+		log.Error("Error requeuing failed notifications:", err)
+		c.JSON(500, gin.H{
+			"error": "Error requeuing failed notifications",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{"requeued": requeued},
+	})
+}
+
+func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware) {
+	notificationRoutes := router.Group("api/v1/notifications")
+	notificationRoutes.Use(auth.MiddlewareFunc())
+	{
+		notificationRoutes.GET("", h.getInbox)
+		notificationRoutes.POST("/read", h.markAllInboxItemsRead)
+		notificationRoutes.PUT("/:id/read", h.markInboxItemRead)
+		notificationRoutes.DELETE("/:id/read", h.markInboxItemUnread)
+		notificationRoutes.DELETE("/:id", h.dismissInboxItem)
+		notificationRoutes.GET("/failed", h.getFailedNotifications)
+		notificationRoutes.POST("/failed/requeue", h.requeueFailedNotifications)
+		notificationRoutes.GET("/templates", h.getMessageTemplates)
+		notificationRoutes.PUT("/templates", h.saveMessageTemplate)
+		notificationRoutes.DELETE("/templates/:id", h.deleteMessageTemplate)
+		notificationRoutes.POST("/templates/preview", h.previewMessageTemplate)
+	}
+}
@@ -0,0 +1,186 @@
+package notifier
+
+import (
+	"strconv"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultInboxPageSize = 20
+	maxInboxPageSize     = 100
+)
+
+// queryInt reads an optional non-negative integer query parameter
+func queryInt(c *gin.Context, name string, fallback int) (int, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return fallback, true
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	return value, true
+}
+
+// getInbox returns a page of the current user's inbox, newest first. unread=true leaves out the
+// items already read
+func (h *Handler) getInbox(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	limit, ok := queryInt(c, "limit", defaultInboxPageSize)
+	if !ok || limit == 0 {
+		c.JSON(400, gin.H{
+			"error": "Invalid limit",
+		})
+		return
+	}
+	if limit > maxInboxPageSize {
+		limit = maxInboxPageSize
+	}
+	offset, ok := queryInt(c, "offset", 0)
+	if !ok {
+		c.JSON(400, gin.H{
+			"error": "Invalid offset",
+		})
+		return
+	}
+
+	items, err := h.notificationRepo.GetInboxItems(c, currentUser.ID, c.Query("unread") == "true", limit, offset)
+	if err != nil {
+		log.Error("Error getting inbox:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting notifications",
+		})
+		return
+	}
+	total, unread, err := h.notificationRepo.CountInboxItems(c, currentUser.ID)
+	if err != nil {
+		log.Error("Error counting inbox items:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting notifications",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{
+			"items":       items,
+			"total":       total,
+			"unreadCount": unread,
+			"limit":       limit,
+			"offset":      offset,
+		},
+	})
+}
+
+func (h *Handler) markInboxItemRead(c *gin.Context) {
+	now := time.Now().UTC()
+	h.setInboxItemRead(c, &now)
+}
+
+func (h *Handler) markInboxItemUnread(c *gin.Context) {
+	h.setInboxItemRead(c, nil)
+}
+
+func (h *Handler) setInboxItemRead(c *gin.Context, readAt *time.Time) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid notification ID",
+		})
+		return
+	}
+
+	updated, err := h.notificationRepo.SetInboxItemRead(c, currentUser.ID, itemID, readAt)
+	if err != nil {
+		log.Error("Error updating inbox item:", err)
+		c.JSON(500, gin.H{
+			"error": "Error updating notification",
+		})
+		return
+	}
+	if updated == 0 {
+		c.JSON(404, gin.H{
+			"error": "Notification not found",
+		})
+		return
+	}
+	c.JSON(200, gin.H{})
+}
+
+func (h *Handler) markAllInboxItemsRead(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	updated, err := h.notificationRepo.MarkAllInboxItemsRead(c, currentUser.ID, time.Now().UTC())
+	if err != nil {
+		log.Error("Error marking inbox as read:", err)
+		c.JSON(500, gin.H{
+			"error": "Error updating notifications",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{"updated": updated},
+	})
+}
+
+// dismissInboxItem removes an item from the inbox, dismissed items are deleted by the cleanup job
+func (h *Handler) dismissInboxItem(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+	itemID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid notification ID",
+		})
+		return
+	}
+
+	dismissed, err := h.notificationRepo.DismissInboxItem(c, currentUser.ID, itemID, time.Now().UTC())
+	if err != nil {
+		log.Error("Error dismissing inbox item:", err)
+		c.JSON(500, gin.H{
+			"error": "Error dismissing notification",
+		})
+		return
+	}
+	if dismissed == 0 {
+		c.JSON(404, gin.H{
+			"error": "Notification not found",
+		})
+		return
+	}
+	c.JSON(200, gin.H{})
+}
@@ -4,6 +4,9 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -19,6 +22,13 @@ type Notification struct {
 	ScheduledFor time.Time            `json:"scheduled_for" gorm:"column:scheduled_for;index"`
 	CreatedAt    time.Time            `json:"created_at" gorm:"column:created_at"`
 	RawEvent     JSONB                `json:"raw_event" gorm:"column:raw_event;type:jsonb"`
+	EventType    EventType            `json:"event_type" gorm:"column:event_type;index"`
+
+	// delivery state, a notification is retried with backoff until it is sent or marked as failed
+	Attempts      int        `json:"attempts" gorm:"column:attempts;default:0"`
+	NextAttemptAt *time.Time `json:"next_attempt_at" gorm:"column:next_attempt_at;index"`
+	LastError     *string    `json:"last_error" gorm:"column:last_error"`
+	IsFailed      bool       `json:"is_failed" gorm:"column:is_failed;index;default:false"`
 }
 type NotificationDetails struct {
 	Notification
@@ -30,6 +40,43 @@ func (n *Notification) IsValid() bool {
 	return true
 }
 
+// ChoreURL is the deep link to the chore in the frontend, empty when it can't be built
+func (n *Notification) ChoreURL(appHost string) string {
+	if n.ChoreID == 0 || appHost == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/chores/%d", strings.TrimRight(appHost, "/"), n.ChoreID)
+}
+
+// emphasisRegex matches the * and ** the message templates put around values. a marker opens after a space
+// or the start of the text and closes before a space, punctuation or the end of it
+var emphasisRegex = regexp.MustCompile(`(^|[\s(\[])\*{1,2}(\S|\S[^\n]*?\S)\*{1,2}($|[\s.,!?:;)\]])`)
+
+// StripEmphasis removes the emphasis markers from a notification text for providers that show them verbatim,
+// a * that isn't one of a pair, like in a chore name or a url, is kept
+func StripEmphasis(text string) string {
+	for {
+		stripped := emphasisRegex.ReplaceAllString(text, "$1$2$3")
+		if stripped == text {
+			return text
+		}
+		text = stripped
+	}
+}
+
+// Priority returns the chore priority carried in the raw event, 0 when the chore has none
+func (n *Notification) Priority() int {
+	switch priority := n.RawEvent["priority"].(type) {
+	case int:
+		return priority
+	case float64:
+		// JSON numbers are decoded as float64 when the event is loaded from the database
+		return int(priority)
+	default:
+		return 0
+	}
+}
+
 type NotificationPlatform int8
 
 const (
@@ -38,8 +85,98 @@ const (
 	NotificationPlatformPushover
 	NotificationPlatformWebhook
 	NotificationPlatformDiscord
+	NotificationPlatformEmail
+	NotificationPlatformNtfy
+	NotificationPlatformGotify
+	NotificationPlatformMatrix
+	NotificationPlatformSlack
+	NotificationPlatformWebPush
 )
 
+type EventType string
+
+const (
+	EventTypeUnknown EventType = "unknown"
+	EventTypeDue     EventType = "due"
+	EventTypePreDue  EventType = "pre_due"
+	EventTypeOverdue EventType = "overdue"
+	// EventTypeNagging marks the repeating reminders sent while a chore stays overdue
+	EventTypeNagging EventType = "nagging"
+	// EventTypeCompletion is sent when someone completes a chore
+	EventTypeCompletion EventType = "completion"
+	// EventTypeDigest is the daily or weekly summary of a user's chores
+	EventTypeDigest EventType = "digest"
+	// EventTypeEscalation tells the circle admins that an overdue chore was escalated
+	EventTypeEscalation EventType = "escalation"
+	// EventTypeRedemption is a points or reward redemption, it is only shown in the in-app inbox
+	EventTypeRedemption EventType = "redemption"
+)
+
+// EventFilter limits a notification target to some event types, an empty filter matches every event
+type EventFilter []EventType
+
+// FilterableEventTypes are the events a notification target can subscribe to
+var FilterableEventTypes = []EventType{EventTypePreDue, EventTypeDue, EventTypeOverdue, EventTypeCompletion, EventTypeNagging, EventTypeDigest, EventTypeEscalation}
+
+func (f EventFilter) Matches(eventType EventType) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, e := range f {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (f EventFilter) Validate() error {
+	for _, e := range f {
+		valid := false
+		for _, allowed := range FilterableEventTypes {
+			if e == allowed {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("unknown event type %q", e)
+		}
+	}
+	return nil
+}
+
+func (f EventFilter) Value() (driver.Value, error) {
+	if f == nil {
+		f = EventFilter{}
+	}
+	value, err := json.Marshal(f)
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
+
+func (f *EventFilter) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, f)
+	case string:
+		return json.Unmarshal([]byte(v), f)
+	default:
+		return errors.New("type assertion to []byte or string failed")
+	}
+}
+
+// ProviderCapabilities describes what a notification provider can render
+type ProviderCapabilities struct {
+	Markdown bool `json:"markdown"`
+	Buttons  bool `json:"buttons"`
+	Priority bool `json:"priority"`
+}
+
 type JSONB map[string]interface{}
 
 func (j JSONB) Value() (driver.Value, error) {
@@ -60,3 +197,40 @@ func (j *JSONB) Scan(value interface{}) error {
 		return errors.New("type assertion to []byte or string failed")
 	}
 }
+
+// VAPIDKey is the server key pair used to sign web push requests, only generated when none is configured
+type VAPIDKey struct {
+	ID         int       `json:"id" gorm:"primary_key"`
+	PublicKey  string    `json:"publicKey" gorm:"column:public_key;not null"`
+	PrivateKey string    `json:"-" gorm:"column:private_key;not null"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"column:created_at"`
+}
+
+// MessageTemplate overrides the text of an event type for a circle, an empty locale applies to every
+// locale without its own override
+type MessageTemplate struct {
+	ID        int       `json:"id" gorm:"primary_key"`
+	CircleID  int       `json:"circleId" gorm:"column:circle_id;not null;uniqueIndex:idx_message_template"`
+	EventType EventType `json:"eventType" gorm:"column:event_type;not null;uniqueIndex:idx_message_template"`
+	Locale    string    `json:"locale" gorm:"column:locale;not null;uniqueIndex:idx_message_template"`
+	Body      string    `json:"body" gorm:"column:body;type:text;not null"`
+	UpdatedBy int       `json:"updatedBy" gorm:"column:updated_by"`
+	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+// InboxItem is a notification kept in the user's in-app inbox. Key identifies the event the item is
+// about so a reminder delivered to several targets, or retried, shows up once
+type InboxItem struct {
+	ID          int        `json:"id" gorm:"primary_key"`
+	UserID      int        `json:"userId" gorm:"column:user_id;not null;uniqueIndex:idx_inbox_key;index:idx_inbox_user"`
+	CircleID    int        `json:"circleId" gorm:"column:circle_id"`
+	ChoreID     int        `json:"choreId,omitempty" gorm:"column:chore_id"`
+	EventType   EventType  `json:"eventType" gorm:"column:event_type"`
+	Key         string     `json:"-" gorm:"column:dedup_key;not null;uniqueIndex:idx_inbox_key"`
+	Text        string     `json:"text" gorm:"column:text;type:text"`
+	Data        JSONB      `json:"data,omitempty" gorm:"column:data;type:json"`
+	ReadAt      *time.Time `json:"readAt" gorm:"column:read_at"`
+	DismissedAt *time.Time `json:"-" gorm:"column:dismissed_at"`
+	CreatedAt   time.Time  `json:"createdAt" gorm:"column:created_at;index:idx_inbox_user"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import "testing"
+
+func TestStripEmphasis(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "📅 Reminder: *Take out the trash* is due today.", want: "📅 Reminder: Take out the trash is due today."},
+		{text: "🎁 **Alex** redeemed **Movie night** for 50 points", want: "🎁 Alex redeemed Movie night for 50 points"},
+		{text: "*A* *B*", want: "A B"},
+		{text: "\n*Due today (2)*\n", want: "\nDue today (2)\n"},
+		{text: "🎉 *Clean the 5*3 grid* was completed!", want: "🎉 Clean the 5*3 grid was completed!"},
+		{text: "Check https://example.com/a*b and __init__ with `code`", want: "Check https://example.com/a*b and __init__ with `code`"},
+		{text: "2 * 3 = 6", want: "2 * 3 = 6"},
+	}
+	for _, tt := range tests {
+		if got := StripEmphasis(tt.text); got != tt.want {
+			t.Errorf("StripEmphasis(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
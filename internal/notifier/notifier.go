@@ -2,67 +2,48 @@ package notifier
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
-	"donetick.com/core/internal/events"
 	nModel "donetick.com/core/internal/notifier/model"
-	"donetick.com/core/internal/notifier/service/discord"
-	pushover "donetick.com/core/internal/notifier/service/pushover"
-	telegram "donetick.com/core/internal/notifier/service/telegram"
+	nps "donetick.com/core/internal/notifier/service"
 
 	"donetick.com/core/logging"
 )
 
+var (
+	// ErrPlatformNotConfigured is returned when the notification targets a platform that is not enabled on this server
+	ErrPlatformNotConfigured = errors.New("notification platform is not configured")
+)
+
 type Notifier struct {
-	Telegram       *telegram.TelegramNotifier
-	Pushover       *pushover.Pushover
-	discord        *discord.DiscordNotifier
-	eventsProducer *events.EventsProducer
+	providers *nps.ProviderRegistry
 }
 
-func NewNotifier(t *telegram.TelegramNotifier, p *pushover.Pushover, ep *events.EventsProducer, d *discord.DiscordNotifier) *Notifier {
+func NewNotifier(providers *nps.ProviderRegistry) *Notifier {
 	return &Notifier{
-		Telegram:       t,
-		Pushover:       p,
-		eventsProducer: ep,
-		discord:        d,
+		providers: providers,
 	}
 }
 
 func (n *Notifier) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
 	log := logging.FromContext(c)
-	var err error
-	switch notification.TypeID {
-	case nModel.NotificationPlatformTelegram:
-		if n.Telegram == nil {
-			log.Error("Telegram bot is not initialized, Skipping sending message")
-			return nil
-		}
-		err = n.Telegram.SendNotification(c, notification)
-	case nModel.NotificationPlatformPushover:
-		if n.Pushover == nil {
-			log.Error("Pushover is not initialized, Skipping sending message")
-			return nil
-		}
-		err = n.Pushover.SendNotification(c, notification)
-	case nModel.NotificationPlatformDiscord:
-		if n.discord == nil {
-			log.Error("Discord is not initialized, Skipping sending message")
-			return nil
-		}
-		err = n.discord.SendNotification(c, notification)
-
-	case nModel.NotificationPlatformWebhook:
-		// TODO: Implement webhook notification
-		// currently we have eventProducer to send events always as a webhook
-		// if NotificationPlatform is selected. this a case to catch
-		// when we only want to send a webhook
-
-	default:
-		log.Error("Unknown notification type", "type", notification.TypeID)
+	if notification.TypeID == nModel.NotificationPlatformNone {
 		return nil
 	}
-	if err != nil {
-		log.Error("Failed to send notification", "err", err)
+
+	provider, ok := n.providers.Get(notification.TypeID)
+	if !ok {
+		return fmt.Errorf("platform %d: %w", notification.TypeID, ErrPlatformNotConfigured)
+	}
+	if !provider.Capabilities().Markdown {
+		plain := *notification
+		plain.Text = nModel.StripEmphasis(notification.Text)
+		notification = &plain
+	}
+	if err := provider.Send(c, notification); err != nil {
+		log.Errorw("Failed to send notification", "notification_id", notification.ID, "provider", provider.Name(), "err", err)
+		return err
 	}
 
 	return nil
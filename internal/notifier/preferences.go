@@ -0,0 +1,98 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nModel "donetick.com/core/internal/notifier/model"
+	uModel "donetick.com/core/internal/user/model"
+	uRepo "donetick.com/core/internal/user/repo"
+)
+
+// deliveryPreferences resolves the quiet hours and digest settings of the users in a batch of
+// notifications, settings and targets are loaded once per user and batch
+type deliveryPreferences struct {
+	userRepo *uRepo.UserRepository
+	settings map[int]*uModel.NotificationSettings
+	targets  map[int]map[string]bool
+}
+
+func newDeliveryPreferences(ur *uRepo.UserRepository) *deliveryPreferences {
+	return &deliveryPreferences{
+		userRepo: ur,
+		settings: make(map[int]*uModel.NotificationSettings),
+		targets:  make(map[int]map[string]bool),
+	}
+}
+
+// quietHours returns whether the notification is held at now, until when and the user's policy
+func (q *deliveryPreferences) quietHours(c context.Context, notification *nModel.Notification, now time.Time) (time.Time, uModel.QuietHoursPolicy, bool, error) {
+	settings, err := q.userSettings(c, notification)
+	if err != nil || settings == nil {
+		return time.Time{}, "", false, err
+	}
+	until, muted := settings.MutedUntil(now, notification.Priority() == 1)
+	policy := settings.Policy
+	if policy == "" {
+		policy = uModel.QuietHoursPolicyDefer
+	}
+	return until, policy, muted, nil
+}
+
+// suppressedByDigest reports whether the notification is a reminder the user gets in their digest instead
+func (q *deliveryPreferences) suppressedByDigest(c context.Context, notification *nModel.Notification) (bool, error) {
+	switch notification.EventType {
+	case nModel.EventTypePreDue, nModel.EventTypeDue, nModel.EventTypeOverdue:
+	default:
+		return false, nil
+	}
+	settings, err := q.userSettings(c, notification)
+	if err != nil || settings == nil {
+		return false, err
+	}
+	return settings.DigestEnabled() && settings.DigestOnly, nil
+}
+
+// userSettings returns the settings of the notification's user when it goes to one of the user's
+// own targets, circle groups and webhook-only notifications are not affected by them
+func (q *deliveryPreferences) userSettings(c context.Context, notification *nModel.Notification) (*uModel.NotificationSettings, error) {
+	if notification.TypeID == nModel.NotificationPlatformNone {
+		return nil, nil
+	}
+	settings, err := q.load(c, notification.UserID)
+	if err != nil || settings == nil {
+		return nil, err
+	}
+	if !q.targets[notification.UserID][targetKey(notification.TypeID, notification.TargetID)] {
+		return nil, nil
+	}
+	return settings, nil
+}
+
+func (q *deliveryPreferences) load(c context.Context, userID int) (*uModel.NotificationSettings, error) {
+	if settings, ok := q.settings[userID]; ok {
+		return settings, nil
+	}
+	settings, err := q.userRepo.GetNotificationSettings(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]bool)
+	if settings != nil {
+		userTargets, err := q.userRepo.GetNotificationTargets(c, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, target := range userTargets {
+			targets[targetKey(target.Type, target.TargetID)] = true
+		}
+	}
+	q.settings[userID] = settings
+	q.targets[userID] = targets
+	return settings, nil
+}
+
+func targetKey(platform nModel.NotificationPlatform, targetID string) string {
+	return fmt.Sprintf("%d/%s", platform, targetID)
+}
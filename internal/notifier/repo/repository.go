@@ -4,8 +4,10 @@ import (
 	"context"
 	"time"
 
+	"donetick.com/core/internal/database/dbtx"
 	nModel "donetick.com/core/internal/notifier/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type NotificationRepository struct {
@@ -20,6 +22,17 @@ func (r *NotificationRepository) DeleteAllChoreNotifications(choreID int) error
 	return r.db.Where("chore_id = ?", choreID).Delete(&nModel.Notification{}).Error
 }
 
+// DeleteChoreReminders removes the pending pre-due, due and overdue reminders of the chore before they are
+// planned again. sent and failed notifications, nagging and escalation notifications are kept, the nagging
+// job dedupes on them and failed ones stay listed until they are requeued. reminders planned before
+// notifications had an event type have none
+func (r *NotificationRepository) DeleteChoreReminders(c context.Context, choreID int) error {
+	return dbtx.DB(c, r.db).
+		Where("chore_id = ? AND is_sent = ? AND is_failed = ?", choreID, false, false).
+		Where("event_type IN (?) OR event_type IS NULL OR event_type = ?", []nModel.EventType{nModel.EventTypePreDue, nModel.EventTypeDue, nModel.EventTypeOverdue}, "").
+		Delete(&nModel.Notification{}).Error
+}
+
 func (r *NotificationRepository) BatchInsertNotifications(notifications []*nModel.Notification) error {
 	return r.db.Create(&notifications).Error
 }
@@ -39,13 +52,176 @@ func (r *NotificationRepository) GetPendingNotificaiton(c context.Context, lookb
 	if err := r.db.Table("notifications").
 		Select("notifications.*, circles.webhook_url as webhook_url").
 		Joins("left join circles on circles.id = notifications.circle_id").
-		Where("notifications.is_sent = ? AND notifications.scheduled_for < ? AND notifications.scheduled_for > ?", false, end, start).
+		Where("notifications.is_sent = ? AND notifications.is_failed = ? AND notifications.scheduled_for < ? AND notifications.scheduled_for > ?", false, false, end, start).
+		Where("notifications.next_attempt_at IS NULL OR notifications.next_attempt_at <= ?", end).
 		Find(&notifications).Error; err != nil {
 		return nil, err
 	}
 	return notifications, nil
 }
 
+// DeleteSentNotifications removes delivered and failed notifications scheduled before since
 func (r *NotificationRepository) DeleteSentNotifications(c context.Context, since time.Time) error {
-	return r.db.WithContext(c).Where("is_sent = ? AND scheduled_for < ?", true, since).Delete(&nModel.Notification{}).Error
+	return dbtx.DB(c, r.db).Where("(is_sent = ? OR is_failed = ?) AND scheduled_for < ?", true, true, since).Delete(&nModel.Notification{}).Error
+}
+
+// UpdateDeliveryAttempt stores the outcome of a failed delivery attempt
+func (r *NotificationRepository) UpdateDeliveryAttempt(c context.Context, notification *nModel.Notification) error {
+	return dbtx.DB(c, r.db).Model(&nModel.Notification{}).Where("id = ?", notification.ID).Updates(map[string]interface{}{
+		"attempts":        notification.Attempts,
+		"next_attempt_at": notification.NextAttemptAt,
+		"last_error":      notification.LastError,
+		"is_failed":       notification.IsFailed,
+	}).Error
+}
+
+// DeferNotification moves a notification held by quiet hours to the time they end
+func (r *NotificationRepository) DeferNotification(c context.Context, notificationID int, until time.Time) error {
+	return dbtx.DB(c, r.db).Model(&nModel.Notification{}).Where("id = ?", notificationID).Update("scheduled_for", until).Error
+}
+
+func (r *NotificationRepository) GetFailedNotifications(c context.Context, circleID int) ([]*nModel.Notification, error) {
+	var notifications []*nModel.Notification
+	if err := dbtx.DB(c, r.db).Where("circle_id = ? AND is_failed = ?", circleID, true).Order("scheduled_for desc").Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// RequeueFailedNotifications resets the delivery state of failed notifications so they are sent on the next run
+func (r *NotificationRepository) RequeueFailedNotifications(c context.Context, circleID int, ids []int) (int64, error) {
+	result := dbtx.DB(c, r.db).Model(&nModel.Notification{}).
+		Where("circle_id = ? AND is_failed = ? AND id IN (?)", circleID, true, ids).
+		Updates(map[string]interface{}{
+			"is_failed":       false,
+			"attempts":        0,
+			"next_attempt_at": nil,
+			"last_error":      nil,
+			"scheduled_for":   time.Now().UTC(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// GetVAPIDKey returns the stored web push key pair, nil when none was generated yet
+func (r *NotificationRepository) GetVAPIDKey(c context.Context) (*nModel.VAPIDKey, error) {
+	var keys []*nModel.VAPIDKey
+	if err := dbtx.DB(c, r.db).Order("id asc").Limit(1).Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return keys[0], nil
+}
+
+func (r *NotificationRepository) CreateVAPIDKey(c context.Context, key *nModel.VAPIDKey) error {
+	return dbtx.DB(c, r.db).Create(key).Error
+}
+
+func (r *NotificationRepository) GetMessageTemplates(c context.Context, circleID int) ([]*nModel.MessageTemplate, error) {
+	var templates []*nModel.MessageTemplate
+	if err := dbtx.DB(c, r.db).Where("circle_id = ?", circleID).Order("event_type asc, locale asc").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetMessageTemplate returns the circle override for the event type and locale, nil when there is none
+func (r *NotificationRepository) GetMessageTemplate(c context.Context, circleID int, eventType nModel.EventType, locale string) (*nModel.MessageTemplate, error) {
+	var templates []*nModel.MessageTemplate
+	if err := dbtx.DB(c, r.db).Where("circle_id = ? AND event_type = ? AND locale = ?", circleID, eventType, locale).Limit(1).Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	return templates[0], nil
+}
+
+// SaveMessageTemplate creates the override or replaces the body of the existing one for the same event type and locale
+func (r *NotificationRepository) SaveMessageTemplate(c context.Context, template *nModel.MessageTemplate) error {
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
+		var existing nModel.MessageTemplate
+		err := tx.Where("circle_id = ? AND event_type = ? AND locale = ?", template.CircleID, template.EventType, template.Locale).First(&existing).Error
+		if err == nil {
+			template.ID = existing.ID
+			template.CreatedAt = existing.CreatedAt
+			return tx.Model(&existing).Updates(map[string]interface{}{
+				"body":       template.Body,
+				"updated_by": template.UpdatedBy,
+				"updated_at": template.UpdatedAt,
+			}).Error
+		}
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		return tx.Create(template).Error
+	})
+}
+
+func (r *NotificationRepository) DeleteMessageTemplate(c context.Context, circleID int, templateID int) (int64, error) {
+	result := dbtx.DB(c, r.db).Where("id = ? AND circle_id = ?", templateID, circleID).Delete(&nModel.MessageTemplate{})
+	return result.RowsAffected, result.Error
+}
+
+// AddInboxItem stores the item and reports whether it was added, false when the user already has an
+// item with the same key
+func (r *NotificationRepository) AddInboxItem(c context.Context, item *nModel.InboxItem) (bool, error) {
+	result := dbtx.DB(c, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(item)
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetInboxItems returns a page of the user's inbox, newest first, without the dismissed items
+func (r *NotificationRepository) GetInboxItems(c context.Context, userID int, unreadOnly bool, limit int, offset int) ([]*nModel.InboxItem, error) {
+	var items []*nModel.InboxItem
+	query := dbtx.DB(c, r.db).Where("user_id = ? AND dismissed_at IS NULL", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Order("created_at desc, id desc").Limit(limit).Offset(offset).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// CountInboxItems returns the number of items in the user's inbox and how many of them are unread
+func (r *NotificationRepository) CountInboxItems(c context.Context, userID int) (int64, int64, error) {
+	var total, unread int64
+	query := dbtx.DB(c, r.db).Model(&nModel.InboxItem{}).Where("user_id = ? AND dismissed_at IS NULL", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := query.Where("read_at IS NULL").Count(&unread).Error; err != nil {
+		return 0, 0, err
+	}
+	return total, unread, nil
+}
+
+// SetInboxItemRead marks one of the user's items as read or unread, it returns the number of items changed
+func (r *NotificationRepository) SetInboxItemRead(c context.Context, userID int, itemID int, readAt *time.Time) (int64, error) {
+	result := dbtx.DB(c, r.db).Model(&nModel.InboxItem{}).
+		Where("id = ? AND user_id = ? AND dismissed_at IS NULL", itemID, userID).
+		Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
+
+// MarkAllInboxItemsRead marks every unread item of the user as read
+func (r *NotificationRepository) MarkAllInboxItemsRead(c context.Context, userID int, readAt time.Time) (int64, error) {
+	result := dbtx.DB(c, r.db).Model(&nModel.InboxItem{}).
+		Where("user_id = ? AND read_at IS NULL AND dismissed_at IS NULL", userID).
+		Update("read_at", readAt)
+	return result.RowsAffected, result.Error
+}
+
+// DismissInboxItem hides one of the user's items from the inbox, it returns the number of items changed
+func (r *NotificationRepository) DismissInboxItem(c context.Context, userID int, itemID int, dismissedAt time.Time) (int64, error) {
+	result := dbtx.DB(c, r.db).Model(&nModel.InboxItem{}).
+		Where("id = ? AND user_id = ? AND dismissed_at IS NULL", itemID, userID).
+		Update("dismissed_at", dismissedAt)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteInboxItems removes the items created before before and the ones dismissed before dismissedBefore
+func (r *NotificationRepository) DeleteInboxItems(c context.Context, before time.Time, dismissedBefore time.Time) error {
+	return dbtx.DB(c, r.db).Where("created_at < ? OR dismissed_at < ?", before, dismissedBefore).Delete(&nModel.InboxItem{}).Error
 }
@@ -0,0 +1,78 @@
+package user
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	nModel "donetick.com/core/internal/notifier/model"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRepository(t *testing.T) *NotificationRepository {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "notifications.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&nModel.Notification{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewNotificationRepository(db)
+}
+
+func TestDeleteChoreRemindersKeepsFailedAndNaggingNotifications(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRepository(t)
+	now := time.Now().UTC()
+
+	notifications := []*nModel.Notification{
+		{ChoreID: 1, CircleID: 7, EventType: nModel.EventTypePreDue, Text: "pending pre-due"},
+		{ChoreID: 1, CircleID: 7, EventType: nModel.EventTypeDue, Text: "pending due"},
+		{ChoreID: 1, CircleID: 7, EventType: nModel.EventTypeOverdue, Text: "pending overdue"},
+		{ChoreID: 1, CircleID: 7, Text: "pending without an event type"},
+		{ChoreID: 1, CircleID: 7, EventType: nModel.EventTypeDue, Text: "failed due", IsFailed: true, Attempts: 5},
+		{ChoreID: 1, CircleID: 7, EventType: nModel.EventTypeDue, Text: "sent due", IsSent: true},
+		{ChoreID: 1, CircleID: 7, EventType: nModel.EventTypeNagging, Text: "sent nag", IsSent: true},
+		{ChoreID: 1, CircleID: 7, EventType: nModel.EventTypeNagging, Text: "pending nag"},
+		{ChoreID: 1, CircleID: 7, EventType: nModel.EventTypeEscalation, Text: "pending escalation"},
+		{ChoreID: 2, CircleID: 7, EventType: nModel.EventTypeDue, Text: "other chore"},
+	}
+	for _, notification := range notifications {
+		notification.ScheduledFor = now
+		notification.CreatedAt = now
+	}
+	if err := repo.BatchInsertNotifications(notifications); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.DeleteChoreReminders(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	var kept []*nModel.Notification
+	if err := repo.db.Order("id").Find(&kept).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"failed due", "sent due", "sent nag", "pending nag", "pending escalation", "other chore"}
+	if len(kept) != len(want) {
+		t.Fatalf("kept %d notifications, want %d", len(kept), len(want))
+	}
+	for i, notification := range kept {
+		if notification.Text != want[i] {
+			t.Errorf("kept notification %d = %q, want %q", i, notification.Text, want[i])
+		}
+	}
+
+	failed, err := repo.GetFailedNotifications(ctx, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0].Attempts != 5 {
+		t.Errorf("failed notifications after a replan = %v, want the failed due reminder with its attempts", failed)
+	}
+}
@@ -2,14 +2,21 @@ package notifier
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
 	"donetick.com/core/config"
 	chRepo "donetick.com/core/internal/chore/repo"
+	cRepo "donetick.com/core/internal/circle/repo"
 	"donetick.com/core/internal/events"
+	nModel "donetick.com/core/internal/notifier/model"
 	nRepo "donetick.com/core/internal/notifier/repo"
+	nps "donetick.com/core/internal/notifier/service"
+	uModel "donetick.com/core/internal/user/model"
 	uRepo "donetick.com/core/internal/user/repo"
+	"donetick.com/core/internal/utils"
 	"donetick.com/core/logging"
 )
 
@@ -17,26 +24,44 @@ type keyType string
 
 const (
 	SchedulerKey keyType = "scheduler"
+
+	defaultOverdueJobInterval = 3 * time.Hour
+	defaultNaggingInterval    = 24 * time.Hour
+	defaultNaggingCutoff      = 7 * 24 * time.Hour
+	defaultDigestJobInterval  = 15 * time.Minute
+
+	inboxRetention          = 90 * 24 * time.Hour
+	dismissedInboxRetention = 30 * 24 * time.Hour
+
+	maxDeliveryAttempts = 5
+	baseRetryDelay      = 5 * time.Minute
+	maxRetryDelay       = time.Hour
 )
 
 type Scheduler struct {
 	choreRepo        *chRepo.ChoreRepository
+	circleRepo       *cRepo.CircleRepository
 	userRepo         *uRepo.UserRepository
 	stopChan         chan bool
 	notifier         *Notifier
 	eventsProducer   *events.EventsProducer
 	notificationRepo *nRepo.NotificationRepository
+	planner          *nps.NotificationPlanner
+	inbox            *nps.Inbox
 	SchedulerJobs    config.SchedulerConfig
 }
 
-func NewScheduler(cfg *config.Config, ur *uRepo.UserRepository, cr *chRepo.ChoreRepository, n *Notifier, nr *nRepo.NotificationRepository, ep *events.EventsProducer) *Scheduler {
+func NewScheduler(cfg *config.Config, ur *uRepo.UserRepository, cr *chRepo.ChoreRepository, circleRepo *cRepo.CircleRepository, n *Notifier, nr *nRepo.NotificationRepository, ep *events.EventsProducer, np *nps.NotificationPlanner, inbox *nps.Inbox) *Scheduler {
 	return &Scheduler{
 		choreRepo:        cr,
+		circleRepo:       circleRepo,
 		userRepo:         ur,
 		stopChan:         make(chan bool),
 		notifier:         n,
 		notificationRepo: nr,
 		eventsProducer:   ep,
+		planner:          np,
+		inbox:            inbox,
 		SchedulerJobs:    cfg.SchedulerJobs,
 	}
 }
@@ -46,6 +71,49 @@ func (s *Scheduler) Start(c context.Context) {
 	log.Debug("Scheduler started")
 	go s.runScheduler(c, " NOTIFICATION_SCHEDULER ", s.loadAndSendNotificationJob, 3*time.Minute)
 	go s.runScheduler(c, " NOTIFICATION_CLEANUP ", s.cleanupSentNotifications, 24*time.Hour*30)
+	go s.runScheduler(c, " OVERDUE_NAGGING ", s.generateOverdueNaggingJob, durationOrDefault(s.SchedulerJobs.OverdueJob, defaultOverdueJobInterval))
+	go s.runScheduler(c, " DIGEST ", s.generateDigestJob, durationOrDefault(s.SchedulerJobs.DigestJob, defaultDigestJobInterval))
+}
+
+// generateOverdueNaggingJob queues a reminder for every overdue chore with nagging enabled. the queued
+// notifications are persisted before delivery, so a restart never re-nags a chore inside its interval.
+func (s *Scheduler) generateOverdueNaggingJob(c context.Context) (time.Duration, error) {
+	log := logging.FromContext(c)
+	startTime := time.Now()
+	interval := durationOrDefault(s.SchedulerJobs.NaggingInterval, defaultNaggingInterval)
+	cutoff := durationOrDefault(s.SchedulerJobs.NaggingCutoff, defaultNaggingCutoff)
+
+	chores, err := s.choreRepo.GetOverdueChoresForNotification(c, interval, interval, cutoff)
+	if err != nil {
+		log.Error("Error getting overdue chores", err)
+		return time.Since(startTime), err
+	}
+	log.Debug("Getting overdue chores for nagging", " count ", len(chores))
+
+	notifications := make([]*nModel.Notification, 0, len(chores))
+	for _, chore := range chores {
+		choreNotifications, err := s.planner.GenerateNaggingNotifications(c, chore)
+		if err != nil {
+			log.Errorw("Error generating nagging notifications", "chore_id", chore.ID, "error", err)
+			continue
+		}
+		notifications = append(notifications, choreNotifications...)
+	}
+	if len(notifications) == 0 {
+		return time.Since(startTime), nil
+	}
+	if err := s.notificationRepo.BatchInsertNotifications(notifications); err != nil {
+		log.Error("Error inserting nagging notifications", err)
+		return time.Since(startTime), err
+	}
+	return time.Since(startTime), nil
+}
+
+func durationOrDefault(d time.Duration, fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return d
 }
 func (s *Scheduler) cleanupSentNotifications(c context.Context) (time.Duration, error) {
 	log := logging.FromContext(c)
@@ -55,6 +123,12 @@ func (s *Scheduler) cleanupSentNotifications(c context.Context) (time.Duration,
 		log.Error("Error deleting sent notifications", err)
 		return time.Duration(0), err
 	}
+	// the inbox keeps its own history, dismissed items go sooner
+	now := time.Now().UTC()
+	if err := s.notificationRepo.DeleteInboxItems(c, now.Add(-inboxRetention), now.Add(-dismissedInboxRetention)); err != nil {
+		log.Error("Error deleting old inbox items", err)
+		return time.Duration(0), err
+	}
 	return time.Duration(0), nil
 }
 
@@ -69,23 +143,83 @@ func (s *Scheduler) loadAndSendNotificationJob(c context.Context) (time.Duration
 		return time.Since(startTime), err
 	}
 
+	sent := make([]*nModel.NotificationDetails, 0, len(getAllPendingNotifications))
+	// a reminder fanned out to several targets shares one circle webhook event
+	publishedEvents := make(map[string]bool)
+	preferences := newDeliveryPreferences(s.userRepo)
 	for _, notification := range getAllPendingNotifications {
-		err := s.notifier.SendNotification(c, notification)
-		if err != nil {
-			log.Error("Error sending notification", err)
+		skip, deferred := s.checkPreferences(c, preferences, notification)
+		if deferred {
 			continue
 		}
-		if notification.RawEvent != nil && notification.WebhookURL != nil {
-			// if we have a webhook url, we should send the event to the webhook
-			s.eventsProducer.NotificationEvent(c, *notification.WebhookURL, notification.RawEvent)
+		// skipped notifications are not delivered to the user, the circle webhook below still gets the event
+		if !skip {
+			if err := s.notifier.SendNotification(c, notification); err != nil {
+				recordFailedAttempt(&notification.Notification, err, time.Now().UTC())
+				if notification.IsFailed {
+					log.Errorw("Giving up on notification", "notification_id", notification.ID, "attempts", notification.Attempts, "error", err)
+					// the app still shows what could not be delivered
+					s.addToInbox(c, notification)
+				} else {
+					log.Warnw("Error sending notification, will retry", "notification_id", notification.ID, "attempts", notification.Attempts, "next_attempt_at", notification.NextAttemptAt, "error", err)
+				}
+				if err := s.notificationRepo.UpdateDeliveryAttempt(c, &notification.Notification); err != nil {
+					log.Error("Error saving notification delivery attempt", err)
+				}
+				continue
+			}
+		}
+		if notification.RawEvent != nil && !publishedEvents[webhookEventKey(notification)] {
+			publishedEvents[webhookEventKey(notification)] = true
+			// the producer sends the event to the circle webhook url and the matching subscriptions
+			var err error
+			switch notification.EventType {
+			case nModel.EventTypeNagging:
+				err = s.eventsProducer.ChoreOverdue(c, notification.CircleID, notification.ChoreID, notification.WebhookURL, notification.RawEvent)
+			case nModel.EventTypeCompletion:
+				// already published as task.completed when the chore was completed
+			case nModel.EventTypeEscalation:
+				// published as task.escalated by the escalation job
+			case nModel.EventTypeDigest:
+				// digests are personal summaries, not circle events
+			default:
+				err = s.eventsProducer.NotificationEvent(c, notification.CircleID, notification.ChoreID, notification.WebhookURL, notification.RawEvent)
+			}
+			if err != nil {
+				log.Errorw("Error publishing notification event", "notification_id", notification.ID, "chore_id", notification.ChoreID, "error", err)
+			}
 		}
 
+		s.addToInbox(c, notification)
 		notification.IsSent = true
+		sent = append(sent, notification)
 	}
 
-	s.notificationRepo.MarkNotificationsAsSent(getAllPendingNotifications)
+	if len(sent) > 0 {
+		if err := s.notificationRepo.MarkNotificationsAsSent(sent); err != nil {
+			log.Error("Error marking notifications as sent", err)
+			return time.Since(startTime), err
+		}
+	}
 	return time.Since(startTime), nil
 }
+
+// recordFailedAttempt bumps the attempt count and either schedules the next retry or marks the
+// notification as failed when the platform can't deliver it or the attempts are exhausted.
+func recordFailedAttempt(notification *nModel.Notification, sendErr error, now time.Time) {
+	notification.Attempts++
+	lastError := sendErr.Error()
+	notification.LastError = &lastError
+
+	if errors.Is(sendErr, ErrPlatformNotConfigured) || notification.Attempts >= maxDeliveryAttempts {
+		notification.IsFailed = true
+		notification.NextAttemptAt = nil
+		return
+	}
+	nextAttemptAt := now.Add(utils.RetryBackoff(notification.Attempts, baseRetryDelay, maxRetryDelay))
+	notification.NextAttemptAt = &nextAttemptAt
+}
+
 func (s *Scheduler) runScheduler(c context.Context, jobName string, job func(c context.Context) (time.Duration, error), interval time.Duration) {
 
 	for {
@@ -109,3 +243,48 @@ func (s *Scheduler) runScheduler(c context.Context, jobName string, job func(c c
 func (s *Scheduler) Stop() {
 	s.stopChan <- true
 }
+
+// checkPreferences applies the user's digest and quiet hours settings. skip means the notification is
+// not delivered to the user, deferred that it was moved to the end of the quiet hours instead
+func (s *Scheduler) checkPreferences(c context.Context, preferences *deliveryPreferences, notification *nModel.NotificationDetails) (bool, bool) {
+	log := logging.FromContext(c)
+	suppressed, err := preferences.suppressedByDigest(c, &notification.Notification)
+	if err != nil {
+		log.Errorw("Error checking digest settings", "user_id", notification.UserID, "error", err)
+	}
+	if suppressed {
+		log.Debugw("Skipping reminder covered by the user's digest", "notification_id", notification.ID)
+		return true, false
+	}
+
+	until, policy, muted, err := preferences.quietHours(c, &notification.Notification, time.Now().UTC())
+	if err != nil {
+		log.Errorw("Error checking quiet hours", "user_id", notification.UserID, "error", err)
+	}
+	if !muted {
+		return false, false
+	}
+	if policy == uModel.QuietHoursPolicyDrop {
+		log.Debugw("Dropping notification during quiet hours", "notification_id", notification.ID)
+		return true, false
+	}
+	log.Debugw("Deferring notification during quiet hours", "notification_id", notification.ID, "until", until)
+	if err := s.notificationRepo.DeferNotification(c, notification.ID, until); err != nil {
+		log.Error("Error deferring notification", err)
+	}
+	return true, true
+}
+
+// addToInbox records the reminders a user was sent, completions and escalations are added when they
+// are planned and digests only summarize what is already there
+func (s *Scheduler) addToInbox(c context.Context, notification *nModel.NotificationDetails) {
+	switch notification.EventType {
+	case nModel.EventTypeCompletion, nModel.EventTypeEscalation, nModel.EventTypeDigest:
+		return
+	}
+	s.inbox.AddNotification(c, &notification.Notification)
+}
+
+func webhookEventKey(notification *nModel.NotificationDetails) string {
+	return fmt.Sprintf("%d/%s/%d", notification.ChoreID, notification.EventType, notification.ScheduledFor.Unix())
+}
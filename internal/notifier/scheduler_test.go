@@ -0,0 +1,66 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	nModel "donetick.com/core/internal/notifier/model"
+	"donetick.com/core/internal/utils"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 5 * time.Minute},
+		{attempts: 2, want: 10 * time.Minute},
+		{attempts: 3, want: 20 * time.Minute},
+		{attempts: 4, want: 40 * time.Minute},
+		{attempts: 5, want: time.Hour},
+		{attempts: 10, want: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempts), func(t *testing.T) {
+			if got := utils.RetryBackoff(tt.attempts, baseRetryDelay, maxRetryDelay); got != tt.want {
+				t.Errorf("retryBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordFailedAttempt(t *testing.T) {
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	t.Run("schedules retry", func(t *testing.T) {
+		notification := &nModel.Notification{}
+		recordFailedAttempt(notification, errors.New("timeout"), now)
+		if notification.Attempts != 1 || notification.IsFailed {
+			t.Fatalf("expected a retry after the first attempt, got attempts=%d failed=%v", notification.Attempts, notification.IsFailed)
+		}
+		if notification.NextAttemptAt == nil || !notification.NextAttemptAt.Equal(now.Add(baseRetryDelay)) {
+			t.Errorf("unexpected next attempt: %v", notification.NextAttemptAt)
+		}
+		if notification.LastError == nil || *notification.LastError != "timeout" {
+			t.Errorf("expected last error to be recorded, got %v", notification.LastError)
+		}
+	})
+
+	t.Run("fails after max attempts", func(t *testing.T) {
+		notification := &nModel.Notification{Attempts: maxDeliveryAttempts - 1}
+		recordFailedAttempt(notification, errors.New("timeout"), now)
+		if !notification.IsFailed || notification.NextAttemptAt != nil {
+			t.Errorf("expected notification to be failed, got failed=%v next=%v", notification.IsFailed, notification.NextAttemptAt)
+		}
+	})
+
+	t.Run("fails immediately when platform is not configured", func(t *testing.T) {
+		notification := &nModel.Notification{}
+		recordFailedAttempt(notification, fmt.Errorf("telegram: %w", ErrPlatformNotConfigured), now)
+		if !notification.IsFailed || notification.Attempts != 1 {
+			t.Errorf("expected notification to be failed on first attempt, got failed=%v attempts=%d", notification.IsFailed, notification.Attempts)
+		}
+	})
+}
@@ -5,35 +5,52 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"donetick.com/core/config"
-	chModel "donetick.com/core/internal/chore/model"
 	nModel "donetick.com/core/internal/notifier/model"
-	uModel "donetick.com/core/internal/user/model"
 	"donetick.com/core/logging"
 )
 
-type DiscordNotifier struct {
-}
+type DiscordNotifier struct{}
 
 func NewDiscordNotifier(config *config.Config) *DiscordNotifier {
 	return &DiscordNotifier{}
 }
 
-func (dn *DiscordNotifier) SendChoreCompletion(c context.Context, chore *chModel.Chore, user *uModel.User) {
-	log := logging.FromContext(c)
-	if dn == nil {
-		log.Error("Discord notifier is not initialized, skipping message sending")
-		return
-	}
+func (dn *DiscordNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformDiscord
+}
 
-	message := fmt.Sprintf("🎉 **%s** is completed! Great job, %s! 🌟", chore.Name, user.DisplayName)
-	err := dn.sendMessage(c, user.UserNotificationTargets.TargetID, message)
-	if err != nil {
-		log.Error("Error sending Discord message:", err)
+func (dn *DiscordNotifier) Name() string {
+	return "discord"
+}
+
+func (dn *DiscordNotifier) Capabilities() nModel.ProviderCapabilities {
+	return nModel.ProviderCapabilities{Markdown: true}
+}
+
+// IsConfigured is always true, discord targets carry their own webhook URL
+func (dn *DiscordNotifier) IsConfigured() bool {
+	return dn != nil
+}
+
+// ValidateTarget checks the target is a discord webhook URL
+func (dn *DiscordNotifier) ValidateTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("discord target must be an https webhook URL")
 	}
+	if !strings.HasPrefix(u.Path, "/api/webhooks/") {
+		return errors.New("discord target must be a webhook URL")
+	}
+	return nil
+}
+
+func (dn *DiscordNotifier) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	return dn.SendNotification(c, notification)
 }
 
 func (dn *DiscordNotifier) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
@@ -0,0 +1,180 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"net/mail"
+	texttemplate "text/template"
+	"time"
+
+	esender "donetick.com/core/internal/email"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+type EmailNotifier struct {
+	sender *esender.EmailSender
+}
+
+func NewEmailNotifier(sender *esender.EmailSender) *EmailNotifier {
+	return &EmailNotifier{
+		sender: sender,
+	}
+}
+
+func (en *EmailNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformEmail
+}
+
+func (en *EmailNotifier) Name() string {
+	return "email"
+}
+
+func (en *EmailNotifier) Capabilities() nModel.ProviderCapabilities {
+	return nModel.ProviderCapabilities{}
+}
+
+func (en *EmailNotifier) IsConfigured() bool {
+	return en != nil && en.sender.IsConfigured()
+}
+
+// ValidateTarget checks the target is a bare email address
+func (en *EmailNotifier) ValidateTarget(target string) error {
+	address, err := mail.ParseAddress(target)
+	if err != nil || address.Address != target {
+		return fmt.Errorf("invalid email address %q", target)
+	}
+	return nil
+}
+
+func (en *EmailNotifier) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	if notification.TargetID == "" {
+		return errors.New("unable to send notification, targetID is empty")
+	}
+	message, err := renderMessage(notification, en.sender.AppHost())
+	if err != nil {
+		return err
+	}
+	return en.sender.SendNotificationEmail(c, notification.TargetID, message.Subject, message.Text, message.HTML)
+}
+
+type emailMessage struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// emailContent is what the templates see, built from the notification and its raw event
+type emailContent struct {
+	Headline string
+	Message  string
+	ChoreURL string
+	DueDate  string
+}
+
+var textTemplate = texttemplate.Must(texttemplate.New("text").Parse(`{{.Headline}}
+
+{{.Message}}
+{{if .DueDate}}
+Due: {{.DueDate}}
+{{end}}{{if .ChoreURL}}
+Open the chore: {{.ChoreURL}}
+{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html lang="en"><head><meta content="text/html; charset=utf-8" http-equiv="Content-Type"><title>{{.Headline}}</title></head>
+<body style="margin:0;padding:24px;background-color:#f4f4f7;font-family:Helvetica,Arial,sans-serif;color:#1f2937;">
+<table role="presentation" width="100%" cellpadding="0" cellspacing="0" style="max-width:560px;margin:0 auto;background-color:#ffffff;border-radius:8px;">
+<tr><td style="padding:24px;">
+<h2 style="margin:0 0 16px 0;font-size:20px;">{{.Headline}}</h2>
+<p style="margin:0 0 16px 0;font-size:15px;line-height:22px;white-space:pre-line;">{{.Message}}</p>
+{{if .DueDate}}<p style="margin:0 0 16px 0;font-size:14px;color:#6b7280;">Due: {{.DueDate}}</p>{{end}}
+{{if .ChoreURL}}<a href="{{.ChoreURL}}" style="display:inline-block;padding:10px 18px;background-color:#06b6d4;color:#ffffff;text-decoration:none;border-radius:6px;font-size:14px;">Open chore</a>{{end}}
+</td></tr></table>
+</body></html>
+`))
+
+func renderMessage(notification *nModel.NotificationDetails, appHost string) (*emailMessage, error) {
+	name := eventString(notification.RawEvent, "name")
+	if name == "" {
+		name = "your chore"
+	}
+
+	var subject, headline string
+	switch notification.EventType {
+	case nModel.EventTypePreDue:
+		subject = fmt.Sprintf("Upcoming: %s is due soon", name)
+		headline = "📅 Coming up soon"
+	case nModel.EventTypeDue:
+		subject = fmt.Sprintf("Reminder: %s is due", name)
+		headline = "📅 Chore reminder"
+	case nModel.EventTypeOverdue, nModel.EventTypeNagging:
+		subject = fmt.Sprintf("Overdue: %s", name)
+		headline = "⏰ Chore overdue"
+	case nModel.EventTypeEscalation:
+		subject = fmt.Sprintf("Escalated: %s", name)
+		headline = "🚨 Overdue chore escalated"
+	case nModel.EventTypeCompletion:
+		subject = fmt.Sprintf("Completed: %s", name)
+		headline = "🎉 Chore completed"
+	case nModel.EventTypeDigest:
+		frequency := eventString(notification.RawEvent, "frequency")
+		subject = fmt.Sprintf("Your %s Donetick digest", frequency)
+		headline = "Your chores at a glance"
+	default:
+		subject = fmt.Sprintf("Donetick: %s", name)
+		headline = "Donetick notification"
+	}
+
+	content := emailContent{
+		Headline: headline,
+		// the notification text is written for chat apps, drop the markdown emphasis
+		Message:  nModel.StripEmphasis(notification.Text),
+		DueDate:  formatDueDate(notification.RawEvent["due_date"]),
+		ChoreURL: notification.ChoreURL(appHost),
+	}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, content); err != nil {
+		return nil, err
+	}
+	if err := htmlTemplate.Execute(&html, content); err != nil {
+		return nil, err
+	}
+	return &emailMessage{Subject: subject, Text: text.String(), HTML: html.String()}, nil
+}
+
+func eventString(event nModel.JSONB, key string) string {
+	if event == nil {
+		return ""
+	}
+	if value, ok := event[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// formatDueDate handles both a time value (fresh notification) and its JSON string form (loaded from the database)
+func formatDueDate(value interface{}) string {
+	var dueDate time.Time
+	switch v := value.(type) {
+	case time.Time:
+		dueDate = v
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		dueDate = *v
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return ""
+		}
+		dueDate = parsed
+	default:
+		return ""
+	}
+	return dueDate.UTC().Format("Mon, Jan 2 2006 15:04 UTC")
+}
@@ -0,0 +1,194 @@
+package email
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	esender "donetick.com/core/internal/email"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+// smtpStandIn is a minimal SMTP server that accepts a single message per connection
+type smtpStandIn struct {
+	listener net.Listener
+	messages chan string
+}
+
+func newSMTPStandIn(t *testing.T) *smtpStandIn {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error starting smtp stand-in: %v", err)
+	}
+	s := &smtpStandIn{listener: listener, messages: make(chan string, 10)}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *smtpStandIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *smtpStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ESMTP stand-in")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "DATA"):
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.messages <- data.String()
+			reply("250 OK")
+		case strings.HasPrefix(command, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpStandIn) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func newTestNotifier(t *testing.T, server *smtpStandIn) *EmailNotifier {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.EmailConfig.Host = "127.0.0.1"
+	cfg.EmailConfig.Port = server.port()
+	cfg.EmailConfig.Email = "donetick@example.com"
+	cfg.EmailConfig.AppHost = "https://app.example.com"
+	return NewEmailNotifier(esender.NewEmailSender(cfg))
+}
+
+func TestEmailNotifierSend(t *testing.T) {
+	dueDate := time.Date(2025, 1, 2, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		eventType    nModel.EventType
+		wantSubject  string
+		wantContains []string
+	}{
+		{
+			name:         "reminder",
+			eventType:    nModel.EventTypeDue,
+			wantSubject:  "Reminder: Water plants is due",
+			wantContains: []string{"Due: Thu, Jan 2 2025 09:30 UTC", "https://app.example.com/chores/42"},
+		},
+		{
+			name:         "overdue",
+			eventType:    nModel.EventTypeNagging,
+			wantSubject:  "Overdue: Water plants",
+			wantContains: []string{"Chore overdue", "https://app.example.com/chores/42"},
+		},
+		{
+			name:         "completion",
+			eventType:    nModel.EventTypeCompletion,
+			wantSubject:  "Completed: Water plants",
+			wantContains: []string{"Chore completed"},
+		},
+	}
+
+	server := newSMTPStandIn(t)
+	notifier := newTestNotifier(t, server)
+	if !notifier.IsConfigured() {
+		t.Fatal("expected notifier to be configured")
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := notifier.Send(context.Background(), &nModel.NotificationDetails{
+				Notification: nModel.Notification{
+					ChoreID:   42,
+					TargetID:  "user@example.com",
+					TypeID:    nModel.NotificationPlatformEmail,
+					EventType: tt.eventType,
+					Text:      "📅 Reminder: *Water plants* is due today and assigned to Sam.",
+					RawEvent: nModel.JSONB{
+						"name":     "Water plants",
+						"due_date": dueDate.Format(time.RFC3339),
+					},
+				},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error sending email: %v", err)
+			}
+
+			var message string
+			select {
+			case message = <-server.messages:
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for email")
+			}
+
+			if !strings.Contains(message, "Subject: "+tt.wantSubject) {
+				t.Errorf("expected subject %q in message:\n%s", tt.wantSubject, message)
+			}
+			if !strings.Contains(message, "To: user@example.com") {
+				t.Errorf("expected recipient in message:\n%s", message)
+			}
+			if !strings.Contains(message, "text/plain") || !strings.Contains(message, "text/html") {
+				t.Errorf("expected a text and an html part in message:\n%s", message)
+			}
+			if strings.Contains(message, "*Water plants*") {
+				t.Errorf("expected markdown to be stripped from message:\n%s", message)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(message, want) {
+					t.Errorf("expected %q in message:\n%s", want, message)
+				}
+			}
+		})
+	}
+}
+
+func TestEmailNotifierValidateTarget(t *testing.T) {
+	notifier := NewEmailNotifier(esender.NewEmailSender(&config.Config{}))
+	tests := map[string]bool{
+		"user@example.com":         true,
+		"user+chores@example.com":  true,
+		"Sam <user@example.com>":   false,
+		"not-an-email":             false,
+		"":                         false,
+		strconv.Itoa(123456789012): false,
+	}
+	for target, valid := range tests {
+		if err := notifier.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) error = %v, want valid %v", target, err, valid)
+		}
+	}
+}
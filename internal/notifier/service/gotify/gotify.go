@@ -0,0 +1,137 @@
+package gotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+// GotifyNotifier posts messages to a Gotify server. the target is the server URL with
+// the application token as the token query parameter:
+//
+//	https://gotify.example.com?token=AbCdEf
+type GotifyNotifier struct {
+	client  *http.Client
+	appHost string
+}
+
+type message struct {
+	Title    string                 `json:"title"`
+	Message  string                 `json:"message"`
+	Priority *int                   `json:"priority,omitempty"`
+	Extras   map[string]interface{} `json:"extras,omitempty"`
+}
+
+func NewGotifyNotifier(cfg *config.Config) *GotifyNotifier {
+	return &GotifyNotifier{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		appHost: cfg.EmailConfig.AppHost,
+	}
+}
+
+func (g *GotifyNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformGotify
+}
+
+func (g *GotifyNotifier) Name() string {
+	return "gotify"
+}
+
+func (g *GotifyNotifier) Capabilities() nModel.ProviderCapabilities {
+	return nModel.ProviderCapabilities{Markdown: true, Priority: true}
+}
+
+// IsConfigured is always true, gotify targets carry their own server
+func (g *GotifyNotifier) IsConfigured() bool {
+	return g != nil
+}
+
+func (g *GotifyNotifier) ValidateTarget(target string) error {
+	_, _, err := parseTarget(target)
+	return err
+}
+
+func (g *GotifyNotifier) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	messageURL, token, err := parseTarget(notification.TargetID)
+	if err != nil {
+		return err
+	}
+
+	payload := message{
+		Title:   "Donetick",
+		Message: notification.Text,
+		Extras: map[string]interface{}{
+			"client::display": map[string]string{"contentType": "text/markdown"},
+		},
+	}
+	if priority, ok := mapPriority(notification.Priority()); ok {
+		payload.Priority = &priority
+	}
+	if choreURL := notification.ChoreURL(g.appHost); choreURL != "" {
+		payload.Extras["client::notification"] = map[string]interface{}{
+			"click": map[string]string{"url": choreURL},
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(c, http.MethodPost, messageURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", token)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("gotify returned unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// parseTarget returns the message endpoint of the server and the application token
+func parseTarget(target string) (string, string, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", errors.New("gotify target must be an http(s) server URL")
+	}
+	token := u.Query().Get("token")
+	if token == "" {
+		return "", "", errors.New("gotify target must include the application token, e.g. https://gotify.example.com?token=AbCdEf")
+	}
+	u.RawQuery = ""
+	u.Path = strings.TrimRight(u.Path, "/") + "/message"
+	return u.String(), token, nil
+}
+
+// mapPriority maps chore priority (1 highest to 4 lowest) onto gotify's 0-10 scale,
+// chores without priority keep the application default
+func mapPriority(priority int) (int, bool) {
+	switch priority {
+	case 1:
+		return 8, true
+	case 2:
+		return 6, true
+	case 3:
+		return 4, true
+	case 4:
+		return 2, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,96 @@
+package gotify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestGotifyNotifierSend(t *testing.T) {
+	var gotPath, gotKey string
+	var got message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.String()
+		gotKey = r.Header.Get("X-Gotify-Key")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("error decoding body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.EmailConfig.AppHost = "https://app.example.com/"
+	notifier := NewGotifyNotifier(cfg)
+
+	err := notifier.Send(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			ChoreID:  7,
+			TargetID: server.URL + "/gotify?token=AppToken",
+			TypeID:   nModel.NotificationPlatformGotify,
+			Text:     "📅 Reminder: *Dishes* is due today",
+			RawEvent: nModel.JSONB{"priority": 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotPath != "/gotify/message" {
+		t.Errorf("expected post to the message endpoint, got %q", gotPath)
+	}
+	if gotKey != "AppToken" {
+		t.Errorf("expected application token header, got %q", gotKey)
+	}
+	if got.Message != "📅 Reminder: *Dishes* is due today" {
+		t.Errorf("unexpected message %q", got.Message)
+	}
+	if got.Priority == nil || *got.Priority != 6 {
+		t.Errorf("expected priority 6, got %v", got.Priority)
+	}
+	click, _ := got.Extras["client::notification"].(map[string]interface{})["click"].(map[string]interface{})
+	if click["url"] != "https://app.example.com/chores/7" {
+		t.Errorf("expected deep link to the chore, got %v", got.Extras)
+	}
+}
+
+func TestGotifyNotifierSendWithoutPriority(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	notifier := NewGotifyNotifier(&config.Config{})
+	err := notifier.Send(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{TargetID: server.URL + "?token=AppToken", Text: "hello"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := body["priority"]; ok {
+		t.Errorf("expected no priority for chores without one, got %v", body["priority"])
+	}
+}
+
+func TestGotifyValidateTarget(t *testing.T) {
+	notifier := NewGotifyNotifier(&config.Config{})
+	tests := map[string]bool{
+		"https://gotify.example.com?token=abc":   true,
+		"http://10.0.0.2:8080/gotify/?token=abc": true,
+		"https://gotify.example.com":             false,
+		"gotify.example.com?token=abc":           false,
+		"mailto:someone@example.com?token=abc":   false,
+	}
+	for target, valid := range tests {
+		if err := notifier.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) error = %v, want valid %v", target, err, valid)
+		}
+	}
+}
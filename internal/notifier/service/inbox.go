@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	nModel "donetick.com/core/internal/notifier/model"
+	nRepo "donetick.com/core/internal/notifier/repo"
+	"donetick.com/core/internal/realtime"
+	"donetick.com/core/logging"
+)
+
+// Inbox keeps the in-app history of what users were notified about and pushes new items to the
+// apps they have open
+type Inbox struct {
+	nRepo           *nRepo.NotificationRepository
+	realTimeService *realtime.RealTimeService
+}
+
+func NewInbox(nr *nRepo.NotificationRepository, rts *realtime.RealTimeService) *Inbox {
+	return &Inbox{
+		nRepo:           nr,
+		realTimeService: rts,
+	}
+}
+
+// Add stores the item unless the user already has one with the same key, new items are sent over
+// the realtime service
+func (i *Inbox) Add(c context.Context, item *nModel.InboxItem) error {
+	if i == nil || item.UserID == 0 {
+		return nil
+	}
+	if item.CreatedAt.IsZero() {
+		item.CreatedAt = time.Now().UTC()
+	}
+	added, err := i.nRepo.AddInboxItem(c, item)
+	if err != nil {
+		return err
+	}
+	if added && i.realTimeService != nil {
+		i.realTimeService.GetEventBroadcaster().BroadcastNotification(item)
+	}
+	return nil
+}
+
+// AddNotification records a delivered notification, the copies of a reminder sent to the user's
+// other targets share its key
+func (i *Inbox) AddNotification(c context.Context, notification *nModel.Notification) {
+	item := &nModel.InboxItem{
+		UserID:    notification.UserID,
+		CircleID:  notification.CircleID,
+		ChoreID:   notification.ChoreID,
+		EventType: notification.EventType,
+		Key:       inboxKey(notification),
+		Text:      notification.Text,
+		Data:      notification.RawEvent,
+	}
+	if err := i.Add(c, item); err != nil {
+		logging.FromContext(c).Errorw("Error adding notification to inbox", "notification_id", notification.ID, "user_id", notification.UserID, "error", err)
+	}
+}
+
+// inboxKey identifies the reminder a notification is for, regardless of the target it was sent to
+func inboxKey(notification *nModel.Notification) string {
+	return fmt.Sprintf("%s:%d:%d", notification.EventType, notification.ChoreID, notification.ScheduledFor.Unix())
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestInboxKey(t *testing.T) {
+	scheduledFor := time.Date(2026, time.March, 10, 9, 0, 0, 0, time.UTC)
+	reminder := nModel.Notification{ChoreID: 5, UserID: 3, EventType: nModel.EventTypeDue, ScheduledFor: scheduledFor, TypeID: nModel.NotificationPlatformTelegram, TargetID: "42"}
+
+	otherTarget := reminder
+	otherTarget.TypeID = nModel.NotificationPlatformEmail
+	otherTarget.TargetID = "sam@example.com"
+	if inboxKey(&reminder) != inboxKey(&otherTarget) {
+		t.Errorf("copies of a reminder for different targets should share a key, got %q and %q", inboxKey(&reminder), inboxKey(&otherTarget))
+	}
+
+	nextDay := reminder
+	nextDay.ScheduledFor = scheduledFor.Add(24 * time.Hour)
+	nagging := reminder
+	nagging.EventType = nModel.EventTypeNagging
+	for _, other := range []nModel.Notification{nextDay, nagging} {
+		if inboxKey(&reminder) == inboxKey(&other) {
+			t.Errorf("different reminders should not share key %q", inboxKey(&reminder))
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package matrix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	"donetick.com/core/logging"
+)
+
+const (
+	maxRateLimitRetries = 3
+	// maxRetryAfter caps how long a single send waits on the homeserver, longer waits are left
+	// to the notification scheduler's backoff
+	maxRetryAfter     = 30 * time.Second
+	defaultRetryAfter = time.Second
+)
+
+var (
+	roomIDRegex = regexp.MustCompile(`^![^:\s]+:\S+$`)
+	boldRegex   = regexp.MustCompile(`\*\*?([^*]+?)\*\*?`)
+)
+
+type MatrixNotifier struct {
+	client      *http.Client
+	homeserver  string
+	accessToken string
+	// sleep waits for the retry-after duration, replaced in tests
+	sleep func(c context.Context, d time.Duration) error
+}
+
+type roomMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+type errorResponse struct {
+	ErrCode      string `json:"errcode"`
+	Error        string `json:"error"`
+	RetryAfterMs int64  `json:"retry_after_ms"`
+}
+
+func NewMatrixNotifier(cfg *config.Config) *MatrixNotifier {
+	return &MatrixNotifier{
+		client:      &http.Client{Timeout: 10 * time.Second},
+		homeserver:  strings.TrimRight(cfg.Matrix.Homeserver, "/"),
+		accessToken: cfg.Matrix.AccessToken,
+		sleep:       sleepContext,
+	}
+}
+
+func (m *MatrixNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformMatrix
+}
+
+func (m *MatrixNotifier) Name() string {
+	return "matrix"
+}
+
+func (m *MatrixNotifier) Capabilities() nModel.ProviderCapabilities {
+	return nModel.ProviderCapabilities{Markdown: true}
+}
+
+func (m *MatrixNotifier) IsConfigured() bool {
+	return m != nil && m.homeserver != "" && m.accessToken != ""
+}
+
+// ValidateTarget checks the target is a room ID such as !abcdef:example.org
+func (m *MatrixNotifier) ValidateTarget(target string) error {
+	if !roomIDRegex.MatchString(target) {
+		return fmt.Errorf("invalid matrix room id %q, expected !room:server", target)
+	}
+	return nil
+}
+
+func (m *MatrixNotifier) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	if err := m.ValidateTarget(notification.TargetID); err != nil {
+		return err
+	}
+	body, err := json.Marshal(roomMessage{
+		MsgType:       "m.text",
+		Body:          boldRegex.ReplaceAllString(notification.Text, "$1"),
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatHTML(notification.Text),
+	})
+	if err != nil {
+		return err
+	}
+
+	// reusing the transaction id across rate limited retries lets the homeserver drop duplicates
+	txnID := fmt.Sprintf("donetick-%d-%d", notification.ID, time.Now().UnixNano())
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		m.homeserver, url.PathEscape(notification.TargetID), url.PathEscape(txnID))
+
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := m.put(c, endpoint, body)
+		if err == nil {
+			return nil
+		}
+		if retryAfter == 0 || attempt >= maxRateLimitRetries {
+			return err
+		}
+		logging.FromContext(c).Debugw("Matrix rate limited, retrying", "retry_after", retryAfter, "attempt", attempt+1)
+		if err := m.sleep(c, retryAfter); err != nil {
+			return err
+		}
+	}
+}
+
+// put sends the message, a non-zero duration is returned when the homeserver rate limited the request
+func (m *MatrixNotifier) put(c context.Context, endpoint string, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(c, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.accessToken)
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return 0, nil
+	}
+
+	var matrixErr errorResponse
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	json.Unmarshal(respBody, &matrixErr)
+	err = fmt.Errorf("matrix returned unexpected status: %s %s", resp.Status, matrixErr.ErrCode)
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return 0, err
+	}
+	retryAfter := defaultRetryAfter
+	if matrixErr.RetryAfterMs > 0 {
+		retryAfter = time.Duration(matrixErr.RetryAfterMs) * time.Millisecond
+	} else if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+	if retryAfter > maxRetryAfter {
+		return 0, errors.Join(err, fmt.Errorf("retry after %s exceeds the maximum wait", retryAfter))
+	}
+	return retryAfter, err
+}
+
+// formatHTML escapes the text and turns the markdown bold used in notification texts into <strong>
+func formatHTML(text string) string {
+	escaped := html.EscapeString(text)
+	escaped = boldRegex.ReplaceAllString(escaped, "<strong>$1</strong>")
+	return strings.ReplaceAll(escaped, "\n", "<br>")
+}
+
+func sleepContext(c context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-c.Done():
+		return c.Err()
+	case <-timer.C:
+		return nil
+	}
+}
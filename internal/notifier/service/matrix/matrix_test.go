@@ -0,0 +1,172 @@
+package matrix
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func newTestNotifier(serverURL string, sleeps *[]time.Duration) *MatrixNotifier {
+	cfg := &config.Config{}
+	cfg.Matrix.Homeserver = serverURL
+	cfg.Matrix.AccessToken = "syt_token"
+	notifier := NewMatrixNotifier(cfg)
+	notifier.sleep = func(c context.Context, d time.Duration) error {
+		*sleeps = append(*sleeps, d)
+		return nil
+	}
+	return notifier
+}
+
+func testNotification() *nModel.NotificationDetails {
+	return &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			ID:       12,
+			TargetID: "!room:example.org",
+			TypeID:   nModel.NotificationPlatformMatrix,
+			Text:     "📅 Reminder: *Dishes & pans* is due today",
+		},
+	}
+}
+
+func TestMatrixNotifierSend(t *testing.T) {
+	var gotPath, gotAuth string
+	var got roomMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		gotAuth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.Write([]byte(`{"event_id":"$event"}`))
+	}))
+	defer server.Close()
+
+	var sleeps []time.Duration
+	notifier := newTestNotifier(server.URL, &sleeps)
+	if err := notifier.Send(context.Background(), testNotification()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(gotPath, "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/donetick-12-") {
+		t.Errorf("unexpected path %q", gotPath)
+	}
+	if gotAuth != "Bearer syt_token" {
+		t.Errorf("unexpected authorization %q", gotAuth)
+	}
+	if got.Body != "📅 Reminder: Dishes & pans is due today" {
+		t.Errorf("unexpected plain body %q", got.Body)
+	}
+	if got.Format != "org.matrix.custom.html" || got.FormattedBody != "📅 Reminder: <strong>Dishes &amp; pans</strong> is due today" {
+		t.Errorf("unexpected formatted body %q (%s)", got.FormattedBody, got.Format)
+	}
+}
+
+func TestMatrixNotifierRateLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		limitedFor  int
+		respond     func(w http.ResponseWriter)
+		wantErr     bool
+		wantSleeps  []time.Duration
+		wantTxnSame bool
+	}{
+		{
+			name:       "retry_after_ms in body",
+			limitedFor: 1,
+			respond: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"errcode":"M_LIMIT_EXCEEDED","retry_after_ms":1500}`))
+			},
+			wantSleeps:  []time.Duration{1500 * time.Millisecond},
+			wantTxnSame: true,
+		},
+		{
+			name:       "Retry-After header",
+			limitedFor: 2,
+			respond: func(w http.ResponseWriter) {
+				w.Header().Set("Retry-After", "2")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantSleeps:  []time.Duration{2 * time.Second, 2 * time.Second},
+			wantTxnSame: true,
+		},
+		{
+			name:       "gives up after max retries",
+			limitedFor: 10,
+			respond: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"errcode":"M_LIMIT_EXCEEDED","retry_after_ms":100}`))
+			},
+			wantErr:    true,
+			wantSleeps: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond, 100 * time.Millisecond},
+		},
+		{
+			name:       "retry after longer than the maximum wait",
+			limitedFor: 1,
+			respond: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"errcode":"M_LIMIT_EXCEEDED","retry_after_ms":600000}`))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				if len(paths) <= tt.limitedFor {
+					tt.respond(w)
+					return
+				}
+				w.Write([]byte(`{"event_id":"$event"}`))
+			}))
+			defer server.Close()
+
+			var sleeps []time.Duration
+			notifier := newTestNotifier(server.URL, &sleeps)
+			err := notifier.Send(context.Background(), testNotification())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(sleeps) != len(tt.wantSleeps) {
+				t.Fatalf("expected sleeps %v, got %v", tt.wantSleeps, sleeps)
+			}
+			for i := range sleeps {
+				if sleeps[i] != tt.wantSleeps[i] {
+					t.Errorf("sleep %d = %v, want %v", i, sleeps[i], tt.wantSleeps[i])
+				}
+			}
+			if tt.wantTxnSame {
+				for _, path := range paths {
+					if path != paths[0] {
+						t.Errorf("expected the transaction id to be reused, got %q and %q", paths[0], path)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMatrixValidateTarget(t *testing.T) {
+	notifier := NewMatrixNotifier(&config.Config{})
+	tests := map[string]bool{
+		"!abcdef:example.org":          true,
+		"!abc:matrix.example.com:8448": true,
+		"#room:example.org":            false,
+		"!abcdef":                      false,
+		"":                             false,
+	}
+	for target, valid := range tests {
+		if err := notifier.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) error = %v, want valid %v", target, err, valid)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package ntfy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+// NtfyNotifier publishes to a ntfy topic. the target is the topic URL, an access token
+// for protected topics can be passed as the token query parameter:
+//
+//	https://ntfy.example.com/chores?token=tk_xxx
+type NtfyNotifier struct {
+	client  *http.Client
+	appHost string
+}
+
+func NewNtfyNotifier(cfg *config.Config) *NtfyNotifier {
+	return &NtfyNotifier{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		appHost: cfg.EmailConfig.AppHost,
+	}
+}
+
+func (n *NtfyNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformNtfy
+}
+
+func (n *NtfyNotifier) Name() string {
+	return "ntfy"
+}
+
+func (n *NtfyNotifier) Capabilities() nModel.ProviderCapabilities {
+	return nModel.ProviderCapabilities{Markdown: true, Priority: true}
+}
+
+// IsConfigured is always true, ntfy targets carry their own server
+func (n *NtfyNotifier) IsConfigured() bool {
+	return n != nil
+}
+
+func (n *NtfyNotifier) ValidateTarget(target string) error {
+	_, _, err := parseTarget(target)
+	return err
+}
+
+func (n *NtfyNotifier) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	topicURL, token, err := parseTarget(notification.TargetID)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(c, http.MethodPost, topicURL, strings.NewReader(notification.Text))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", "Donetick")
+	req.Header.Set("Markdown", "yes")
+	req.Header.Set("Tags", tagForEvent(notification.EventType))
+	if priority := mapPriority(notification.Priority()); priority != 0 {
+		req.Header.Set("Priority", strconv.Itoa(priority))
+	}
+	if choreURL := notification.ChoreURL(n.appHost); choreURL != "" {
+		req.Header.Set("Click", choreURL)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("ntfy returned unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// parseTarget splits the target into the topic URL and the optional access token
+func parseTarget(target string) (string, string, error) {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", "", errors.New("ntfy target must be an http(s) topic URL")
+	}
+	topic := strings.Trim(u.Path, "/")
+	if topic == "" || strings.Contains(topic, "/") {
+		return "", "", errors.New("ntfy target must include a single topic, e.g. https://ntfy.sh/my-chores")
+	}
+	query := u.Query()
+	token := query.Get("token")
+	query.Del("token")
+	u.RawQuery = query.Encode()
+	return u.String(), token, nil
+}
+
+// mapPriority maps chore priority (1 highest to 4 lowest, 0 none) to ntfy priority (5 max to 1 min),
+// 0 keeps the server default
+func mapPriority(priority int) int {
+	switch priority {
+	case 1:
+		return 5
+	case 2:
+		return 4
+	case 3:
+		return 3
+	case 4:
+		return 2
+	default:
+		return 0
+	}
+}
+
+func tagForEvent(eventType nModel.EventType) string {
+	switch eventType {
+	case nModel.EventTypeOverdue, nModel.EventTypeNagging:
+		return "alarm_clock"
+	case nModel.EventTypeCompletion:
+		return "tada"
+	case nModel.EventTypeEscalation:
+		return "rotating_light"
+	default:
+		return "calendar"
+	}
+}
@@ -0,0 +1,94 @@
+package ntfy
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestNtfyNotifierSend(t *testing.T) {
+	var gotPath, gotBody string
+	var gotHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.String()
+		gotHeader = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.EmailConfig.AppHost = "https://app.example.com"
+	notifier := NewNtfyNotifier(cfg)
+
+	err := notifier.Send(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			ChoreID:   7,
+			TargetID:  server.URL + "/chores?token=tk_secret",
+			TypeID:    nModel.NotificationPlatformNtfy,
+			EventType: nModel.EventTypeNagging,
+			Text:      "⏰ Overdue: *Dishes*",
+			RawEvent:  nModel.JSONB{"priority": float64(1)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotPath != "/chores" {
+		t.Errorf("expected post to the topic without the token, got %q", gotPath)
+	}
+	if gotBody != "⏰ Overdue: *Dishes*" {
+		t.Errorf("unexpected body %q", gotBody)
+	}
+	wantHeaders := map[string]string{
+		"Authorization": "Bearer tk_secret",
+		"Priority":      "5",
+		"Click":         "https://app.example.com/chores/7",
+		"Tags":          "alarm_clock",
+		"Markdown":      "yes",
+	}
+	for key, want := range wantHeaders {
+		if got := gotHeader.Get(key); got != want {
+			t.Errorf("header %s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestNtfyNotifierSendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	notifier := NewNtfyNotifier(&config.Config{})
+	err := notifier.Send(context.Background(), &nModel.NotificationDetails{
+		Notification: nModel.Notification{TargetID: server.URL + "/chores", Text: "hello"},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a forbidden topic")
+	}
+}
+
+func TestNtfyValidateTarget(t *testing.T) {
+	notifier := NewNtfyNotifier(&config.Config{})
+	tests := map[string]bool{
+		"https://ntfy.sh/chores":                true,
+		"http://ntfy.local:8080/chores?token=x": true,
+		"https://ntfy.sh":                       false,
+		"https://ntfy.sh/a/b":                   false,
+		"ftp://ntfy.sh/chores":                  false,
+		"chores":                                false,
+	}
+	for target, valid := range tests {
+		if err := notifier.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) error = %v, want valid %v", target, err, valid)
+		}
+	}
+}
@@ -10,17 +10,25 @@ import (
 	cRepo "donetick.com/core/internal/circle/repo"
 	nModel "donetick.com/core/internal/notifier/model"
 	nRepo "donetick.com/core/internal/notifier/repo"
+	"donetick.com/core/internal/notifier/templates"
+	uRepo "donetick.com/core/internal/user/repo"
 	"donetick.com/core/logging"
 )
 
 type NotificationPlanner struct {
-	nRepo *nRepo.NotificationRepository
-	cRepo *cRepo.CircleRepository
+	nRepo    *nRepo.NotificationRepository
+	cRepo    *cRepo.CircleRepository
+	uRepo    *uRepo.UserRepository
+	renderer *templates.Renderer
+	inbox    *Inbox
 }
 
-func NewNotificationPlanner(nr *nRepo.NotificationRepository, cr *cRepo.CircleRepository) *NotificationPlanner {
+func NewNotificationPlanner(nr *nRepo.NotificationRepository, cr *cRepo.CircleRepository, ur *uRepo.UserRepository, renderer *templates.Renderer, inbox *Inbox) *NotificationPlanner {
 	return &NotificationPlanner{nRepo: nr,
-		cRepo: cr,
+		cRepo:    cr,
+		uRepo:    ur,
+		renderer: renderer,
+		inbox:    inbox,
 	}
 }
 
@@ -39,7 +47,10 @@ func (n *NotificationPlanner) GenerateNotifications(c context.Context, chore *ch
 		log.Error("Error getting circle members", err)
 		return false
 	}
-	n.nRepo.DeleteAllChoreNotifications(chore.ID)
+	if err := n.nRepo.DeleteChoreReminders(c, chore.ID); err != nil {
+		log.Errorw("Error deleting chore reminders", "chore_id", chore.ID, "error", err)
+		return false
+	}
 	notifications := make([]*nModel.Notification, 0)
 	if !chore.Notification || chore.FrequencyType == "trigger" {
 
@@ -50,29 +61,397 @@ func (n *NotificationPlanner) GenerateNotifications(c context.Context, chore *ch
 		return true
 	}
 
-	if len(chore.NotificationMetadataV2.Templates) > 0 {
-		notifications = append(notifications, generateNotificationsFromTemplate(chore, assignedUser, nil)...)
+	if assignedUser == nil {
+		log.Errorw("Assignee is not a member of the circle, skipping notifications", "chore_id", chore.ID, "assignee", chore.AssignedTo)
+		return false
 	}
 
-	if chore.NotificationMetadataV2.CircleGroup {
-		notifications = append(notifications, generateNotificationsFromTemplate(chore, assignedUser, chore.NotificationMetadataV2.CircleGroupID)...)
+	targets, err := n.userTargets(c, assignedUser.UserID)
+	if err != nil {
+		log.Errorw("Error getting notification targets", "user_id", assignedUser.UserID, "error", err)
+		return false
 	}
+	data := templateData(chore, assignedUser)
+	render := func(eventType nModel.EventType) string {
+		return n.renderer.Render(c, chore.CircleID, eventType, recipientOf(assignedUser), data)
+	}
+	notifications = append(notifications, generateNotificationsFromTemplate(chore, assignedUser, targets, circleGroupTargets(chore), render)...)
 
 	log.Debug("Generated notifications", "count", len(notifications))
 	n.nRepo.BatchInsertNotifications(notifications)
 	return true
 }
 
-func getEventTypeFromTemplate(template *chModel.NotificationTemplate) EventType {
+// GenerateNaggingNotifications builds one reminder per configured platform for an overdue chore.
+// the notifications are scheduled for now so the regular delivery job picks them up, and the
+// stored rows double as the record of when the chore was last nagged.
+func (n *NotificationPlanner) GenerateNaggingNotifications(c context.Context, chore *chModel.Chore) ([]*nModel.Notification, error) {
+	if chore.NextDueDate == nil {
+		return nil, nil
+	}
+	circleMembers, err := n.cRepo.GetCircleUsers(c, chore.CircleID)
+	if err != nil {
+		return nil, err
+	}
+	var assignedUser *cModel.UserCircleDetail
+	for _, member := range circleMembers {
+		if member.UserID == chore.AssignedTo {
+			assignedUser = member
+			break
+		}
+	}
+	if assignedUser == nil {
+		return nil, fmt.Errorf("assignee %d is not a member of circle %d", chore.AssignedTo, chore.CircleID)
+	}
+
+	targets, err := n.userTargets(c, assignedUser.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	data := templateData(chore, assignedUser)
+	data.Overdue = templates.FormatOverdue(now.Sub(*chore.NextDueDate))
+	notification := &nModel.Notification{
+		ChoreID:      chore.ID,
+		IsSent:       false,
+		ScheduledFor: now,
+		CreatedAt:    now,
+		UserID:       assignedUser.UserID,
+		CircleID:     assignedUser.CircleID,
+		EventType:    nModel.EventTypeNagging,
+		Text:         n.renderer.Render(c, chore.CircleID, nModel.EventTypeNagging, recipientOf(assignedUser), data),
+		RawEvent: map[string]interface{}{
+			"id":                chore.ID,
+			"type":              nModel.EventTypeOverdue,
+			"name":              chore.Name,
+			"due_date":          chore.NextDueDate,
+			"overdue_seconds":   int64(now.Sub(*chore.NextDueDate).Seconds()),
+			"priority":          chore.Priority,
+			"assignee":          assignedUser.DisplayName,
+			"assignee_username": assignedUser.Username,
+		},
+	}
+	notifications := make([]*nModel.Notification, 0)
+	for _, target := range targetsForEvent(targets, nModel.EventTypeNagging) {
+		targetNotification := *notification
+		targetNotification.TypeID = target.platform
+		targetNotification.TargetID = target.targetID
+		notifications = append(notifications, &targetNotification)
+	}
+
+	for _, group := range circleGroupTargets(chore) {
+		groupNotification := *notification
+		groupNotification.TypeID = group.platform
+		groupNotification.TargetID = group.targetID
+		// the webhook event is already carried by the assignee notification
+		groupNotification.RawEvent = nil
+		notifications = append(notifications, &groupNotification)
+	}
+	return notifications, nil
+}
+
+// GenerateCompletionNotifications queues completion notifications when the chore has them enabled.
+// the chore creator is notified when someone else completed it, as is the circle group.
+func (n *NotificationPlanner) GenerateCompletionNotifications(c context.Context, chore *chModel.Chore, completedBy int) error {
+	if !chore.Notification || chore.NotificationMetadataV2 == nil || !chore.NotificationMetadataV2.Completion {
+		return nil
+	}
+	circleMembers, err := n.cRepo.GetCircleUsers(c, chore.CircleID)
+	if err != nil {
+		return err
+	}
+	var creator, performer *cModel.UserCircleDetail
+	for _, member := range circleMembers {
+		if member.UserID == chore.CreatedBy {
+			creator = member
+		}
+		if member.UserID == completedBy {
+			performer = member
+		}
+	}
+	if performer == nil {
+		return fmt.Errorf("user %d is not a member of circle %d", completedBy, chore.CircleID)
+	}
+
+	data := templateData(chore, nil)
+	data.CompletedBy = templates.Person{Name: performer.DisplayName, Username: performer.Username}
+	now := time.Now().UTC()
+	base := nModel.Notification{
+		ChoreID:      chore.ID,
+		CircleID:     chore.CircleID,
+		IsSent:       false,
+		ScheduledFor: now,
+		CreatedAt:    now,
+		EventType:    nModel.EventTypeCompletion,
+		Text:         n.renderer.Render(c, chore.CircleID, nModel.EventTypeCompletion, recipientOf(performer), data),
+		RawEvent: map[string]interface{}{
+			"id":                    chore.ID,
+			"type":                  nModel.EventTypeCompletion,
+			"name":                  chore.Name,
+			"completed_by":          performer.DisplayName,
+			"completed_by_username": performer.Username,
+			"next_due_date":         chore.NextDueDate,
+			"priority":              chore.Priority,
+		},
+	}
+
+	notifications := make([]*nModel.Notification, 0)
+	if creator != nil && creator.UserID != completedBy {
+		targets, err := n.userTargets(c, creator.UserID)
+		if err != nil {
+			return err
+		}
+		// the creator gets the message in their own language
+		text := n.renderer.Render(c, chore.CircleID, nModel.EventTypeCompletion, recipientOf(creator), data)
+		// the inbox gets the completion right away, whether or not the creator has a target for it
+		inboxNotification := base
+		inboxNotification.Text = text
+		inboxNotification.UserID = creator.UserID
+		n.inbox.AddNotification(c, &inboxNotification)
+		for _, target := range targets {
+			if !target.events.Matches(nModel.EventTypeCompletion) {
+				continue
+			}
+			notification := base
+			notification.Text = text
+			notification.UserID = creator.UserID
+			notification.TypeID = target.platform
+			notification.TargetID = target.targetID
+			notifications = append(notifications, &notification)
+		}
+	}
+	for _, group := range circleGroupTargets(chore) {
+		notification := base
+		notification.UserID = completedBy
+		notification.TypeID = group.platform
+		notification.TargetID = group.targetID
+		notifications = append(notifications, &notification)
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	return n.nRepo.BatchInsertNotifications(notifications)
+}
+
+// GenerateEscalationNotifications tells the circle admins that the overdue chore was escalated. every admin
+// gets it in their inbox and on the targets subscribed to escalations, the circle group is not notified
+func (n *NotificationPlanner) GenerateEscalationNotifications(c context.Context, chore *chModel.Chore) error {
+	if chore.NextDueDate == nil {
+		return nil
+	}
+	circleMembers, err := n.cRepo.GetCircleUsers(c, chore.CircleID)
+	if err != nil {
+		return err
+	}
+	var assignee *cModel.UserCircleDetail
+	for _, member := range circleMembers {
+		if member.UserID == chore.AssignedTo {
+			assignee = member
+			break
+		}
+	}
+
+	now := time.Now().UTC()
+	data := templateData(chore, assignee)
+	data.Overdue = templates.FormatOverdue(now.Sub(*chore.NextDueDate))
+	rawEvent := map[string]interface{}{
+		"id":              chore.ID,
+		"type":            nModel.EventTypeEscalation,
+		"name":            chore.Name,
+		"due_date":        chore.NextDueDate,
+		"overdue_seconds": int64(now.Sub(*chore.NextDueDate).Seconds()),
+		"priority":        chore.Priority,
+	}
+	if assignee != nil {
+		rawEvent["assignee"] = assignee.DisplayName
+		rawEvent["assignee_username"] = assignee.Username
+	}
+
+	notifications := make([]*nModel.Notification, 0)
+	for _, admin := range circleMembers {
+		if admin.Role != "admin" {
+			continue
+		}
+		base := nModel.Notification{
+			ChoreID:      chore.ID,
+			CircleID:     chore.CircleID,
+			UserID:       admin.UserID,
+			ScheduledFor: now,
+			CreatedAt:    now,
+			EventType:    nModel.EventTypeEscalation,
+			Text:         n.renderer.Render(c, chore.CircleID, nModel.EventTypeEscalation, recipientOf(admin), data),
+			RawEvent:     rawEvent,
+		}
+		inboxNotification := base
+		n.inbox.AddNotification(c, &inboxNotification)
+
+		targets, err := n.userTargets(c, admin.UserID)
+		if err != nil {
+			return err
+		}
+		for _, target := range targets {
+			if !target.events.Matches(nModel.EventTypeEscalation) {
+				continue
+			}
+			notification := base
+			notification.TypeID = target.platform
+			notification.TargetID = target.targetID
+			notifications = append(notifications, &notification)
+		}
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	return n.nRepo.BatchInsertNotifications(notifications)
+}
+
+// GenerateSnoozedNotifications queues a reminder to the user's targets at until, a nagging reminder
+// when the chore is overdue by then. snoozed reminders are not published to the circle webhook
+func (n *NotificationPlanner) GenerateSnoozedNotifications(c context.Context, chore *chModel.Chore, userID int, until time.Time) error {
+	if chore.NextDueDate == nil {
+		return nil
+	}
+	circleMembers, err := n.cRepo.GetCircleUsers(c, chore.CircleID)
+	if err != nil {
+		return err
+	}
+	var assignee, recipient *cModel.UserCircleDetail
+	for _, member := range circleMembers {
+		if member.UserID == chore.AssignedTo {
+			assignee = member
+		}
+		if member.UserID == userID {
+			recipient = member
+		}
+	}
+	if recipient == nil {
+		return fmt.Errorf("user %d is not a member of circle %d", userID, chore.CircleID)
+	}
+
+	eventType := nModel.EventTypeDue
+	data := templateData(chore, assignee)
+	if until.After(*chore.NextDueDate) {
+		eventType = nModel.EventTypeNagging
+		data.Overdue = templates.FormatOverdue(until.Sub(*chore.NextDueDate))
+	}
+	targets, err := n.userTargets(c, userID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	text := n.renderer.Render(c, chore.CircleID, eventType, recipientOf(recipient), data)
+	notifications := make([]*nModel.Notification, 0, len(targets))
+	for _, target := range targets {
+		if !target.events.Matches(eventType) {
+			continue
+		}
+		notifications = append(notifications, &nModel.Notification{
+			ChoreID:      chore.ID,
+			CircleID:     chore.CircleID,
+			UserID:       userID,
+			TypeID:       target.platform,
+			TargetID:     target.targetID,
+			EventType:    eventType,
+			ScheduledFor: until.UTC(),
+			CreatedAt:    now,
+			Text:         text,
+		})
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+	return n.nRepo.BatchInsertNotifications(notifications)
+}
+
+// deliveryTarget is where a notification is delivered, events limits the event types it receives
+type deliveryTarget struct {
+	platform nModel.NotificationPlatform
+	targetID string
+	events   nModel.EventFilter
+}
+
+// userTargets returns the enabled notification targets of a user
+func (n *NotificationPlanner) userTargets(c context.Context, userID int) ([]deliveryTarget, error) {
+	userTargets, err := n.uRepo.GetNotificationTargets(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]deliveryTarget, 0, len(userTargets))
+	for _, target := range userTargets {
+		if !target.Enabled || target.Type == nModel.NotificationPlatformNone {
+			continue
+		}
+		targets = append(targets, deliveryTarget{
+			platform: target.Type,
+			targetID: target.TargetID,
+			events:   target.Events,
+		})
+	}
+	return targets, nil
+}
+
+// targetsForEvent picks the assignee targets subscribed to the event. when none is, a single
+// notification without a platform is kept so the circle webhook still receives the event
+func targetsForEvent(targets []deliveryTarget, eventType nModel.EventType) []deliveryTarget {
+	matching := make([]deliveryTarget, 0, len(targets))
+	for _, target := range targets {
+		if target.events.Matches(eventType) {
+			matching = append(matching, target)
+		}
+	}
+	if len(matching) == 0 {
+		return []deliveryTarget{{platform: nModel.NotificationPlatformNone}}
+	}
+	return matching
+}
+
+// circleGroupTargets returns the group chats or rooms that get the chore notifications alongside the assignee
+func circleGroupTargets(chore *chModel.Chore) []deliveryTarget {
+	metadata := chore.NotificationMetadataV2
+	if metadata == nil || !metadata.CircleGroup {
+		return nil
+	}
+	targets := make([]deliveryTarget, 0, 2)
+	if metadata.CircleGroupID != nil && *metadata.CircleGroupID != 0 {
+		targets = append(targets, deliveryTarget{
+			platform: nModel.NotificationPlatformTelegram,
+			targetID: fmt.Sprint(*metadata.CircleGroupID),
+		})
+	}
+	if metadata.MatrixRoomID != nil && *metadata.MatrixRoomID != "" {
+		targets = append(targets, deliveryTarget{
+			platform: nModel.NotificationPlatformMatrix,
+			targetID: *metadata.MatrixRoomID,
+		})
+	}
+	return targets
+}
+
+// templateData is the template variable set of a chore, assignee can be nil
+func templateData(chore *chModel.Chore, assignee *cModel.UserCircleDetail) *templates.Data {
+	data := &templates.Data{Chore: templates.NewChore(chore)}
+	if assignee != nil {
+		data.Assignee = templates.Person{Name: assignee.DisplayName, Username: assignee.Username}
+	}
+	return data
+}
+
+func recipientOf(member *cModel.UserCircleDetail) templates.Recipient {
+	return templates.Recipient{Language: member.Language, Timezone: member.Timezone}
+}
+
+func getEventTypeFromTemplate(template *chModel.NotificationTemplate) nModel.EventType {
 	if template == nil {
-		return EventTypeUnknown
+		return nModel.EventTypeUnknown
 	}
 	if template.Value < 0 {
-		return EventTypePreDue
+		return nModel.EventTypePreDue
 	} else if template.Value == 0 {
-		return EventTypeDue
+		return nModel.EventTypeDue
 	} else {
-		return EventTypeOverdue
+		return nModel.EventTypeOverdue
 	}
 }
 
@@ -104,15 +483,14 @@ func calculateScheduledTime(baseTime time.Time, template *chModel.NotificationTe
 	return baseTime.Add(duration), nil
 }
 
-func generateNotificationsFromTemplate(chore *chModel.Chore, assignedUser *cModel.UserCircleDetail, overrideTarget *int64) []*nModel.Notification {
-	if len(chore.NotificationMetadataV2.Templates) == 0 {
+// generateNotificationsFromTemplate fans every template out to the assignee targets subscribed to
+// its event type and to the circle groups, render returns the text of an event type
+func generateNotificationsFromTemplate(chore *chModel.Chore, assignedUser *cModel.UserCircleDetail, targets []deliveryTarget, groups []deliveryTarget, render func(nModel.EventType) string) []*nModel.Notification {
+	if chore.NotificationMetadataV2 == nil || len(chore.NotificationMetadataV2.Templates) == 0 {
 		return nil // No templates to process
 	}
-	targetID := assignedUser.TargetID
-	if overrideTarget != nil {
-		targetID = fmt.Sprint(*overrideTarget)
-	}
 	notifications := make([]*nModel.Notification, 0)
+	texts := make(map[nModel.EventType]string)
 
 	for _, template := range chore.NotificationMetadataV2.Templates {
 		scheduledTime, err := calculateScheduledTime(*chore.NextDueDate, template)
@@ -126,36 +504,33 @@ func generateNotificationsFromTemplate(chore *chModel.Chore, assignedUser *cMode
 			continue
 		}
 		eventType := getEventTypeFromTemplate(template)
-		notifications = append(notifications, &nModel.Notification{
-			ChoreID:      chore.ID,
-			IsSent:       false,
-			ScheduledFor: scheduledTime,
-			CreatedAt:    time.Now().UTC(),
-			TypeID:       assignedUser.NotificationType,
-			UserID:       assignedUser.UserID,
-			CircleID:     assignedUser.CircleID,
-			TargetID:     targetID,
-			Text:         fmt.Sprintf("📅 Reminder: *%s* is due today and assigned to %s.", chore.Name, assignedUser.DisplayName),
-			RawEvent: map[string]interface{}{
-				"id":                chore.ID,
-				"type":              eventType,
-				"name":              chore.Name,
-				"due_date":          chore.NextDueDate,
-				"assignee":          assignedUser.DisplayName,
-				"assignee_username": assignedUser.Username,
-			},
-		})
-
+		if _, ok := texts[eventType]; !ok {
+			texts[eventType] = render(eventType)
+		}
+		for _, target := range append(targetsForEvent(targets, eventType), groups...) {
+			notifications = append(notifications, &nModel.Notification{
+				ChoreID:      chore.ID,
+				IsSent:       false,
+				ScheduledFor: scheduledTime,
+				CreatedAt:    time.Now().UTC(),
+				TypeID:       target.platform,
+				UserID:       assignedUser.UserID,
+				CircleID:     assignedUser.CircleID,
+				TargetID:     target.targetID,
+				EventType:    eventType,
+				Text:         texts[eventType],
+				RawEvent: map[string]interface{}{
+					"id":                chore.ID,
+					"type":              eventType,
+					"name":              chore.Name,
+					"due_date":          chore.NextDueDate,
+					"priority":          chore.Priority,
+					"assignee":          assignedUser.DisplayName,
+					"assignee_username": assignedUser.Username,
+				},
+			})
+		}
 	}
 
 	return notifications
 }
-
-type EventType string
-
-const (
-	EventTypeUnknown EventType = "unknown"
-	EventTypeDue     EventType = "due"
-	EventTypePreDue  EventType = "pre_due"
-	EventTypeOverdue EventType = "overdue"
-)
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	cModel "donetick.com/core/internal/circle/model"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestGenerateNotificationsFromTemplateFanOut(t *testing.T) {
+	dueDate := time.Now().UTC().Add(48 * time.Hour)
+	chore := &chModel.Chore{
+		ID:          4,
+		Name:        "Mow the lawn",
+		NextDueDate: &dueDate,
+		NotificationMetadataV2: &chModel.NotificationMetadata{
+			Templates: []*chModel.NotificationTemplate{
+				{Value: -1, Unit: chModel.NotificationTemplateUnitHour},
+				{Value: 0, Unit: chModel.NotificationTemplateUnitMinute},
+				{Value: 2, Unit: chModel.NotificationTemplateUnitHour},
+			},
+		},
+	}
+	assignee := &cModel.UserCircleDetail{UserCircle: cModel.UserCircle{UserID: 7, CircleID: 1}, DisplayName: "Sam"}
+	targets := []deliveryTarget{
+		{platform: nModel.NotificationPlatformPushover, targetID: "pushover-key", events: nModel.EventFilter{nModel.EventTypeOverdue}},
+		{platform: nModel.NotificationPlatformTelegram, targetID: "123", events: nModel.EventFilter{nModel.EventTypePreDue, nModel.EventTypeDue}},
+		{platform: nModel.NotificationPlatformEmail, targetID: "sam@example.com"},
+	}
+	groups := []deliveryTarget{{platform: nModel.NotificationPlatformMatrix, targetID: "!room:example.org"}}
+
+	got := map[nModel.EventType][]nModel.NotificationPlatform{}
+	for _, notification := range generateNotificationsFromTemplate(chore, assignee, targets, groups, func(eventType nModel.EventType) string { return string(eventType) }) {
+		got[notification.EventType] = append(got[notification.EventType], notification.TypeID)
+		if notification.UserID != 7 || notification.RawEvent == nil || notification.Text != string(notification.EventType) {
+			t.Errorf("unexpected notification %+v", notification)
+		}
+	}
+
+	want := map[nModel.EventType][]nModel.NotificationPlatform{
+		nModel.EventTypePreDue:  {nModel.NotificationPlatformTelegram, nModel.NotificationPlatformEmail, nModel.NotificationPlatformMatrix},
+		nModel.EventTypeDue:     {nModel.NotificationPlatformTelegram, nModel.NotificationPlatformEmail, nModel.NotificationPlatformMatrix},
+		nModel.EventTypeOverdue: {nModel.NotificationPlatformPushover, nModel.NotificationPlatformEmail, nModel.NotificationPlatformMatrix},
+	}
+	for eventType, platforms := range want {
+		if len(got[eventType]) != len(platforms) {
+			t.Errorf("%s: got platforms %v, want %v", eventType, got[eventType], platforms)
+			continue
+		}
+		for i := range platforms {
+			if got[eventType][i] != platforms[i] {
+				t.Errorf("%s: got platforms %v, want %v", eventType, got[eventType], platforms)
+				break
+			}
+		}
+	}
+}
+
+func TestTargetsForEvent(t *testing.T) {
+	targets := []deliveryTarget{
+		{platform: nModel.NotificationPlatformPushover, events: nModel.EventFilter{nModel.EventTypeNagging}},
+	}
+	if got := targetsForEvent(targets, nModel.EventTypeNagging); len(got) != 1 || got[0].platform != nModel.NotificationPlatformPushover {
+		t.Errorf("expected the pushover target, got %+v", got)
+	}
+	// without a matching target the event is still planned so the circle webhook receives it
+	if got := targetsForEvent(targets, nModel.EventTypeDue); len(got) != 1 || got[0].platform != nModel.NotificationPlatformNone {
+		t.Errorf("expected a single target without platform, got %+v", got)
+	}
+}
+
+func TestEventFilter(t *testing.T) {
+	if !(nModel.EventFilter{}).Matches(nModel.EventTypeCompletion) {
+		t.Error("an empty filter should match every event")
+	}
+	filter := nModel.EventFilter{nModel.EventTypeDue, nModel.EventTypeCompletion}
+	if !filter.Matches(nModel.EventTypeCompletion) || filter.Matches(nModel.EventTypeNagging) {
+		t.Errorf("unexpected matches for %v", filter)
+	}
+	if err := filter.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := (nModel.EventFilter{"reminder"}).Validate(); err == nil {
+		t.Error("expected an error for an unknown event type")
+	}
+}
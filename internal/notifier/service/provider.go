@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	"donetick.com/core/logging"
+)
+
+// Provider delivers notifications for a single NotificationPlatform
+type Provider interface {
+	Platform() nModel.NotificationPlatform
+	// Name is the key used to enable the provider in config
+	Name() string
+	Capabilities() nModel.ProviderCapabilities
+	// IsConfigured reports whether the provider has what it needs (tokens, clients) to send
+	IsConfigured() bool
+	ValidateTarget(target string) error
+	Send(c context.Context, notification *nModel.NotificationDetails) error
+}
+
+type ProviderRegistry struct {
+	providers map[nModel.NotificationPlatform]Provider
+}
+
+// NewProviderRegistry registers every configured provider that is enabled in config
+func NewProviderRegistry(cfg *config.Config, providers []Provider) *ProviderRegistry {
+	log := logging.DefaultLogger()
+	registry := &ProviderRegistry{
+		providers: make(map[nModel.NotificationPlatform]Provider),
+	}
+	for _, provider := range providers {
+		if provider == nil {
+			continue
+		}
+		if !cfg.Notification.IsProviderEnabled(provider.Name()) {
+			log.Infof("Notification provider %s is disabled in config", provider.Name())
+			continue
+		}
+		if !provider.IsConfigured() {
+			log.Infof("Notification provider %s is not configured, skipping", provider.Name())
+			continue
+		}
+		registry.Register(provider)
+	}
+	return registry
+}
+
+func (r *ProviderRegistry) Register(provider Provider) {
+	r.providers[provider.Platform()] = provider
+}
+
+func (r *ProviderRegistry) Get(platform nModel.NotificationPlatform) (Provider, bool) {
+	provider, ok := r.providers[platform]
+	return provider, ok
+}
+
+// ValidateTarget checks the target against the provider of the given platform
+func (r *ProviderRegistry) ValidateTarget(platform nModel.NotificationPlatform, target string) error {
+	provider, ok := r.Get(platform)
+	if !ok {
+		return fmt.Errorf("notification platform %d is not available", platform)
+	}
+	return provider.ValidateTarget(target)
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+type fakeProvider struct {
+	platform   nModel.NotificationPlatform
+	name       string
+	configured bool
+}
+
+func (f *fakeProvider) Platform() nModel.NotificationPlatform { return f.platform }
+func (f *fakeProvider) Name() string                          { return f.name }
+func (f *fakeProvider) Capabilities() nModel.ProviderCapabilities {
+	return nModel.ProviderCapabilities{}
+}
+func (f *fakeProvider) IsConfigured() bool                 { return f.configured }
+func (f *fakeProvider) ValidateTarget(target string) error { return nil }
+func (f *fakeProvider) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	return nil
+}
+
+func TestNewProviderRegistry(t *testing.T) {
+	providers := []Provider{
+		&fakeProvider{platform: nModel.NotificationPlatformTelegram, name: "telegram", configured: true},
+		&fakeProvider{platform: nModel.NotificationPlatformPushover, name: "pushover", configured: false},
+		&fakeProvider{platform: nModel.NotificationPlatformDiscord, name: "discord", configured: true},
+	}
+
+	tests := []struct {
+		name    string
+		enabled []string
+		want    map[nModel.NotificationPlatform]bool
+	}{
+		{
+			name: "all configured providers when none listed",
+			want: map[nModel.NotificationPlatform]bool{
+				nModel.NotificationPlatformTelegram: true,
+				nModel.NotificationPlatformPushover: false,
+				nModel.NotificationPlatformDiscord:  true,
+			},
+		},
+		{
+			name:    "only listed providers",
+			enabled: []string{"Discord", "pushover"},
+			want: map[nModel.NotificationPlatform]bool{
+				nModel.NotificationPlatformTelegram: false,
+				nModel.NotificationPlatformPushover: false,
+				nModel.NotificationPlatformDiscord:  true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Notification: config.NotificationConfig{Providers: tt.enabled}}
+			registry := NewProviderRegistry(cfg, providers)
+			for platform, want := range tt.want {
+				if _, ok := registry.Get(platform); ok != want {
+					t.Errorf("platform %d registered = %v, want %v", platform, ok, want)
+				}
+			}
+		})
+	}
+}
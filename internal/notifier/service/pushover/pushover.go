@@ -3,6 +3,8 @@ package pushover
 import (
 	"context"
 	"errors"
+	"fmt"
+	"regexp"
 
 	"donetick.com/core/config"
 	nModel "donetick.com/core/internal/notifier/model"
@@ -10,8 +12,12 @@ import (
 	"github.com/gregdel/pushover"
 )
 
+// pushover user and group keys are 30 character alphanumeric strings
+var recipientKeyRegex = regexp.MustCompile(`^[A-Za-z0-9]{30}$`)
+
 type Pushover struct {
-	pushover *pushover.Pushover
+	pushover   *pushover.Pushover
+	configured bool
 }
 
 func NewPushover(cfg *config.Config) *Pushover {
@@ -19,10 +25,38 @@ func NewPushover(cfg *config.Config) *Pushover {
 	pushoverApp := pushover.New(cfg.Pushover.Token)
 
 	return &Pushover{
-		pushover: pushoverApp,
+		pushover:   pushoverApp,
+		configured: cfg.Pushover.Token != "",
 	}
 }
 
+func (p *Pushover) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformPushover
+}
+
+func (p *Pushover) Name() string {
+	return "pushover"
+}
+
+func (p *Pushover) Capabilities() nModel.ProviderCapabilities {
+	return nModel.ProviderCapabilities{Priority: true}
+}
+
+func (p *Pushover) IsConfigured() bool {
+	return p != nil && p.configured
+}
+
+func (p *Pushover) ValidateTarget(target string) error {
+	if !recipientKeyRegex.MatchString(target) {
+		return fmt.Errorf("invalid pushover user key %q", target)
+	}
+	return nil
+}
+
+func (p *Pushover) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	return p.SendNotification(c, notification)
+}
+
 func (p *Pushover) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
 	if notification.TargetID == "" {
 		return errors.New("unable to send notification, targetID is empty")
@@ -0,0 +1,158 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+// SlackNotifier posts Block Kit messages to a Slack incoming webhook, the target is the webhook URL
+type SlackNotifier struct {
+	client  *http.Client
+	appHost string
+}
+
+type message struct {
+	Text   string  `json:"text"`
+	Blocks []block `json:"blocks"`
+}
+
+type block struct {
+	Type     string    `json:"type"`
+	Text     *text     `json:"text,omitempty"`
+	Elements []element `json:"elements,omitempty"`
+}
+
+type text struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+type element struct {
+	Type  string `json:"type"`
+	Text  any    `json:"text,omitempty"`
+	URL   string `json:"url,omitempty"`
+	Style string `json:"style,omitempty"`
+}
+
+func NewSlackNotifier(cfg *config.Config) *SlackNotifier {
+	return &SlackNotifier{
+		client:  &http.Client{Timeout: 10 * time.Second},
+		appHost: cfg.EmailConfig.AppHost,
+	}
+}
+
+func (s *SlackNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformSlack
+}
+
+func (s *SlackNotifier) Name() string {
+	return "slack"
+}
+
+func (s *SlackNotifier) Capabilities() nModel.ProviderCapabilities {
+	return nModel.ProviderCapabilities{Markdown: true, Buttons: true}
+}
+
+// IsConfigured is always true, slack targets carry their own webhook URL
+func (s *SlackNotifier) IsConfigured() bool {
+	return s != nil
+}
+
+// ValidateTarget checks the target is a slack incoming webhook URL
+func (s *SlackNotifier) ValidateTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("slack target must be an https incoming webhook URL")
+	}
+	if !isSlackHost(u.Hostname()) {
+		return fmt.Errorf("slack target host %q is not a slack webhook host", u.Host)
+	}
+	return nil
+}
+
+func isSlackHost(host string) bool {
+	for _, domain := range []string{"slack.com", "slack-gov.com"} {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *SlackNotifier) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	if err := s.ValidateTarget(notification.TargetID); err != nil {
+		return err
+	}
+	body, err := json.Marshal(buildMessage(notification, notification.ChoreURL(s.appHost)))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(c, http.MethodPost, notification.TargetID, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack returned unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// buildMessage renders the notification as Block Kit, the notification text uses *bold* which is
+// also slack's mrkdwn bold, and text stays as the fallback for notifications and screen readers
+func buildMessage(notification *nModel.NotificationDetails, choreURL string) message {
+	blocks := []block{
+		{
+			Type: "section",
+			Text: &text{Type: "mrkdwn", Text: notification.Text},
+		},
+	}
+
+	var contextElements []element
+	if dueDate, ok := notification.RawEvent["due_date"].(string); ok && dueDate != "" {
+		if parsed, err := time.Parse(time.RFC3339, dueDate); err == nil {
+			// slack renders the date in the reader's timezone
+			contextElements = append(contextElements, element{
+				Type: "mrkdwn",
+				Text: fmt.Sprintf("Due <!date^%d^{date_short_pretty} at {time}|%s>", parsed.Unix(), parsed.UTC().Format(time.RFC1123)),
+			})
+		}
+	}
+	if priority := notification.Priority(); priority > 0 {
+		contextElements = append(contextElements, element{Type: "mrkdwn", Text: fmt.Sprintf("Priority P%d", priority)})
+	}
+	if len(contextElements) > 0 {
+		blocks = append(blocks, block{Type: "context", Elements: contextElements})
+	}
+
+	if choreURL != "" {
+		blocks = append(blocks, block{
+			Type: "actions",
+			Elements: []element{{
+				Type:  "button",
+				Text:  text{Type: "plain_text", Text: "Open chore", Emoji: true},
+				URL:   choreURL,
+				Style: "primary",
+			}},
+		})
+	}
+
+	return message{Text: notification.Text, Blocks: blocks}
+}
@@ -0,0 +1,67 @@
+package slack
+
+import (
+	"strings"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestBuildMessage(t *testing.T) {
+	notification := &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			ChoreID:  9,
+			Text:     "⏰ Overdue: *Trash* was due 1d ago",
+			RawEvent: nModel.JSONB{"due_date": "2025-01-02T09:30:00Z", "priority": float64(1)},
+		},
+	}
+	msg := buildMessage(notification, "https://app.example.com/chores/9")
+
+	if msg.Text != notification.Text {
+		t.Errorf("expected the fallback text to be the notification text, got %q", msg.Text)
+	}
+	if len(msg.Blocks) != 3 {
+		t.Fatalf("expected section, context and actions blocks, got %+v", msg.Blocks)
+	}
+	if msg.Blocks[0].Type != "section" || msg.Blocks[0].Text.Type != "mrkdwn" || msg.Blocks[0].Text.Text != notification.Text {
+		t.Errorf("unexpected section block %+v", msg.Blocks[0])
+	}
+	contextBlock := msg.Blocks[1]
+	if contextBlock.Type != "context" || len(contextBlock.Elements) != 2 {
+		t.Fatalf("unexpected context block %+v", contextBlock)
+	}
+	if due, _ := contextBlock.Elements[0].Text.(string); !strings.HasPrefix(due, "Due <!date^1735810200^") {
+		t.Errorf("unexpected due date element %q", due)
+	}
+	if contextBlock.Elements[1].Text != "Priority P1" {
+		t.Errorf("unexpected priority element %v", contextBlock.Elements[1].Text)
+	}
+	button := msg.Blocks[2].Elements[0]
+	if msg.Blocks[2].Type != "actions" || button.Type != "button" || button.URL != "https://app.example.com/chores/9" {
+		t.Errorf("unexpected actions block %+v", msg.Blocks[2])
+	}
+}
+
+func TestBuildMessageMinimal(t *testing.T) {
+	msg := buildMessage(&nModel.NotificationDetails{Notification: nModel.Notification{Text: "hello"}}, "")
+	if len(msg.Blocks) != 1 {
+		t.Errorf("expected only the section block, got %+v", msg.Blocks)
+	}
+}
+
+func TestSlackValidateTarget(t *testing.T) {
+	notifier := NewSlackNotifier(&config.Config{})
+	tests := map[string]bool{
+		"https://hooks.slack.com/services/T000/B000/XXXX": true,
+		"https://hooks.slack-gov.com/services/T000/B000":  true,
+		"http://hooks.slack.com/services/T000/B000/XXXX":  false,
+		"https://evilslack.com/services/T000":             false,
+		"https://hooks.slack.com.example.com/services/T0": false,
+	}
+	for target, valid := range tests {
+		if err := notifier.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) error = %v, want valid %v", target, err, valid)
+		}
+	}
+}
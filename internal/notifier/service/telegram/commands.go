@@ -0,0 +1,286 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const dueDateFormat = "Mon, Jan 2 15:04"
+
+const helpText = `Donetick commands:
+/today - chores due today
+/overdue - overdue chores
+/mine - chores assigned to you
+/add <name> [due] - add a chore, due can be today, tomorrow, 2026-03-10, 18:00 or 2026-03-10 18:00
+/done <id> - complete a chore
+/link <code> - link this chat with the code from Donetick settings
+
+In a linked group /today and /overdue list the whole circle's chores.`
+
+const (
+	replyNotLinked      = "This Telegram account is not linked to Donetick, create a link code in the app settings and send /link <code>"
+	replyGroupNotLinked = "This group is not linked to a Donetick circle"
+	replyFailed         = "Something went wrong, please try again from the app"
+)
+
+// handleCommand answers a slash command, private chats act for the linked user and groups set as a
+// circle group act for the member who sent the command
+func (tn *TelegramNotifier) handleCommand(c context.Context, msg *tgbotapi.Message) {
+	// commands for another bot in the same group
+	if at := strings.Index(msg.CommandWithAt(), "@"); at >= 0 && !strings.EqualFold(msg.CommandWithAt()[at+1:], tn.bot.Self.UserName) {
+		return
+	}
+	command := strings.ToLower(msg.Command())
+	args := strings.TrimSpace(msg.CommandArguments())
+
+	switch command {
+	case "help":
+		tn.reply(c, msg, helpText)
+		return
+	case "start", "link":
+		tn.linkChat(c, msg, args)
+		return
+	case "today", "overdue", "mine", "add", "done":
+	default:
+		tn.reply(c, msg, "Unknown command, send /help to see what I can do")
+		return
+	}
+
+	user, circleWide, reply := tn.commandUser(c, msg)
+	if user == nil {
+		tn.reply(c, msg, reply)
+		return
+	}
+	loc := userLocation(user)
+	now := time.Now().In(loc)
+
+	switch command {
+	case "today":
+		startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+		chores, err := tn.actions.ChoresDueBefore(c, user, circleWide, startOfDay.AddDate(0, 0, 1))
+		if err != nil {
+			tn.replyError(c, msg, command, err)
+			return
+		}
+		var today []*chModel.Chore
+		for _, chore := range chores {
+			if !chore.NextDueDate.Before(startOfDay) {
+				today = append(today, chore)
+			}
+		}
+		tn.reply(c, msg, choreList("Due today", "Nothing due today 🎉", today, loc))
+	case "overdue":
+		chores, err := tn.actions.ChoresDueBefore(c, user, circleWide, now)
+		if err != nil {
+			tn.replyError(c, msg, command, err)
+			return
+		}
+		tn.reply(c, msg, choreList("Overdue", "Nothing overdue 🎉", chores, loc))
+	case "mine":
+		chores, err := tn.actions.AssignedChores(c, user)
+		if err != nil {
+			tn.replyError(c, msg, command, err)
+			return
+		}
+		tn.reply(c, msg, choreList("Assigned to you", "Nothing is assigned to you", chores, loc))
+	case "add":
+		name, dueDate := parseAddArgs(args, now)
+		if name == "" {
+			tn.reply(c, msg, "Usage: /add <name> [due], e.g. /add Water plants tomorrow 18:00")
+			return
+		}
+		chore, err := tn.actions.CreateChore(c, user, name, dueDate)
+		if err != nil {
+			tn.replyError(c, msg, command, err)
+			return
+		}
+		text := fmt.Sprintf("➕ Added #%d %s", chore.ID, chore.Name)
+		if chore.NextDueDate != nil {
+			text += fmt.Sprintf(", due %s", chore.NextDueDate.In(loc).Format(dueDateFormat))
+		}
+		tn.reply(c, msg, text)
+	case "done":
+		choreID, err := strconv.Atoi(strings.TrimPrefix(args, "#"))
+		if err != nil {
+			tn.reply(c, msg, "Usage: /done <id>, the id is shown by /today, /overdue and /mine")
+			return
+		}
+		chore, err := tn.actions.CompleteChore(c, choreID, user)
+		if err != nil {
+			logging.FromContext(c).Debugw("Telegram command failed", "command", command, "chore_id", choreID, "user_id", user.ID, "error", err)
+			tn.reply(c, msg, actionFailureReply(err))
+			return
+		}
+		text := fmt.Sprintf("✅ %s done by %s", chore.Name, user.DisplayName)
+		if chore.NextDueDate != nil {
+			text += fmt.Sprintf(", next due %s", chore.NextDueDate.In(loc).Format(dueDateFormat))
+		}
+		tn.reply(c, msg, text)
+	}
+}
+
+// commandUser resolves the user a command acts for and whether it covers the whole circle, or the
+// reply explaining why the chat can't use commands
+func (tn *TelegramNotifier) commandUser(c context.Context, msg *tgbotapi.Message) (*uModel.UserDetails, bool, string) {
+	log := logging.FromContext(c)
+	if msg.Chat.IsPrivate() {
+		user, err := tn.users.GetUserByTelegramChatID(c, msg.Chat.ID)
+		if err != nil {
+			log.Errorw("Error getting user for telegram chat", "chat_id", msg.Chat.ID, "error", err)
+			return nil, false, replyFailed
+		}
+		if user == nil {
+			return nil, false, replyNotLinked
+		}
+		return user, false, ""
+	}
+
+	if msg.From == nil {
+		return nil, false, replyNotLinked
+	}
+	user, err := tn.users.GetUserByTelegramChatID(c, msg.From.ID)
+	if err != nil {
+		log.Errorw("Error getting user for telegram chat", "chat_id", msg.From.ID, "error", err)
+		return nil, false, replyFailed
+	}
+	if user == nil {
+		return nil, false, replyNotLinked
+	}
+	isGroup, err := tn.actions.IsCircleGroup(c, user, msg.Chat.ID)
+	if err != nil {
+		log.Errorw("Error checking telegram circle group", "chat_id", msg.Chat.ID, "error", err)
+		return nil, false, replyFailed
+	}
+	if !isGroup {
+		return nil, false, replyGroupNotLinked
+	}
+	return user, true, ""
+}
+
+// linkChat links the private chat to the user who created the code
+func (tn *TelegramNotifier) linkChat(c context.Context, msg *tgbotapi.Message, code string) {
+	if code == "" {
+		tn.reply(c, msg, "Welcome to Donetick! Create a link code in the app settings and send /link <code> to get your reminders here.\n\n"+helpText)
+		return
+	}
+	if !msg.Chat.IsPrivate() {
+		tn.reply(c, msg, "Link your account in a private chat with me, group chats are linked from the chore notification settings")
+		return
+	}
+	log := logging.FromContext(c)
+	linkCode, err := tn.users.ConsumeTelegramLinkCode(c, strings.ToUpper(code), time.Now().UTC())
+	if err != nil {
+		log.Errorw("Error consuming telegram link code", "error", err)
+		tn.reply(c, msg, replyFailed)
+		return
+	}
+	if linkCode == nil {
+		tn.reply(c, msg, "This code is invalid or has expired, create a new one in the app settings")
+		return
+	}
+	if err := tn.users.LinkTelegramChat(c, linkCode.UserID, msg.Chat.ID); err != nil {
+		log.Errorw("Error linking telegram chat", "user_id", linkCode.UserID, "chat_id", msg.Chat.ID, "error", err)
+		tn.reply(c, msg, replyFailed)
+		return
+	}
+	tn.reply(c, msg, "✅ Your Telegram account is linked, your reminders will arrive here. Send /help to see what I can do.")
+}
+
+func (tn *TelegramNotifier) reply(c context.Context, msg *tgbotapi.Message, text string) {
+	reply := tgbotapi.NewMessage(msg.Chat.ID, text)
+	if !msg.Chat.IsPrivate() {
+		reply.ReplyToMessageID = msg.MessageID
+	}
+	if _, err := tn.bot.Send(reply); err != nil {
+		logging.FromContext(c).Errorw("Error replying to telegram command", "chat_id", msg.Chat.ID, "error", err)
+	}
+}
+
+func (tn *TelegramNotifier) replyError(c context.Context, msg *tgbotapi.Message, command string, err error) {
+	logging.FromContext(c).Errorw("Telegram command failed", "command", command, "chat_id", msg.Chat.ID, "error", err)
+	tn.reply(c, msg, replyFailed)
+}
+
+func choreList(title string, empty string, chores []*chModel.Chore, loc *time.Location) string {
+	if len(chores) == 0 {
+		return empty
+	}
+	var sb strings.Builder
+	sb.WriteString(title + ":")
+	for _, chore := range chores {
+		sb.WriteString(fmt.Sprintf("\n#%d %s", chore.ID, chore.Name))
+		if chore.NextDueDate != nil {
+			sb.WriteString(" - " + chore.NextDueDate.In(loc).Format(dueDateFormat))
+		}
+	}
+	return sb.String()
+}
+
+func userLocation(user *uModel.UserDetails) *time.Location {
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// parseAddArgs splits /add arguments into the chore name and an optional trailing due date in the
+// location of now. a day without a time is due at the end of that day and a time without a day is
+// its next occurrence
+func parseAddArgs(args string, now time.Time) (string, *time.Time) {
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		return strings.Join(fields, " "), nil
+	}
+	last := fields[len(fields)-1]
+
+	if hour, minute, ok := parseClock(last); ok {
+		if len(fields) > 2 {
+			if day, ok := parseDay(fields[len(fields)-2], now); ok {
+				due := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location()).UTC()
+				return strings.Join(fields[:len(fields)-2], " "), &due
+			}
+		}
+		due := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+		if !due.After(now) {
+			due = due.AddDate(0, 0, 1)
+		}
+		due = due.UTC()
+		return strings.Join(fields[:len(fields)-1], " "), &due
+	}
+	if day, ok := parseDay(last, now); ok {
+		due := time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 0, 0, now.Location()).UTC()
+		return strings.Join(fields[:len(fields)-1], " "), &due
+	}
+	return strings.Join(fields, " "), nil
+}
+
+func parseDay(value string, now time.Time) (time.Time, bool) {
+	switch strings.ToLower(value) {
+	case "today":
+		return now, true
+	case "tomorrow":
+		return now.AddDate(0, 0, 1), true
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, now.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+func parseClock(value string) (int, int, bool) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, false
+	}
+	return clock.Hour(), clock.Minute(), true
+}
@@ -0,0 +1,212 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	uModel "donetick.com/core/internal/user/model"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestParseAddArgs(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	now := time.Date(2026, time.March, 10, 14, 30, 0, 0, berlin)
+
+	tests := []struct {
+		args     string
+		wantName string
+		wantDue  string
+	}{
+		{args: "Water plants", wantName: "Water plants"},
+		{args: "today", wantName: "today"},
+		{args: "Water plants today", wantName: "Water plants", wantDue: "2026-03-10 23:59"},
+		{args: "Water plants tomorrow", wantName: "Water plants", wantDue: "2026-03-11 23:59"},
+		{args: "Water plants 2026-04-01", wantName: "Water plants", wantDue: "2026-04-01 23:59"},
+		{args: "Water plants 18:00", wantName: "Water plants", wantDue: "2026-03-10 18:00"},
+		{args: "Water plants 09:00", wantName: "Water plants", wantDue: "2026-03-11 09:00"},
+		{args: "Water plants tomorrow 18:00", wantName: "Water plants", wantDue: "2026-03-11 18:00"},
+		{args: "Water plants 2026-04-01 07:15", wantName: "Water plants", wantDue: "2026-04-01 07:15"},
+		{args: "Call Mia at 5", wantName: "Call Mia at 5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.args, func(t *testing.T) {
+			name, due := parseAddArgs(tt.args, now)
+			if name != tt.wantName {
+				t.Errorf("name = %q, want %q", name, tt.wantName)
+			}
+			if tt.wantDue == "" {
+				if due != nil {
+					t.Errorf("expected no due date, got %v", due)
+				}
+				return
+			}
+			if due == nil {
+				t.Fatalf("expected due %s, got none", tt.wantDue)
+			}
+			if due.Location() != time.UTC {
+				t.Errorf("due dates are stored in UTC, got %v", due.Location())
+			}
+			if got := due.In(berlin).Format("2006-01-02 15:04"); got != tt.wantDue {
+				t.Errorf("due = %s, want %s", got, tt.wantDue)
+			}
+		})
+	}
+}
+
+func command(text string, chat *tgbotapi.Chat, from int64) *tgbotapi.Message {
+	name, _, _ := strings.Cut(text, " ")
+	return &tgbotapi.Message{
+		MessageID: 3,
+		From:      &tgbotapi.User{ID: from},
+		Chat:      chat,
+		Text:      text,
+		Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(name)}},
+	}
+}
+
+func TestHandleCommand(t *testing.T) {
+	private := &tgbotapi.Chat{ID: 42, Type: "private"}
+	group := &tgbotapi.Chat{ID: -100, Type: "group"}
+	sam := &uModel.UserDetails{User: uModel.User{ID: 3, DisplayName: "Sam", CircleID: 1}}
+
+	now := time.Now().UTC()
+	overdue := now.Add(-48 * time.Hour)
+	later := now.Add(72 * time.Hour)
+	chores := []*chModel.Chore{
+		{ID: 1, Name: "Vacuum", AssignedTo: 3, NextDueDate: &overdue},
+		{ID: 2, Name: "Dishes", AssignedTo: 4, NextDueDate: &overdue},
+		{ID: 3, Name: "Laundry", AssignedTo: 3, NextDueDate: &later},
+	}
+
+	tests := []struct {
+		name       string
+		msg        *tgbotapi.Message
+		wantAction string
+		want       []string
+		notWant    []string
+	}{
+		{
+			name:       "overdue in a private chat lists the user's chores",
+			msg:        command("/overdue", private, 42),
+			wantAction: "due",
+			want:       []string{"Overdue:", "#1 Vacuum"},
+			notWant:    []string{"Dishes"},
+		},
+		{
+			name:       "overdue in the circle group lists the circle's chores",
+			msg:        command("/overdue@donetick_bot", group, 42),
+			wantAction: "circle due",
+			want:       []string{"#1 Vacuum", "#2 Dishes"},
+		},
+		{
+			name:    "mine",
+			msg:     command("/mine", private, 42),
+			want:    []string{"Assigned to you:", "#1 Vacuum", "#3 Laundry"},
+			notWant: []string{"Dishes"},
+		},
+		{
+			name: "done",
+			msg:  command("/done #5", private, 42),
+			want: []string{"✅ Vacuum done by Sam, next due"},
+		},
+		{
+			name: "done without an id",
+			msg:  command("/done", private, 42),
+			want: []string{"Usage: /done <id>"},
+		},
+		{
+			name: "add",
+			msg:  command("/add Water plants", private, 42),
+			want: []string{"➕ Added #12 Water plants"},
+		},
+		{
+			name: "unlinked chat",
+			msg:  command("/today", &tgbotapi.Chat{ID: 9, Type: "private"}, 9),
+			want: []string{replyNotLinked},
+		},
+		{
+			name: "group that is not a circle group",
+			msg:  command("/today", &tgbotapi.Chat{ID: -7, Type: "group"}, 42),
+			want: []string{replyGroupNotLinked},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, bot := newStandIn(t)
+			actions := &fakeActions{chores: chores, group: group.ID}
+			tn := &TelegramNotifier{bot: bot, actions: actions, users: &fakeUsers{byChat: map[int64]*uModel.UserDetails{42: sam}}, interactive: true}
+
+			tn.handleCommand(context.Background(), tt.msg)
+
+			if tt.wantAction != "" && actions.called != tt.wantAction {
+				t.Errorf("action = %q, want %q", actions.called, tt.wantAction)
+			}
+			sent := api.last("sendMessage")
+			if sent == nil {
+				t.Fatal("expected a reply")
+			}
+			text := sent.Get("text")
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("reply %q should contain %q", text, want)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(text, notWant) {
+					t.Errorf("reply %q should not contain %q", text, notWant)
+				}
+			}
+			if !tt.msg.Chat.IsPrivate() && sent.Get("reply_to_message_id") != "3" {
+				t.Errorf("group replies should quote the command, got %v", sent)
+			}
+		})
+	}
+}
+
+func TestHandleCommandForAnotherBot(t *testing.T) {
+	api, bot := newStandIn(t)
+	tn := &TelegramNotifier{bot: bot, actions: &fakeActions{}, users: &fakeUsers{}, interactive: true}
+
+	tn.handleCommand(context.Background(), command("/today@other_bot", &tgbotapi.Chat{ID: -100, Type: "group"}, 42))
+
+	if sent := api.last("sendMessage"); sent != nil {
+		t.Errorf("commands for other bots should be ignored, replied %v", sent)
+	}
+}
+
+func TestLinkCommand(t *testing.T) {
+	private := &tgbotapi.Chat{ID: 42, Type: "private"}
+	future := time.Now().UTC().Add(10 * time.Minute)
+	past := time.Now().UTC().Add(-time.Minute)
+
+	api, bot := newStandIn(t)
+	users := &fakeUsers{codes: map[string]*uModel.TelegramLinkCode{
+		"ABCD2345": {Code: "ABCD2345", UserID: 3, ExpiresAt: future},
+		"EXPIRED2": {Code: "EXPIRED2", UserID: 4, ExpiresAt: past},
+	}}
+	tn := &TelegramNotifier{bot: bot, actions: &fakeActions{}, users: users, interactive: true}
+
+	tn.handleCommand(context.Background(), command("/link EXPIRED2", private, 42))
+	if text := api.last("sendMessage").Get("text"); !strings.Contains(text, "invalid or has expired") {
+		t.Errorf("expired codes should be rejected, replied %q", text)
+	}
+
+	tn.handleCommand(context.Background(), command("/start abcd2345", private, 42))
+	if users.linked[3] != 42 {
+		t.Errorf("expected user 3 to be linked to chat 42, got %v", users.linked)
+	}
+	if text := api.last("sendMessage").Get("text"); !strings.Contains(text, "linked") {
+		t.Errorf("unexpected reply %q", text)
+	}
+
+	tn.handleCommand(context.Background(), command("/link ABCD2345", private, 42))
+	if text := api.last("sendMessage").Get("text"); !strings.Contains(text, "invalid or has expired") {
+		t.Errorf("codes should only work once, replied %q", text)
+	}
+}
@@ -7,62 +7,85 @@ import (
 	"strconv"
 
 	"donetick.com/core/config"
-	chModel "donetick.com/core/internal/chore/model"
 	nModel "donetick.com/core/internal/notifier/model"
-	uModel "donetick.com/core/internal/user/model"
+	uRepo "donetick.com/core/internal/user/repo"
 	"donetick.com/core/logging"
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.uber.org/fx"
 )
 
 type TelegramNotifier struct {
-	bot *tgbotapi.BotAPI
+	bot         *tgbotapi.BotAPI
+	actions     ChoreActions
+	users       userStore
+	interactive bool
+	stop        context.CancelFunc
 }
 
-func NewTelegramNotifier(config *config.Config) *TelegramNotifier {
-	bot, err := tgbotapi.NewBotAPI(config.Telegram.Token)
+// NewTelegramNotifier returns a notifier without a bot when telegram has no token or is disabled in
+// notification.providers, the registry skips it and the bot never polls for updates
+func NewTelegramNotifier(lc fx.Lifecycle, config *config.Config, actions ChoreActions, ur *uRepo.UserRepository) *TelegramNotifier {
+	disabled := &TelegramNotifier{}
+	if config.Telegram.Token == "" || !config.Notification.IsProviderEnabled(disabled.Name()) {
+		return disabled
+	}
+	endpoint := config.Telegram.APIEndpoint
+	if endpoint == "" {
+		endpoint = tgbotapi.APIEndpoint
+	}
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(config.Telegram.Token, endpoint)
 	if err != nil {
-		fmt.Println("Error creating bot: ", err)
-		return nil
+		logging.DefaultLogger().Errorw("Error creating telegram bot", "error", err)
+		return disabled
 	}
 
-	return &TelegramNotifier{
-		bot: bot,
+	tn := &TelegramNotifier{
+		bot:         bot,
+		actions:     actions,
+		users:       ur,
+		interactive: config.Telegram.Interactive,
 	}
-}
-
-func (tn *TelegramNotifier) SendChoreCompletion(c context.Context, chore *chModel.Chore, user *uModel.User) {
-
-	log := logging.FromContext(c)
-	if tn == nil {
-		log.Error("Telegram bot is not initialized, Skipping sending message")
-		return
+	if tn.interactive {
+		lc.Append(fx.Hook{
+			OnStart: func(ctx context.Context) error {
+				tn.Start(context.Background())
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				tn.Stop()
+				return nil
+			},
+		})
 	}
+	return tn
+}
 
-	targets := []int64{}
-	if user.ChatID != 0 {
-		targets = append(targets, user.ChatID)
-	}
-	if chore.NotificationMetadataV2.CircleGroup && chore.NotificationMetadataV2.CircleGroupID != nil {
-		// attempt to parse it:
+func (tn *TelegramNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformTelegram
+}
 
-		if *chore.NotificationMetadataV2.CircleGroupID != 0 {
-			targets = append(targets, *chore.NotificationMetadataV2.CircleGroupID)
-		}
+func (tn *TelegramNotifier) Name() string {
+	return "telegram"
+}
 
-	}
+func (tn *TelegramNotifier) Capabilities() nModel.ProviderCapabilities {
+	return nModel.ProviderCapabilities{Markdown: true}
+}
 
-	text := fmt.Sprintf("🎉 *%s* is completed! is off the list, %s! 🌟 ", chore.Name, user.DisplayName)
-	for _, target := range targets {
-		msg := tgbotapi.NewMessage(target, text)
+func (tn *TelegramNotifier) IsConfigured() bool {
+	return tn != nil && tn.bot != nil
+}
 
-		msg.ParseMode = "Markdown"
-		_, err := tn.bot.Send(msg)
-		if err != nil {
-			log.Error("Error sending message to user: ", err)
-			log.Debug("Error sending message, chore: ", chore.Name, " user: ", user.DisplayName, " chatID: ", user.ChatID, " user id: ", user.ID)
-		}
+// ValidateTarget checks the target is a telegram chat id
+func (tn *TelegramNotifier) ValidateTarget(target string) error {
+	if _, err := strconv.ParseInt(target, 10, 64); err != nil {
+		return fmt.Errorf("invalid telegram chat id %q", target)
 	}
+	return nil
+}
 
+func (tn *TelegramNotifier) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	return tn.SendNotification(c, notification)
 }
 
 func (tn *TelegramNotifier) SendNotification(c context.Context, notification *nModel.NotificationDetails) error {
@@ -78,6 +101,9 @@ func (tn *TelegramNotifier) SendNotification(c context.Context, notification *nM
 
 	msg := tgbotapi.NewMessage(chatID, notification.Text)
 	msg.ParseMode = "Markdown"
+	if tn.interactive && notification.ChoreID != 0 && isReminder(notification.EventType) {
+		msg.ReplyMarkup = reminderKeyboard(notification.ChoreID)
+	}
 	_, err = tn.bot.Send(msg)
 	if err != nil {
 		log.Error("Error sending message to user: ", err)
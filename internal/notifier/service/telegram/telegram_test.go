@@ -0,0 +1,38 @@
+package telegram
+
+import (
+	"testing"
+
+	"donetick.com/core/config"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestNewTelegramNotifierDisabled(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+	}{
+		{name: "no token"},
+		{
+			name: "not in providers",
+			cfg: config.Config{
+				Telegram:     config.TelegramConfig{Token: "token", Interactive: true},
+				Notification: config.NotificationConfig{Providers: []string{"email"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lc := fxtest.NewLifecycle(t)
+			tn := NewTelegramNotifier(lc, &tt.cfg, &fakeActions{}, nil)
+			if tn == nil {
+				t.Fatal("NewTelegramNotifier() = nil, want a disabled notifier")
+			}
+			if tn.IsConfigured() {
+				t.Error("IsConfigured() = true for a disabled notifier")
+			}
+			// Start panics on the missing bot if the polling hook was registered
+			lc.RequireStart().RequireStop()
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	nModel "donetick.com/core/internal/notifier/model"
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const snoozeDuration = time.Hour
+
+const (
+	actionDone   = "done"
+	actionSkip   = "skip"
+	actionSnooze = "snooze"
+)
+
+// ChoreActions performs the chore actions behind the reminder buttons and bot commands, it is the
+// same logic the chore endpoints use
+type ChoreActions interface {
+	CompleteChore(c context.Context, choreID int, user *uModel.UserDetails) (*chModel.Chore, error)
+	SkipChore(c context.Context, choreID int, user *uModel.UserDetails) (*chModel.Chore, error)
+	SnoozeChore(c context.Context, choreID int, user *uModel.UserDetails, until time.Time) (*chModel.Chore, error)
+	CreateChore(c context.Context, user *uModel.UserDetails, name string, dueDate *time.Time) (*chModel.Chore, error)
+	ChoresDueBefore(c context.Context, user *uModel.UserDetails, circleWide bool, before time.Time) ([]*chModel.Chore, error)
+	AssignedChores(c context.Context, user *uModel.UserDetails) ([]*chModel.Chore, error)
+	IsCircleGroup(c context.Context, user *uModel.UserDetails, chatID int64) (bool, error)
+}
+
+type userStore interface {
+	GetUserByTelegramChatID(c context.Context, chatID int64) (*uModel.UserDetails, error)
+	ConsumeTelegramLinkCode(c context.Context, code string, now time.Time) (*uModel.TelegramLinkCode, error)
+	LinkTelegramChat(c context.Context, userID int, chatID int64) error
+}
+
+func isReminder(eventType nModel.EventType) bool {
+	switch eventType {
+	case nModel.EventTypePreDue, nModel.EventTypeDue, nModel.EventTypeOverdue, nModel.EventTypeNagging:
+		return true
+	}
+	return false
+}
+
+func reminderKeyboard(choreID int) tgbotapi.InlineKeyboardMarkup {
+	id := strconv.Itoa(choreID)
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ Done", actionDone+":"+id),
+		tgbotapi.NewInlineKeyboardButtonData("⏭ Skip", actionSkip+":"+id),
+		tgbotapi.NewInlineKeyboardButtonData("😴 Snooze 1h", actionSnooze+":"+id),
+	))
+}
+
+func parseCallbackData(data string) (string, int, error) {
+	action, rawID, found := strings.Cut(data, ":")
+	if !found {
+		return "", 0, fmt.Errorf("invalid callback data %q", data)
+	}
+	switch action {
+	case actionDone, actionSkip, actionSnooze:
+	default:
+		return "", 0, fmt.Errorf("unknown action %q", action)
+	}
+	choreID, err := strconv.Atoi(rawID)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid chore id %q", rawID)
+	}
+	return action, choreID, nil
+}
+
+// Start long polls the Bot API for button presses and commands until Stop is called
+func (tn *TelegramNotifier) Start(ctx context.Context) {
+	ctx, tn.stop = context.WithCancel(ctx)
+	config := tgbotapi.NewUpdate(0)
+	config.Timeout = 60
+	config.AllowedUpdates = []string{"message", "callback_query"}
+	updates := tn.bot.GetUpdatesChan(config)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				switch {
+				case update.CallbackQuery != nil:
+					tn.handleCallback(ctx, update.CallbackQuery)
+				case update.Message != nil && update.Message.IsCommand():
+					tn.handleCommand(ctx, update.Message)
+				}
+			}
+		}
+	}()
+}
+
+func (tn *TelegramNotifier) Stop() {
+	if tn.stop == nil {
+		return
+	}
+	tn.stop()
+	tn.bot.StopReceivingUpdates()
+}
+
+// handleCallback runs the action of a pressed button and replaces the buttons with its outcome
+func (tn *TelegramNotifier) handleCallback(c context.Context, query *tgbotapi.CallbackQuery) {
+	log := logging.FromContext(c)
+	action, choreID, err := parseCallbackData(query.Data)
+	if err != nil || query.Message == nil {
+		tn.answer(c, query.ID, "This button is no longer supported")
+		return
+	}
+
+	// in group chats the member who pressed the button acts, their private chat id is their user id
+	chatID := query.Message.Chat.ID
+	if !query.Message.Chat.IsPrivate() && query.From != nil {
+		chatID = query.From.ID
+	}
+	user, err := tn.users.GetUserByTelegramChatID(c, chatID)
+	if err != nil {
+		log.Errorw("Error getting user for telegram chat", "chat_id", chatID, "error", err)
+		tn.answer(c, query.ID, replyFailed)
+		return
+	}
+	if user == nil {
+		tn.answer(c, query.ID, "This Telegram account is not linked to Donetick")
+		return
+	}
+
+	var chore *chModel.Chore
+	var result string
+	switch action {
+	case actionDone:
+		chore, err = tn.actions.CompleteChore(c, choreID, user)
+		if err == nil {
+			result = fmt.Sprintf("✅ Done by %s", user.DisplayName)
+		}
+	case actionSkip:
+		chore, err = tn.actions.SkipChore(c, choreID, user)
+		if err == nil {
+			result = fmt.Sprintf("⏭ Skipped by %s", user.DisplayName)
+		}
+	case actionSnooze:
+		chore, err = tn.actions.SnoozeChore(c, choreID, user, time.Now().UTC().Add(snoozeDuration))
+		if err == nil {
+			result = "😴 Snoozed for 1 hour"
+		}
+	}
+	if err != nil {
+		log.Debugw("Telegram action failed", "action", action, "chore_id", choreID, "user_id", user.ID, "error", err)
+		tn.answer(c, query.ID, actionFailureReply(err))
+		return
+	}
+	if chore != nil && action != actionSnooze && chore.NextDueDate != nil {
+		result += fmt.Sprintf(", next due %s", chore.NextDueDate.In(userLocation(user)).Format(dueDateFormat))
+	}
+
+	tn.answer(c, query.ID, result)
+	// the original text comes back without its formatting, so the edit is sent as plain text
+	edit := tgbotapi.NewEditMessageText(query.Message.Chat.ID, query.Message.MessageID, query.Message.Text+"\n\n"+result)
+	if _, err := tn.bot.Request(edit); err != nil {
+		log.Errorw("Error editing telegram message", "chat_id", query.Message.Chat.ID, "error", err)
+	}
+}
+
+func (tn *TelegramNotifier) answer(c context.Context, queryID string, text string) {
+	if _, err := tn.bot.Request(tgbotapi.NewCallback(queryID, text)); err != nil {
+		logging.FromContext(c).Errorw("Error answering telegram callback", "error", err)
+	}
+}
+
+func actionFailureReply(err error) string {
+	switch {
+	case errors.Is(err, chModel.ErrNotAssigned):
+		return "You are not assigned to this chore"
+	case errors.Is(err, chModel.ErrChoreNotInCircle):
+		return "This chore is not in your circle"
+	case errors.Is(err, chModel.ErrOutsideCompletionWindow):
+		return "This chore can't be completed yet"
+	case errors.Is(err, chModel.ErrChoreWithoutNextDueDate):
+		return "This chore has no due date"
+	default:
+		return replyFailed
+	}
+}
@@ -0,0 +1,285 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	nModel "donetick.com/core/internal/notifier/model"
+	uModel "donetick.com/core/internal/user/model"
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// standIn is a local Bot API that records the calls made to it
+type standIn struct {
+	mu    sync.Mutex
+	calls map[string][]url.Values
+}
+
+func newStandIn(t *testing.T) (*standIn, *tgbotapi.BotAPI) {
+	t.Helper()
+	api := &standIn{calls: make(map[string][]url.Values)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		r.ParseForm()
+		api.mu.Lock()
+		api.calls[method] = append(api.calls[method], r.PostForm)
+		api.mu.Unlock()
+
+		var result interface{}
+		switch method {
+		case "getMe":
+			result = tgbotapi.User{ID: 1, IsBot: true, FirstName: "Donetick", UserName: "donetick_bot"}
+		case "answerCallbackQuery":
+			result = true
+		default:
+			result = tgbotapi.Message{MessageID: 7, Chat: &tgbotapi.Chat{ID: 42}}
+		}
+		raw, _ := json.Marshal(result)
+		json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: raw})
+	}))
+	t.Cleanup(server.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint("token", server.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return api, bot
+}
+
+func (s *standIn) last(method string) url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	calls := s.calls[method]
+	if len(calls) == 0 {
+		return nil
+	}
+	return calls[len(calls)-1]
+}
+
+type fakeActions struct {
+	err     error
+	called  string
+	until   time.Time
+	chores  []*chModel.Chore
+	group   int64
+	created *chModel.Chore
+}
+
+func (f *fakeActions) result(action string, choreID int) (*chModel.Chore, error) {
+	f.called = action
+	if f.err != nil {
+		return nil, f.err
+	}
+	next := time.Date(2026, time.March, 11, 9, 0, 0, 0, time.UTC)
+	return &chModel.Chore{ID: choreID, Name: "Vacuum", NextDueDate: &next}, nil
+}
+
+func (f *fakeActions) CompleteChore(c context.Context, choreID int, user *uModel.UserDetails) (*chModel.Chore, error) {
+	return f.result(actionDone, choreID)
+}
+
+func (f *fakeActions) SkipChore(c context.Context, choreID int, user *uModel.UserDetails) (*chModel.Chore, error) {
+	return f.result(actionSkip, choreID)
+}
+
+func (f *fakeActions) SnoozeChore(c context.Context, choreID int, user *uModel.UserDetails, until time.Time) (*chModel.Chore, error) {
+	f.until = until
+	return f.result(actionSnooze, choreID)
+}
+
+func (f *fakeActions) CreateChore(c context.Context, user *uModel.UserDetails, name string, dueDate *time.Time) (*chModel.Chore, error) {
+	f.created = &chModel.Chore{ID: 12, Name: name, NextDueDate: dueDate, AssignedTo: user.ID}
+	return f.created, f.err
+}
+
+func (f *fakeActions) ChoresDueBefore(c context.Context, user *uModel.UserDetails, circleWide bool, before time.Time) ([]*chModel.Chore, error) {
+	f.called = "due"
+	if circleWide {
+		f.called = "circle due"
+	}
+	var chores []*chModel.Chore
+	for _, chore := range f.chores {
+		if chore.NextDueDate != nil && chore.NextDueDate.Before(before) && (circleWide || chore.AssignedTo == user.ID) {
+			chores = append(chores, chore)
+		}
+	}
+	return chores, f.err
+}
+
+func (f *fakeActions) AssignedChores(c context.Context, user *uModel.UserDetails) ([]*chModel.Chore, error) {
+	var chores []*chModel.Chore
+	for _, chore := range f.chores {
+		if chore.AssignedTo == user.ID {
+			chores = append(chores, chore)
+		}
+	}
+	return chores, f.err
+}
+
+func (f *fakeActions) IsCircleGroup(c context.Context, user *uModel.UserDetails, chatID int64) (bool, error) {
+	return f.group != 0 && f.group == chatID, nil
+}
+
+type fakeUsers struct {
+	byChat map[int64]*uModel.UserDetails
+	codes  map[string]*uModel.TelegramLinkCode
+	linked map[int]int64
+}
+
+func (f *fakeUsers) GetUserByTelegramChatID(c context.Context, chatID int64) (*uModel.UserDetails, error) {
+	return f.byChat[chatID], nil
+}
+
+func (f *fakeUsers) ConsumeTelegramLinkCode(c context.Context, code string, now time.Time) (*uModel.TelegramLinkCode, error) {
+	linkCode, ok := f.codes[code]
+	if !ok {
+		return nil, nil
+	}
+	delete(f.codes, code)
+	if !linkCode.ExpiresAt.After(now) {
+		return nil, nil
+	}
+	return linkCode, nil
+}
+
+func (f *fakeUsers) LinkTelegramChat(c context.Context, userID int, chatID int64) error {
+	if f.linked == nil {
+		f.linked = make(map[int]int64)
+	}
+	f.linked[userID] = chatID
+	return nil
+}
+
+func callback(data string, chat *tgbotapi.Chat, from int64) *tgbotapi.CallbackQuery {
+	return &tgbotapi.CallbackQuery{
+		ID:   "query-1",
+		From: &tgbotapi.User{ID: from},
+		Data: data,
+		Message: &tgbotapi.Message{
+			MessageID: 7,
+			Chat:      chat,
+			Text:      "📅 Reminder: Vacuum is due today and assigned to Sam.",
+		},
+	}
+}
+
+func TestSendNotificationKeyboard(t *testing.T) {
+	api, bot := newStandIn(t)
+	tn := &TelegramNotifier{bot: bot, interactive: true}
+
+	reminder := &nModel.NotificationDetails{Notification: nModel.Notification{ChoreID: 5, TargetID: "42", EventType: nModel.EventTypeDue, Text: "due"}}
+	if err := tn.SendNotification(context.Background(), reminder); err != nil {
+		t.Fatal(err)
+	}
+	var markup tgbotapi.InlineKeyboardMarkup
+	if err := json.Unmarshal([]byte(api.last("sendMessage").Get("reply_markup")), &markup); err != nil {
+		t.Fatalf("expected an inline keyboard: %v", err)
+	}
+	var data []string
+	for _, button := range markup.InlineKeyboard[0] {
+		data = append(data, *button.CallbackData)
+	}
+	if strings.Join(data, ",") != "done:5,skip:5,snooze:5" {
+		t.Errorf("unexpected buttons %v", data)
+	}
+
+	completion := &nModel.NotificationDetails{Notification: nModel.Notification{ChoreID: 5, TargetID: "42", EventType: nModel.EventTypeCompletion, Text: "done"}}
+	if err := tn.SendNotification(context.Background(), completion); err != nil {
+		t.Fatal(err)
+	}
+	if markup := api.last("sendMessage").Get("reply_markup"); markup != "" {
+		t.Errorf("completion messages should not have buttons, got %s", markup)
+	}
+}
+
+func TestHandleCallback(t *testing.T) {
+	private := &tgbotapi.Chat{ID: 42, Type: "private"}
+	group := &tgbotapi.Chat{ID: -100, Type: "group"}
+	sam := &uModel.UserDetails{User: uModel.User{ID: 3, DisplayName: "Sam", Timezone: "Europe/Berlin"}}
+
+	tests := []struct {
+		name       string
+		query      *tgbotapi.CallbackQuery
+		err        error
+		wantAction string
+		wantAnswer string
+		wantEdit   string
+	}{
+		{
+			name:       "done in a private chat",
+			query:      callback("done:5", private, 42),
+			wantAction: actionDone,
+			wantAnswer: "✅ Done by Sam, next due Wed, Mar 11 10:00",
+			wantEdit:   "📅 Reminder: Vacuum is due today and assigned to Sam.\n\n✅ Done by Sam, next due Wed, Mar 11 10:00",
+		},
+		{
+			name:       "skip from a group maps the member who pressed it",
+			query:      callback("skip:5", group, 42),
+			wantAction: actionSkip,
+			wantAnswer: "⏭ Skipped by Sam, next due Wed, Mar 11 10:00",
+		},
+		{
+			name:       "snooze",
+			query:      callback("snooze:5", private, 42),
+			wantAction: actionSnooze,
+			wantAnswer: "😴 Snoozed for 1 hour",
+		},
+		{
+			name:       "chat without a user",
+			query:      callback("done:5", &tgbotapi.Chat{ID: 9, Type: "private"}, 9),
+			wantAnswer: "This Telegram account is not linked to Donetick",
+		},
+		{
+			name:       "not assigned",
+			query:      callback("done:5", private, 42),
+			err:        chModel.ErrNotAssigned,
+			wantAction: actionDone,
+			wantAnswer: "You are not assigned to this chore",
+		},
+		{
+			name:       "unknown button",
+			query:      callback("archive:5", private, 42),
+			wantAnswer: "This button is no longer supported",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, bot := newStandIn(t)
+			actions := &fakeActions{err: tt.err}
+			tn := &TelegramNotifier{bot: bot, actions: actions, users: &fakeUsers{byChat: map[int64]*uModel.UserDetails{42: sam}}, interactive: true}
+
+			tn.handleCallback(context.Background(), tt.query)
+
+			if actions.called != tt.wantAction {
+				t.Errorf("action = %q, want %q", actions.called, tt.wantAction)
+			}
+			if got := api.last("answerCallbackQuery").Get("text"); got != tt.wantAnswer {
+				t.Errorf("answer = %q, want %q", got, tt.wantAnswer)
+			}
+			edit := api.last("editMessageText")
+			if tt.wantAction == "" || tt.err != nil {
+				if edit != nil {
+					t.Errorf("the message should not be edited, got %v", edit)
+				}
+				return
+			}
+			if edit == nil || edit.Get("message_id") != "7" || edit.Get("reply_markup") != "" {
+				t.Fatalf("expected the buttons to be replaced, got %v", edit)
+			}
+			if tt.wantEdit != "" && edit.Get("text") != tt.wantEdit {
+				t.Errorf("edited text = %q, want %q", edit.Get("text"), tt.wantEdit)
+			}
+			if tt.wantAction == actionSnooze && time.Until(actions.until) < 59*time.Minute {
+				t.Errorf("expected a snooze of an hour, got %v", actions.until)
+			}
+		})
+	}
+}
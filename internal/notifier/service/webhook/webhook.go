@@ -0,0 +1,180 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+// Target is the user's HTTP endpoint, stored as JSON in the notification target:
+//
+//	{"url": "https://ha.local/api/webhook/chores", "method": "POST",
+//	 "headers": {"Authorization": "Bearer xyz"}, "body": "{\"message\": {{json .PlainText}}}"}
+//
+// a bare URL is accepted as well and gets the default JSON body.
+type Target struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// TemplateData is what the body template is executed with
+type TemplateData struct {
+	Text         string
+	PlainText    string
+	ChoreID      int
+	ChoreURL     string
+	EventType    string
+	Priority     int
+	ScheduledFor time.Time
+	Event        map[string]interface{}
+}
+
+const defaultBody = `{"text": {{json .Text}}, "chore_id": {{.ChoreID}}, "url": {{json .ChoreURL}}, "event_type": {{json .EventType}}, "event": {{json .Event}}}`
+
+var allowedMethods = map[string]bool{
+	http.MethodGet:   true,
+	http.MethodPost:  true,
+	http.MethodPut:   true,
+	http.MethodPatch: true,
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"plain": nModel.StripEmphasis,
+}
+
+type WebhookNotifier struct {
+	client  *http.Client
+	appHost string
+}
+
+func NewWebhookNotifier(cfg *config.Config) *WebhookNotifier {
+	timeout := cfg.WebhookConfig.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &WebhookNotifier{
+		client:  &http.Client{Timeout: timeout},
+		appHost: cfg.EmailConfig.AppHost,
+	}
+}
+
+func (w *WebhookNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformWebhook
+}
+
+func (w *WebhookNotifier) Name() string {
+	return "webhook"
+}
+
+func (w *WebhookNotifier) Capabilities() nModel.ProviderCapabilities {
+	// the text keeps its markdown, templates can use {{plain .Text}} or .PlainText instead
+	return nModel.ProviderCapabilities{Markdown: true}
+}
+
+// IsConfigured is always true, webhook targets carry their own endpoint
+func (w *WebhookNotifier) IsConfigured() bool {
+	return w != nil
+}
+
+func (w *WebhookNotifier) ValidateTarget(target string) error {
+	_, _, err := parseTarget(target)
+	return err
+}
+
+func (w *WebhookNotifier) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	if notification.TargetID == "" {
+		// webhook users without their own endpoint only get the circle webhook from the events producer
+		return nil
+	}
+	target, bodyTemplate, err := parseTarget(notification.TargetID)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := bodyTemplate.Execute(&body, TemplateData{
+		Text:         notification.Text,
+		PlainText:    nModel.StripEmphasis(notification.Text),
+		ChoreID:      notification.ChoreID,
+		ChoreURL:     notification.ChoreURL(w.appHost),
+		EventType:    string(notification.EventType),
+		Priority:     notification.Priority(),
+		ScheduledFor: notification.ScheduledFor,
+		Event:        notification.RawEvent,
+	}); err != nil {
+		return fmt.Errorf("error rendering webhook body: %w", err)
+	}
+
+	var reqBody io.Reader
+	if target.Method != http.MethodGet {
+		reqBody = &body
+	}
+	req, err := http.NewRequestWithContext(c, target.Method, target.URL, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range target.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// parseTarget decodes and validates the target, filling in the defaults
+func parseTarget(raw string) (*Target, *template.Template, error) {
+	raw = strings.TrimSpace(raw)
+	target := &Target{}
+	if strings.HasPrefix(raw, "{") {
+		if err := json.Unmarshal([]byte(raw), target); err != nil {
+			return nil, nil, fmt.Errorf("invalid webhook target: %w", err)
+		}
+	} else {
+		target.URL = raw
+	}
+
+	u, err := url.Parse(target.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, nil, errors.New("webhook target must have an http(s) url")
+	}
+	target.Method = strings.ToUpper(target.Method)
+	if target.Method == "" {
+		target.Method = http.MethodPost
+	}
+	if !allowedMethods[target.Method] {
+		return nil, nil, fmt.Errorf("unsupported webhook method %q", target.Method)
+	}
+	if target.Body == "" {
+		target.Body = defaultBody
+	}
+	bodyTemplate, err := template.New("body").Funcs(templateFuncs).Option("missingkey=zero").Parse(target.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid webhook body template: %w", err)
+	}
+	return target, bodyTemplate, nil
+}
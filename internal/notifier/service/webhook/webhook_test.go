@@ -0,0 +1,130 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+type capturedRequest struct {
+	method string
+	header http.Header
+	body   string
+}
+
+func newCaptureServer(t *testing.T, status int) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+	captured := &capturedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured.method = r.Method
+		captured.header = r.Header.Clone()
+		body, _ := io.ReadAll(r.Body)
+		captured.body = string(body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, captured
+}
+
+func targetJSON(t *testing.T, target Target) string {
+	t.Helper()
+	b, err := json.Marshal(target)
+	if err != nil {
+		t.Fatalf("error marshalling target: %v", err)
+	}
+	return string(b)
+}
+
+func testNotification(target string) *nModel.NotificationDetails {
+	return &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			ChoreID:   3,
+			TargetID:  target,
+			TypeID:    nModel.NotificationPlatformWebhook,
+			EventType: nModel.EventTypeDue,
+			Text:      "📅 Reminder: *Laundry* is due today",
+			RawEvent:  nModel.JSONB{"name": "Laundry", "priority": float64(2)},
+		},
+	}
+}
+
+func TestWebhookNotifierSend(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.EmailConfig.AppHost = "https://app.example.com"
+	notifier := NewWebhookNotifier(cfg)
+
+	t.Run("custom method, headers and body", func(t *testing.T) {
+		server, captured := newCaptureServer(t, http.StatusOK)
+		target := targetJSON(t, Target{
+			URL:     server.URL + "/api/webhook/chores",
+			Method:  "put",
+			Headers: map[string]string{"Authorization": "Bearer ha-token", "Content-Type": "text/plain"},
+			Body:    `{{.Event.name}} P{{.Priority}} {{plain .Text}} {{.ChoreURL}}`,
+		})
+		if err := notifier.Send(context.Background(), testNotification(target)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if captured.method != http.MethodPut {
+			t.Errorf("expected PUT, got %s", captured.method)
+		}
+		if captured.header.Get("Authorization") != "Bearer ha-token" || captured.header.Get("Content-Type") != "text/plain" {
+			t.Errorf("expected custom headers, got %v", captured.header)
+		}
+		want := "Laundry P2 📅 Reminder: Laundry is due today https://app.example.com/chores/3"
+		if captured.body != want {
+			t.Errorf("body = %q, want %q", captured.body, want)
+		}
+	})
+
+	t.Run("bare url gets the default json body", func(t *testing.T) {
+		server, captured := newCaptureServer(t, http.StatusNoContent)
+		if err := notifier.Send(context.Background(), testNotification(server.URL)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal([]byte(captured.body), &body); err != nil {
+			t.Fatalf("expected a json body, got %q: %v", captured.body, err)
+		}
+		if captured.method != http.MethodPost || body["text"] != "📅 Reminder: *Laundry* is due today" || body["url"] != "https://app.example.com/chores/3" {
+			t.Errorf("unexpected default request %s %v", captured.method, body)
+		}
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		server, _ := newCaptureServer(t, http.StatusInternalServerError)
+		if err := notifier.Send(context.Background(), testNotification(server.URL)); err == nil {
+			t.Error("expected an error for a failed request")
+		}
+	})
+
+	t.Run("empty target is left to the circle webhook", func(t *testing.T) {
+		if err := notifier.Send(context.Background(), testNotification("")); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestWebhookValidateTarget(t *testing.T) {
+	notifier := NewWebhookNotifier(&config.Config{})
+	tests := map[string]bool{
+		"https://hooks.example.com/chores":                               true,
+		`{"url": "http://ha.local:8123/api/webhook/x", "method": "GET"}`: true,
+		`{"url": "https://example.com", "body": "{{.Text}}"}`:            true,
+		`{"url": "https://example.com", "method": "DELETE"}`:             false,
+		`{"url": "https://example.com", "body": "{{.Text"}`:              false,
+		`{"url": "ftp://example.com"}`:                                   false,
+		`{"url": `:                                                       false,
+		"not a url":                                                      false,
+	}
+	for target, valid := range tests {
+		if err := notifier.ValidateTarget(target); (err == nil) != valid {
+			t.Errorf("ValidateTarget(%q) error = %v, want valid %v", target, err, valid)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package webpush
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	nRepo "donetick.com/core/internal/notifier/repo"
+	uModel "donetick.com/core/internal/user/model"
+	uRepo "donetick.com/core/internal/user/repo"
+	"donetick.com/core/logging"
+	webpush "github.com/SherClockHolmes/webpush-go"
+)
+
+const (
+	defaultSubject = "https://donetick.com"
+	// push services keep undelivered messages for at most this long while the device is offline
+	messageTTL = 24 * time.Hour
+)
+
+// Payload is the JSON the service worker receives in the push event
+type Payload struct {
+	Title     string `json:"title"`
+	Body      string `json:"body"`
+	URL       string `json:"url,omitempty"`
+	ChoreID   int    `json:"choreId,omitempty"`
+	EventType string `json:"eventType,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+}
+
+// VAPIDKeys provides the server key pair, taken from the config or generated once and stored
+// in the database. keys are loaded lazily since the tables are migrated after startup
+type VAPIDKeys struct {
+	cfg              config.WebPushConfig
+	notificationRepo *nRepo.NotificationRepository
+	mu               sync.Mutex
+	publicKey        string
+	privateKey       string
+}
+
+func NewVAPIDKeys(cfg *config.Config, nr *nRepo.NotificationRepository) *VAPIDKeys {
+	return &VAPIDKeys{
+		cfg:              cfg.WebPush,
+		notificationRepo: nr,
+		publicKey:        cfg.WebPush.PublicKey,
+		privateKey:       cfg.WebPush.PrivateKey,
+	}
+}
+
+// Get returns the public and private key, generating and storing a pair when there is none yet
+func (k *VAPIDKeys) Get(c context.Context) (string, string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.publicKey != "" && k.privateKey != "" {
+		return k.publicKey, k.privateKey, nil
+	}
+
+	stored, err := k.notificationRepo.GetVAPIDKey(c)
+	if err != nil {
+		return "", "", err
+	}
+	if stored == nil {
+		privateKey, publicKey, err := webpush.GenerateVAPIDKeys()
+		if err != nil {
+			return "", "", fmt.Errorf("error generating VAPID keys: %w", err)
+		}
+		stored = &nModel.VAPIDKey{PublicKey: publicKey, PrivateKey: privateKey, CreatedAt: time.Now().UTC()}
+		if err := k.notificationRepo.CreateVAPIDKey(c, stored); err != nil {
+			return "", "", err
+		}
+		logging.FromContext(c).Info("Generated VAPID keys for web push")
+	}
+	k.publicKey, k.privateKey = stored.PublicKey, stored.PrivateKey
+	return k.publicKey, k.privateKey, nil
+}
+
+// Subject is the contact the push services can reach the operator at, a mailto: or https: URL
+func (k *VAPIDKeys) Subject() string {
+	if k.cfg.Subject != "" {
+		return k.cfg.Subject
+	}
+	return defaultSubject
+}
+
+type subscriptionStore interface {
+	GetPushSubscriptions(c context.Context, userID int) ([]*uModel.PushSubscription, error)
+	DeletePushSubscriptionByEndpoint(c context.Context, endpoint string) error
+	TouchPushSubscription(c context.Context, subscriptionID int) error
+}
+
+// WebPushNotifier delivers to every browser the user subscribed, the notification target is not used
+type WebPushNotifier struct {
+	keys          *VAPIDKeys
+	subscriptions subscriptionStore
+	client        *http.Client
+	appHost       string
+}
+
+func NewWebPushNotifier(cfg *config.Config, keys *VAPIDKeys, ur *uRepo.UserRepository) *WebPushNotifier {
+	return &WebPushNotifier{
+		keys:          keys,
+		subscriptions: ur,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		appHost:       cfg.EmailConfig.AppHost,
+	}
+}
+
+func (w *WebPushNotifier) Platform() nModel.NotificationPlatform {
+	return nModel.NotificationPlatformWebPush
+}
+
+func (w *WebPushNotifier) Name() string {
+	return "webpush"
+}
+
+func (w *WebPushNotifier) Capabilities() nModel.ProviderCapabilities {
+	return nModel.ProviderCapabilities{Priority: true}
+}
+
+// IsConfigured is always true, keys are generated when they are not configured
+func (w *WebPushNotifier) IsConfigured() bool {
+	return w != nil
+}
+
+// ValidateTarget accepts any target, web push goes to the user's subscriptions instead
+func (w *WebPushNotifier) ValidateTarget(target string) error {
+	return nil
+}
+
+func (w *WebPushNotifier) Send(c context.Context, notification *nModel.NotificationDetails) error {
+	log := logging.FromContext(c)
+	subscriptions, err := w.subscriptions.GetPushSubscriptions(c, notification.UserID)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return errors.New("user has no web push subscriptions")
+	}
+	publicKey, privateKey, err := w.keys.Get(c)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(buildPayload(notification, notification.ChoreURL(w.appHost)))
+	if err != nil {
+		return err
+	}
+	options := &webpush.Options{
+		HTTPClient:      w.client,
+		Subscriber:      strings.TrimPrefix(w.keys.Subject(), "mailto:"),
+		TTL:             int(messageTTL.Seconds()),
+		Urgency:         urgencyForPriority(notification.Priority()),
+		VAPIDPublicKey:  publicKey,
+		VAPIDPrivateKey: privateKey,
+	}
+
+	var errs []error
+	delivered := 0
+	for _, subscription := range subscriptions {
+		expired, err := w.push(c, payload, subscription, options)
+		if expired {
+			log.Infow("Removing expired web push subscription", "subscriptionID", subscription.ID, "userID", subscription.UserID)
+			if err := w.subscriptions.DeletePushSubscriptionByEndpoint(c, subscription.Endpoint); err != nil {
+				log.Errorw("Error removing expired web push subscription", "subscriptionID", subscription.ID, "error", err)
+			}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delivered++
+		if err := w.subscriptions.TouchPushSubscription(c, subscription.ID); err != nil {
+			log.Debugw("Error updating web push subscription", "subscriptionID", subscription.ID, "error", err)
+		}
+	}
+	// one reachable browser is enough, the failures are retried only when nothing got through
+	if delivered == 0 && len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// push sends the encrypted payload to a single subscription, expired is true when the push
+// service no longer knows the subscription and it should be removed
+func (w *WebPushNotifier) push(c context.Context, payload []byte, subscription *uModel.PushSubscription, options *webpush.Options) (bool, error) {
+	resp, err := webpush.SendNotificationWithContext(c, payload, &webpush.Subscription{
+		Endpoint: subscription.Endpoint,
+		Keys:     webpush.Keys{P256dh: subscription.P256dh, Auth: subscription.Auth},
+	}, options)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return true, nil
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("push service returned unexpected status: %s", resp.Status)
+	}
+	return false, nil
+}
+
+func buildPayload(notification *nModel.NotificationDetails, choreURL string) Payload {
+	payload := Payload{
+		Title:     "Donetick",
+		Body:      nModel.StripEmphasis(notification.Text),
+		URL:       choreURL,
+		ChoreID:   notification.ChoreID,
+		EventType: string(notification.EventType),
+	}
+	if notification.ChoreID != 0 {
+		// a newer notification for the same chore replaces the older one on the device
+		payload.Tag = fmt.Sprintf("chore-%d", notification.ChoreID)
+	}
+	return payload
+}
+
+func urgencyForPriority(priority int) webpush.Urgency {
+	switch priority {
+	case 1:
+		return webpush.UrgencyHigh
+	case 2, 3:
+		return webpush.UrgencyNormal
+	case 4:
+		return webpush.UrgencyLow
+	default:
+		return webpush.UrgencyNormal
+	}
+}
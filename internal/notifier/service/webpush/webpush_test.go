@@ -0,0 +1,218 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"donetick.com/core/config"
+	nModel "donetick.com/core/internal/notifier/model"
+	uModel "donetick.com/core/internal/user/model"
+	webpush "github.com/SherClockHolmes/webpush-go"
+	"golang.org/x/crypto/hkdf"
+)
+
+type fakeStore struct {
+	subscriptions []*uModel.PushSubscription
+	deleted       []string
+	touched       []int
+}
+
+func (f *fakeStore) GetPushSubscriptions(c context.Context, userID int) ([]*uModel.PushSubscription, error) {
+	return f.subscriptions, nil
+}
+
+func (f *fakeStore) DeletePushSubscriptionByEndpoint(c context.Context, endpoint string) error {
+	f.deleted = append(f.deleted, endpoint)
+	return nil
+}
+
+func (f *fakeStore) TouchPushSubscription(c context.Context, subscriptionID int) error {
+	f.touched = append(f.touched, subscriptionID)
+	return nil
+}
+
+// browser is the user agent side of a subscription
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return &browser{key: key, auth: auth}
+}
+
+func (b *browser) subscription(id int, endpoint string) *uModel.PushSubscription {
+	return &uModel.PushSubscription{
+		ID:       id,
+		UserID:   1,
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(b.auth),
+	}
+}
+
+// decrypt reverses the aes128gcm content coding from RFC 8291 / RFC 8188
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	salt, idLen := body[:16], int(body[20])
+	serverPublic, ciphertext := body[21:21+idLen], body[21+idLen:]
+
+	peer, err := ecdh.P256().NewPublicKey(serverPublic)
+	if err != nil {
+		t.Fatalf("invalid server key in header: %v", err)
+	}
+	secret, err := b.key.ECDH(peer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyInfo := append(append([]byte("WebPush: info\x00"), b.key.PublicKey().Bytes()...), serverPublic...)
+	ikm := expand(t, hkdf.Extract(sha256.New, secret, b.auth), keyInfo, 32)
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	cek := expand(t, prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := expand(t, prk, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("error decrypting payload: %v", err)
+	}
+	plain = bytes.TrimRight(plain, "\x00")
+	if len(plain) == 0 || plain[len(plain)-1] != 0x02 {
+		t.Fatal("missing last record delimiter")
+	}
+	return plain[:len(plain)-1]
+}
+
+func expand(t *testing.T, prk, info []byte, length int) []byte {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, info), out); err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func newTestNotifier(t *testing.T, store *fakeStore) *WebPushNotifier {
+	t.Helper()
+	privateKey, publicKey, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{}
+	cfg.EmailConfig.AppHost = "https://app.example.com"
+	cfg.WebPush.PublicKey = publicKey
+	cfg.WebPush.PrivateKey = privateKey
+	cfg.WebPush.Subject = "mailto:admin@example.com"
+	notifier := NewWebPushNotifier(cfg, NewVAPIDKeys(cfg, nil), nil)
+	notifier.subscriptions = store
+	return notifier
+}
+
+func testNotification() *nModel.NotificationDetails {
+	return &nModel.NotificationDetails{
+		Notification: nModel.Notification{
+			ChoreID:   5,
+			UserID:    1,
+			TypeID:    nModel.NotificationPlatformWebPush,
+			EventType: nModel.EventTypeDue,
+			Text:      "📅 Reminder: *Vacuum* is due today",
+			RawEvent:  nModel.JSONB{"priority": float64(1)},
+		},
+	}
+}
+
+func TestWebPushNotifierSend(t *testing.T) {
+	active, expired := newBrowser(t), newBrowser(t)
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/expired" {
+			w.WriteHeader(http.StatusGone)
+			return
+		}
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	store := &fakeStore{subscriptions: []*uModel.PushSubscription{
+		active.subscription(1, server.URL+"/active"),
+		expired.subscription(2, server.URL+"/expired"),
+	}}
+	if err := newTestNotifier(t, store).Send(context.Background(), testNotification()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if header.Get("Content-Encoding") != "aes128gcm" || header.Get("Urgency") != "high" || header.Get("TTL") != "86400" {
+		t.Errorf("unexpected push headers %v", header)
+	}
+	if !strings.HasPrefix(header.Get("Authorization"), "vapid t=") {
+		t.Errorf("expected a VAPID authorization, got %q", header.Get("Authorization"))
+	}
+
+	var payload Payload
+	if err := json.Unmarshal(active.decrypt(t, body), &payload); err != nil {
+		t.Fatalf("error decoding payload: %v", err)
+	}
+	want := Payload{
+		Title:     "Donetick",
+		Body:      "📅 Reminder: Vacuum is due today",
+		URL:       "https://app.example.com/chores/5",
+		ChoreID:   5,
+		EventType: "due",
+		Tag:       "chore-5",
+	}
+	if payload != want {
+		t.Errorf("payload = %+v, want %+v", payload, want)
+	}
+
+	if len(store.deleted) != 1 || store.deleted[0] != server.URL+"/expired" {
+		t.Errorf("expected the expired subscription to be removed, got %v", store.deleted)
+	}
+	if len(store.touched) != 1 || store.touched[0] != 1 {
+		t.Errorf("expected only the active subscription to be touched, got %v", store.touched)
+	}
+}
+
+func TestWebPushNotifierSendFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	t.Run("every subscription failed", func(t *testing.T) {
+		store := &fakeStore{subscriptions: []*uModel.PushSubscription{newBrowser(t).subscription(1, server.URL)}}
+		if err := newTestNotifier(t, store).Send(context.Background(), testNotification()); err == nil {
+			t.Error("expected an error when no subscription received the notification")
+		}
+		if len(store.deleted) != 0 {
+			t.Errorf("server errors must not remove subscriptions, got %v", store.deleted)
+		}
+	})
+
+	t.Run("no subscriptions", func(t *testing.T) {
+		if err := newTestNotifier(t, &fakeStore{}).Send(context.Background(), testNotification()); err == nil {
+			t.Error("expected an error for a user without subscriptions")
+		}
+	})
+}
@@ -0,0 +1,335 @@
+package templates
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	chModel "donetick.com/core/internal/chore/model"
+	nModel "donetick.com/core/internal/notifier/model"
+	nRepo "donetick.com/core/internal/notifier/repo"
+	"donetick.com/core/logging"
+)
+
+// DefaultLocale is used when the recipient has no language or one without a bundle
+const DefaultLocale = "en"
+
+// MaxBodyLength limits the size of a circle override
+const MaxBodyLength = 2000
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// EventTypes are the event types whose text can be customised
+var EventTypes = []nModel.EventType{
+	nModel.EventTypePreDue,
+	nModel.EventTypeDue,
+	nModel.EventTypeOverdue,
+	nModel.EventTypeNagging,
+	nModel.EventTypeCompletion,
+	nModel.EventTypeEscalation,
+}
+
+// Data is the variable set available to message templates
+type Data struct {
+	Chore       Chore
+	Assignee    Person
+	CompletedBy Person
+	// Overdue is how long the chore is overdue, e.g. 2d 3h
+	Overdue string
+	Event   nModel.EventType
+}
+
+type Chore struct {
+	ID          int
+	Name        string
+	Description string
+	DueDate     *time.Time
+	Priority    int
+	Points      int
+	Labels      []string
+}
+
+// NewChore returns the template variables of a chore
+func NewChore(chore *chModel.Chore) Chore {
+	data := Chore{
+		ID:       chore.ID,
+		Name:     chore.Name,
+		DueDate:  chore.NextDueDate,
+		Priority: chore.Priority,
+	}
+	if chore.Description != nil {
+		data.Description = *chore.Description
+	}
+	if chore.Points != nil {
+		data.Points = *chore.Points
+	}
+	if chore.LabelsV2 != nil {
+		for _, label := range *chore.LabelsV2 {
+			data.Labels = append(data.Labels, label.Name)
+		}
+	}
+	return data
+}
+
+type Person struct {
+	Name     string
+	Username string
+}
+
+// Variable documents one of the template variables
+type Variable struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Variables lists the variables and functions templates can use
+var Variables = []Variable{
+	{Name: ".Chore.ID", Description: "Chore id"},
+	{Name: ".Chore.Name", Description: "Chore name"},
+	{Name: ".Chore.Description", Description: "Chore description, empty when not set"},
+	{Name: ".Chore.DueDate", Description: "Due date, format it with {{date .Chore.DueDate}}"},
+	{Name: ".Chore.Priority", Description: "Priority from 1 (highest) to 4, 0 when not set"},
+	{Name: ".Chore.Points", Description: "Points awarded for completing the chore"},
+	{Name: ".Chore.Labels", Description: "Label names, list them with {{join .Chore.Labels \", \"}}"},
+	{Name: ".Assignee.Name", Description: "Display name of the assignee"},
+	{Name: ".Assignee.Username", Description: "Username of the assignee"},
+	{Name: ".CompletedBy.Name", Description: "Display name of the member who completed the chore (completion)"},
+	{Name: ".CompletedBy.Username", Description: "Username of the member who completed the chore (completion)"},
+	{Name: ".Overdue", Description: "How long the chore is overdue, e.g. 2d 3h (nagging and escalation)"},
+	{Name: ".Event", Description: "Event type, e.g. due or completion"},
+	{Name: "date", Description: "Formats a date in the recipient's timezone and locale"},
+	{Name: "join", Description: "Joins a list with a separator"},
+}
+
+// Recipient is who a message is rendered for
+type Recipient struct {
+	Language string
+	Timezone string
+}
+
+type bundle struct {
+	DateFormat string                      `json:"dateFormat"`
+	Messages   map[nModel.EventType]string `json:"messages"`
+}
+
+var bundles = loadBundles()
+
+func loadBundles() map[string]*bundle {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	loaded := make(map[string]*bundle, len(files))
+	for _, file := range files {
+		content, err := localeFiles.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var b bundle
+		if err := json.Unmarshal(content, &b); err != nil {
+			panic(fmt.Sprintf("invalid locale bundle %s: %v", file.Name(), err))
+		}
+		loaded[strings.TrimSuffix(file.Name(), ".json")] = &b
+	}
+	return loaded
+}
+
+// Locales returns the locales with a bundle
+func Locales() []string {
+	locales := make([]string, 0, len(bundles))
+	for locale := range bundles {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// NormalizeLocale maps a language like de-AT to the bundle locale, the default locale when there is none
+func NormalizeLocale(language string) string {
+	if locale, ok := matchLocale(language); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// IsSupportedLocale reports whether there is a bundle for the language or its base language
+func IsSupportedLocale(language string) bool {
+	_, ok := matchLocale(language)
+	return ok
+}
+
+func matchLocale(language string) (string, bool) {
+	language = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(language)), "_", "-")
+	if _, ok := bundles[language]; ok {
+		return language, true
+	}
+	base, _, _ := strings.Cut(language, "-")
+	if _, ok := bundles[base]; ok {
+		return base, true
+	}
+	return "", false
+}
+
+// IsTemplatedEvent reports whether the event type text can be customised
+func IsTemplatedEvent(eventType nModel.EventType) bool {
+	for _, templated := range EventTypes {
+		if templated == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Default returns the bundled template of the event type in the locale
+func Default(locale string, eventType nModel.EventType) string {
+	if body, ok := bundles[NormalizeLocale(locale)].Messages[eventType]; ok {
+		return body
+	}
+	return bundles[DefaultLocale].Messages[eventType]
+}
+
+// Execute renders a template body for the recipient
+func Execute(body string, recipient Recipient, data *Data) (string, error) {
+	locale := NormalizeLocale(recipient.Language)
+	loc, err := time.LoadLocation(recipient.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	dateFormat := bundles[locale].DateFormat
+
+	tmpl, err := template.New("message").Funcs(template.FuncMap{
+		"date": func(value interface{}) string {
+			switch t := value.(type) {
+			case *time.Time:
+				if t == nil {
+					return ""
+				}
+				return t.In(loc).Format(dateFormat)
+			case time.Time:
+				return t.In(loc).Format(dateFormat)
+			default:
+				return fmt.Sprint(value)
+			}
+		},
+		"join": strings.Join,
+	}).Parse(body)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Validate checks a circle override parses and renders against sample data
+func Validate(eventType nModel.EventType, body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("template is empty")
+	}
+	if len(body) > MaxBodyLength {
+		return fmt.Errorf("template is longer than %d characters", MaxBodyLength)
+	}
+	_, err := Execute(body, Recipient{}, SampleData(eventType))
+	return err
+}
+
+// SampleData is the data used to preview and validate templates
+func SampleData(eventType nModel.EventType) *Data {
+	dueDate := time.Now().UTC().Add(2 * time.Hour).Truncate(time.Minute)
+	return &Data{
+		Chore: Chore{
+			ID:          1,
+			Name:        "Take out the trash",
+			Description: "Both the recycling and the general waste",
+			DueDate:     &dueDate,
+			Priority:    2,
+			Points:      5,
+			Labels:      []string{"Kitchen", "Weekly"},
+		},
+		Assignee:    Person{Name: "Alex", Username: "alex"},
+		CompletedBy: Person{Name: "Sam", Username: "sam"},
+		Overdue:     "1d 2h",
+		Event:       eventType,
+	}
+}
+
+// FormatOverdue formats how long a chore is overdue
+func FormatOverdue(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	switch {
+	case days == 0:
+		return fmt.Sprintf("%dh", hours)
+	case hours == 0:
+		return fmt.Sprintf("%dd", days)
+	default:
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+}
+
+// Renderer renders notification text from the circle overrides and the locale bundles
+type Renderer struct {
+	nRepo *nRepo.NotificationRepository
+}
+
+func NewRenderer(nr *nRepo.NotificationRepository) *Renderer {
+	return &Renderer{nRepo: nr}
+}
+
+// Render returns the text of the event for the recipient. an override that fails to render falls
+// back to the bundled template so the notification still goes out
+func (r *Renderer) Render(c context.Context, circleID int, eventType nModel.EventType, recipient Recipient, data *Data) string {
+	log := logging.FromContext(c)
+	data.Event = eventType
+	if body := r.override(c, circleID, eventType, NormalizeLocale(recipient.Language)); body != "" {
+		text, err := Execute(body, recipient, data)
+		if err == nil {
+			return text
+		}
+		log.Warnw("Error rendering circle template, using the default", "circle_id", circleID, "event_type", eventType, "error", err)
+	}
+	text, err := Execute(Default(recipient.Language, eventType), recipient, data)
+	if err != nil {
+		log.Errorw("Error rendering default template", "event_type", eventType, "error", err)
+		return data.Chore.Name
+	}
+	return text
+}
+
+// Template returns the template the circle uses for the event type in the locale
+func (r *Renderer) Template(c context.Context, circleID int, eventType nModel.EventType, locale string) string {
+	if body := r.override(c, circleID, eventType, NormalizeLocale(locale)); body != "" {
+		return body
+	}
+	return Default(locale, eventType)
+}
+
+// override returns the circle template for the locale, or the one for all locales
+func (r *Renderer) override(c context.Context, circleID int, eventType nModel.EventType, locale string) string {
+	if r == nil || r.nRepo == nil || circleID == 0 {
+		return ""
+	}
+	for _, candidate := range []string{locale, ""} {
+		template, err := r.nRepo.GetMessageTemplate(c, circleID, eventType, candidate)
+		if err != nil {
+			logging.FromContext(c).Errorw("Error getting circle template", "circle_id", circleID, "error", err)
+			return ""
+		}
+		if template != nil {
+			return template.Body
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+package templates
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	nModel "donetick.com/core/internal/notifier/model"
+)
+
+func TestBundlesRenderEveryEvent(t *testing.T) {
+	for _, locale := range Locales() {
+		for _, eventType := range EventTypes {
+			body, ok := bundles[locale].Messages[eventType]
+			if !ok {
+				t.Errorf("%s: missing template for %s", locale, eventType)
+				continue
+			}
+			text, err := Execute(body, Recipient{Language: locale}, SampleData(eventType))
+			if err != nil {
+				t.Errorf("%s/%s: %v", locale, eventType, err)
+				continue
+			}
+			if !strings.Contains(text, "Take out the trash") {
+				t.Errorf("%s/%s: chore name missing from %q", locale, eventType, text)
+			}
+		}
+	}
+}
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := map[string]string{
+		"":      DefaultLocale,
+		"de":    "de",
+		"de-AT": "de",
+		"fr_CA": "fr",
+		"EN-us": "en",
+		"xx":    DefaultLocale,
+	}
+	for language, want := range tests {
+		if got := NormalizeLocale(language); got != want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", language, got, want)
+		}
+	}
+	if IsSupportedLocale("xx") || !IsSupportedLocale("es-MX") {
+		t.Error("unexpected supported locales")
+	}
+}
+
+func TestExecute(t *testing.T) {
+	due := time.Date(2026, time.March, 10, 17, 30, 0, 0, time.UTC)
+	data := &Data{
+		Chore:    Chore{Name: "Vacuum", DueDate: &due, Points: 3, Labels: []string{"Home", "Weekly"}},
+		Assignee: Person{Name: "Alex"},
+	}
+	body := "{{.Chore.Name}} ({{join .Chore.Labels \", \"}}, {{.Chore.Points}} points) for {{.Assignee.Name}} on {{date .Chore.DueDate}}"
+
+	text, err := Execute(body, Recipient{Language: "en", Timezone: "Europe/Berlin"}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Vacuum (Home, Weekly, 3 points) for Alex on Tue, Mar 10 at 18:30"; text != want {
+		t.Errorf("got %q, want %q", text, want)
+	}
+	text, err = Execute("{{date .Chore.DueDate}}", Recipient{Language: "de"}, data)
+	if err != nil || text != "10.03.2026 um 17:30" {
+		t.Errorf("got %q, %v", text, err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]bool{
+		"{{.Chore.Name}} is due":       true,
+		"{{.Chore.Name":                false,
+		"{{.Chore.Nonexistent}}":       false,
+		"{{date}}":                     false,
+		"   ":                          false,
+		strings.Repeat("a", 2001):      false,
+		"{{if .Chore.Points}}x{{end}}": true,
+	}
+	for body, valid := range tests {
+		if err := Validate(nModel.EventTypeDue, body); (err == nil) != valid {
+			t.Errorf("Validate(%.20q) error = %v, want valid %v", body, err, valid)
+		}
+	}
+}
+
+func TestRendererFallsBackToDefault(t *testing.T) {
+	var renderer *Renderer
+	data := SampleData(nModel.EventTypeCompletion)
+	text := renderer.Render(context.Background(), 0, nModel.EventTypeCompletion, Recipient{Language: "de"}, data)
+	if text != "🎉 *Take out the trash* wurde von Sam erledigt!" {
+		t.Errorf("unexpected text %q", text)
+	}
+}
@@ -0,0 +1,223 @@
+package notifier
+
+import (
+	"strconv"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	nModel "donetick.com/core/internal/notifier/model"
+	"donetick.com/core/internal/notifier/templates"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+type MessageTemplateReq struct {
+	EventType nModel.EventType `json:"eventType" binding:"required"`
+	// Locale limits the override to one locale, empty applies it to all of them
+	Locale string `json:"locale"`
+	Body   string `json:"body" binding:"required"`
+}
+
+type PreviewTemplateReq struct {
+	EventType nModel.EventType `json:"eventType" binding:"required"`
+	Locale    string           `json:"locale"`
+	// Body is the template to preview, the one the circle uses when empty
+	Body string `json:"body"`
+}
+
+// validateTemplateReq writes the error response and returns false when the event type or locale is not supported
+func validateTemplateReq(c *gin.Context, eventType nModel.EventType, locale string) bool {
+	if !templates.IsTemplatedEvent(eventType) {
+		c.JSON(400, gin.H{
+			"error": "Unsupported event type",
+		})
+		return false
+	}
+	if locale != "" && !templates.IsSupportedLocale(locale) {
+		c.JSON(400, gin.H{
+			"error": "Unsupported locale",
+		})
+		return false
+	}
+	return true
+}
+
+func (h *Handler) getMessageTemplates(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	overrides, err := h.notificationRepo.GetMessageTemplates(c, currentUser.CircleID)
+	if err != nil {
+		log.Error("Error getting message templates:", err)
+		c.JSON(500, gin.H{
+			"error": "Error getting message templates",
+		})
+		return
+	}
+	defaults := make(map[string]map[nModel.EventType]string)
+	for _, locale := range templates.Locales() {
+		defaults[locale] = make(map[nModel.EventType]string, len(templates.EventTypes))
+		for _, eventType := range templates.EventTypes {
+			defaults[locale][eventType] = templates.Default(locale, eventType)
+		}
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{
+			"templates":  overrides,
+			"defaults":   defaults,
+			"locales":    templates.Locales(),
+			"eventTypes": templates.EventTypes,
+			"variables":  templates.Variables,
+		},
+	})
+}
+
+func (h *Handler) saveMessageTemplate(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	var req MessageTemplateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+	if !validateTemplateReq(c, req.EventType, req.Locale) {
+		return
+	}
+	if err := templates.Validate(req.EventType, req.Body); err != nil {
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+
+	locale := ""
+	if req.Locale != "" {
+		locale = templates.NormalizeLocale(req.Locale)
+	}
+	now := time.Now().UTC()
+	template := &nModel.MessageTemplate{
+		CircleID:  currentUser.CircleID,
+		EventType: req.EventType,
+		Locale:    locale,
+		Body:      req.Body,
+		UpdatedBy: currentUser.ID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.notificationRepo.SaveMessageTemplate(c, template); err != nil {
+		log.Error("Error saving message template:", err)
+		c.JSON(500, gin.H{
+			"error": "Error saving message template",
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": template,
+	})
+}
+
+func (h *Handler) deleteMessageTemplate(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	templateID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid template ID",
+		})
+		return
+	}
+	if !h.requireCircleAdmin(c, currentUser.ID, currentUser.CircleID) {
+		return
+	}
+
+	deleted, err := h.notificationRepo.DeleteMessageTemplate(c, currentUser.CircleID, templateID)
+	if err != nil {
+		log.Error("Error deleting message template:", err)
+		c.JSON(500, gin.H{
+			"error": "Error deleting message template",
+		})
+		return
+	}
+	if deleted == 0 {
+		c.JSON(404, gin.H{
+			"error": "Template not found",
+		})
+		return
+	}
+	c.JSON(200, gin.H{})
+}
+
+// previewMessageTemplate renders a template against sample data in the requested locale and the
+// current user's timezone
+func (h *Handler) previewMessageTemplate(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(500, gin.H{
+			"error": "Error getting current user",
+		})
+		return
+	}
+
+	var req PreviewTemplateReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{
+			"error": "Invalid request",
+		})
+		return
+	}
+	if !validateTemplateReq(c, req.EventType, req.Locale) {
+		return
+	}
+	locale := req.Locale
+	if locale == "" {
+		locale = currentUser.Language
+	}
+
+	body := req.Body
+	if body == "" {
+		body = h.renderer.Template(c, currentUser.CircleID, req.EventType, locale)
+	} else if len(body) > templates.MaxBodyLength {
+		c.JSON(400, gin.H{
+			"error": "Template is too long",
+		})
+		return
+	}
+	text, err := templates.Execute(body, templates.Recipient{Language: locale, Timezone: currentUser.Timezone}, templates.SampleData(req.EventType))
+	if err != nil {
+		c.JSON(400, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(200, gin.H{
+		"res": gin.H{
+			"text":   text,
+			"locale": templates.NormalizeLocale(locale),
+		},
+	})
+}
@@ -3,6 +3,7 @@ package points
 import (
 	"context"
 
+	"donetick.com/core/internal/database/dbtx"
 	pModel "donetick.com/core/internal/points"
 	"gorm.io/gorm"
 )
@@ -20,5 +21,5 @@ func (r *PointsRepository) CreatePointsHistory(c context.Context, tx *gorm.DB, p
 		return tx.Model(&pModel.PointsHistory{}).Save(pointsHistory).Error
 	}
 
-	return r.db.WithContext(c).Save(pointsHistory).Error
+	return dbtx.DB(c, r.db).Save(pointsHistory).Error
 }
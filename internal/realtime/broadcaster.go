@@ -7,6 +7,7 @@ import (
 
 	"donetick.com/core/config"
 	chModel "donetick.com/core/internal/chore/model"
+	nModel "donetick.com/core/internal/notifier/model"
 	uModel "donetick.com/core/internal/user/model"
 )
 
@@ -120,6 +121,30 @@ func (b *EventBroadcaster) BroadcastSubtaskCompleted(choreID, subtaskID int, com
 	b.service.BroadcastToCircle(circleID, event)
 }
 
+// BroadcastChoreEscalated broadcasts an escalation step applied to an overdue chore
+func (b *EventBroadcaster) BroadcastChoreEscalated(chore *chModel.Chore, history *chModel.ChoreHistory, changes map[string]interface{}) {
+	if !b.service.config.Enabled {
+		return
+	}
+
+	event := NewChoreEscalatedEvent(chore, history, changes)
+	event.ID = b.generateEventID()
+
+	b.service.BroadcastToCircle(chore.CircleID, event)
+}
+
+// BroadcastNotification sends a new inbox item to the connections of the user it is for
+func (b *EventBroadcaster) BroadcastNotification(item *nModel.InboxItem) {
+	if !b.service.config.Enabled {
+		return
+	}
+
+	event := NewNotificationCreatedEvent(item)
+	event.ID = b.generateEventID()
+
+	b.service.BroadcastToUser(item.CircleID, item.UserID, event)
+}
+
 // generateEventID generates a unique event ID
 func (b *EventBroadcaster) generateEventID() string {
 	bytes := make([]byte, 8)
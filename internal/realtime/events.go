@@ -5,6 +5,7 @@ import (
 	"time"
 
 	chModel "donetick.com/core/internal/chore/model"
+	nModel "donetick.com/core/internal/notifier/model"
 	uModel "donetick.com/core/internal/user/model"
 )
 
@@ -22,11 +23,15 @@ const (
 	EventTypeChoreStatus          EventType = "chore.status"
 	EventTypeChoreDueDateChanged  EventType = "chore.due_date_changed"
 	EventTypeChoreArchived        EventType = "chore.archived"
+	EventTypeChoreEscalated       EventType = "chore.escalated"
 
 	// Subtask events
 	EventTypeSubtaskUpdated   EventType = "subtask.updated"
 	EventTypeSubtaskCompleted EventType = "subtask.completed"
 
+	// Inbox events, only sent to the user the notification is for
+	EventTypeNotificationCreated EventType = "notification.created"
+
 	// System events
 	EventTypeConnectionEstablished EventType = "connection.established"
 	EventTypeHeartbeat             EventType = "heartbeat"
@@ -165,6 +170,17 @@ func NewChoreSkippedEvent(chore *chModel.Chore, user *uModel.User, history *chMo
 	})
 }
 
+// NewChoreEscalatedEvent creates an event for an escalation step applied to an overdue chore, there is no
+// user as the steps are applied by the escalation job
+func NewChoreEscalatedEvent(chore *chModel.Chore, history *chModel.ChoreHistory, changes map[string]interface{}) *Event {
+	return NewEvent(EventTypeChoreEscalated, chore.CircleID, &ChoreEventData{
+		Chore:   chore,
+		Changes: changes,
+		History: history,
+		Note:    history.Note,
+	})
+}
+
 // NewSubtaskUpdatedEvent creates a subtask update event
 func NewSubtaskUpdatedEvent(choreID, subtaskID int, completedAt *time.Time, user *uModel.User, circleID int) *Event {
 	return NewEvent(EventTypeSubtaskUpdated, circleID, &SubtaskEventData{
@@ -185,6 +201,11 @@ func NewSubtaskCompletedEvent(choreID, subtaskID int, completedAt *time.Time, us
 	})
 }
 
+// NewNotificationCreatedEvent creates an event for a new item in a user's inbox
+func NewNotificationCreatedEvent(item *nModel.InboxItem) *Event {
+	return NewEvent(EventTypeNotificationCreated, item.CircleID, item)
+}
+
 // NewConnectionEstablishedEvent creates a connection established event
 func NewConnectionEstablishedEvent(connectionID string, circleID, userID int) *Event {
 	return NewEvent(EventTypeConnectionEstablished, circleID, &ConnectionEstablishedData{
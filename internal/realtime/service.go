@@ -173,6 +173,24 @@ func (s *RealTimeService) BroadcastToCircle(circleID int, event *Event) {
 	}
 }
 
+// BroadcastToUser sends an event to the connections of one user in a circle
+func (s *RealTimeService) BroadcastToUser(circleID int, userID int, event *Event) {
+	if !s.started || !s.config.Enabled {
+		return
+	}
+
+	s.mu.RLock()
+	pool, exists := s.connectionPools[circleID]
+	s.mu.RUnlock()
+
+	if exists {
+		pool.BroadcastToUser(userID, event)
+		s.stats.mu.Lock()
+		s.stats.EventsPublished++
+		s.stats.mu.Unlock()
+	}
+}
+
 // GetStats returns current service statistics
 func (s *RealTimeService) GetStats() ServiceStats {
 	s.stats.mu.RLock()
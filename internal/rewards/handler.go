@@ -1,11 +1,16 @@
 package rewards
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 	"time"
 
 	auth "donetick.com/core/internal/authorization"
 	cRepo "donetick.com/core/internal/circle/repo"
+	"donetick.com/core/internal/events"
+	nModel "donetick.com/core/internal/notifier/model"
+	nps "donetick.com/core/internal/notifier/service"
 	rModel "donetick.com/core/internal/rewards/model"
 	rRepo "donetick.com/core/internal/rewards/repo"
 	"donetick.com/core/logging"
@@ -14,14 +19,18 @@ import (
 )
 
 type Handler struct {
-	rewardsRepo *rRepo.RewardsRepository
-	circleRepo  *cRepo.CircleRepository
+	rewardsRepo   *rRepo.RewardsRepository
+	circleRepo    *cRepo.CircleRepository
+	inbox         *nps.Inbox
+	eventProducer *events.EventsProducer
 }
 
-func NewHandler(rr *rRepo.RewardsRepository, cr *cRepo.CircleRepository) *Handler {
+func NewHandler(rr *rRepo.RewardsRepository, cr *cRepo.CircleRepository, inbox *nps.Inbox, ep *events.EventsProducer) *Handler {
 	return &Handler{
-		rewardsRepo: rr,
-		circleRepo:  cr,
+		rewardsRepo:   rr,
+		circleRepo:    cr,
+		inbox:         inbox,
+		eventProducer: ep,
 	}
 }
 
@@ -74,7 +83,13 @@ func (h *Handler) CreateReward(c *gin.Context) {
 		reward.Category = "general"
 	}
 
-	if err := h.rewardsRepo.CreateReward(c, reward); err != nil {
+	err := h.rewardsRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.rewardsRepo.CreateReward(ctx, reward); err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeRewardCreated, events.ResourceReward, reward.ID, &currentUser.User, nil, events.NewSnapshot(reward))
+	})
+	if err != nil {
 		log.Errorw("Failed to create reward", "error", err)
 		c.JSON(500, gin.H{"error": "Failed to create reward"})
 		return
@@ -163,17 +178,33 @@ func (h *Handler) RedeemReward(c *gin.Context) {
 		UpdatedAt: time.Now().UTC(),
 	}
 
-	if err := h.rewardsRepo.CreateRedemption(c, redemption); err != nil {
-		log.Errorw("Failed to create redemption", "error", err)
+	// the redemption, the deducted points and the published event are committed together
+	err = h.rewardsRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.rewardsRepo.CreateRedemption(ctx, redemption); err != nil {
+			return fmt.Errorf("creating redemption: %w", err)
+		}
+		// Deduct points from user
+		if err := h.circleRepo.RedeemPoints(ctx, currentUser.CircleID, currentUser.ID, reward.PointsCost, currentUser.ID); err != nil {
+			return fmt.Errorf("deducting points: %w", err)
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeRewardRedeemed, events.ResourceRedemption, redemption.ID, &currentUser.User, nil, events.NewSnapshot(redemption))
+	})
+	if err != nil {
+		log.Errorw("Failed to redeem reward", "error", err)
 		c.JSON(500, gin.H{"error": "Failed to redeem reward"})
 		return
 	}
 
-	// Deduct points from user
-	if err := h.circleRepo.RedeemPoints(c, currentUser.CircleID, currentUser.ID, reward.PointsCost, currentUser.ID); err != nil {
-		log.Errorw("Failed to deduct points", "error", err)
-		c.JSON(500, gin.H{"error": "Failed to process redemption"})
-		return
+	// let the admins know there is a redemption to approve
+	admins, err := h.circleRepo.GetCircleAdmins(c, currentUser.CircleID)
+	if err != nil {
+		log.Errorw("Failed to get circle admins", "error", err)
+	}
+	for _, admin := range admins {
+		if admin.UserID == currentUser.ID {
+			continue
+		}
+		h.addRedemptionToInbox(c, admin.UserID, redemption, fmt.Sprintf("🎁 **%s** redeemed **%s** for %d points", currentUser.DisplayName, reward.Name, reward.PointsCost))
 	}
 
 	c.JSON(200, gin.H{"res": redemption})
@@ -250,7 +281,13 @@ func (h *Handler) CreateGoal(c *gin.Context) {
 		goal.Category = "general"
 	}
 
-	if err := h.rewardsRepo.CreateGoal(c, goal); err != nil {
+	err := h.rewardsRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.rewardsRepo.CreateGoal(ctx, goal); err != nil {
+			return err
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeGoalCreated, events.ResourceGoal, goal.ID, &currentUser.User, nil, events.NewSnapshot(goal))
+	})
+	if err != nil {
 		log.Errorw("Failed to create goal", "error", err)
 		c.JSON(500, gin.H{"error": "Failed to create goal"})
 		return
@@ -385,11 +422,36 @@ func (h *Handler) UpdateRedemptionStatus(c *gin.Context) {
 		return
 	}
 
-	if err := h.rewardsRepo.UpdateRedemptionStatus(c, redemptionID, req.Status, req.Notes); err != nil {
+	var before events.Snapshot
+	if existing, err := h.rewardsRepo.GetRedemptionByID(c, redemptionID); err == nil {
+		before = events.NewSnapshot(existing)
+	}
+
+	var redemption *rModel.RewardRedemption
+	err = h.rewardsRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.rewardsRepo.UpdateRedemptionStatus(ctx, redemptionID, req.Status, req.Notes); err != nil {
+			return err
+		}
+		var err error
+		redemption, err = h.rewardsRepo.GetRedemptionByID(ctx, redemptionID)
+		if err != nil {
+			return err
+		}
+		if redemption.CircleID != currentUser.CircleID || before == nil {
+			return nil
+		}
+		return h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeRewardRedemptionUpdated, events.ResourceRedemption, redemption.ID, &currentUser.User, before, events.NewSnapshot(redemption))
+	})
+	if err != nil {
 		log.Errorw("Failed to update redemption status", "error", err)
 		c.JSON(500, gin.H{"error": "Failed to update redemption status"})
 		return
 	}
+	if redemption.CircleID == currentUser.CircleID {
+		if text := redemptionStatusText(redemption); text != "" {
+			h.addRedemptionToInbox(c, redemption.UserID, redemption, text)
+		}
+	}
 
 	c.JSON(200, gin.H{"message": "Redemption status updated successfully"})
 }
@@ -402,7 +464,30 @@ func (h *Handler) UpdateGoalProgress(c *gin.Context) {
 		return
 	}
 
-	if err := h.rewardsRepo.UpdateGoalProgress(c, currentUser.CircleID); err != nil {
+	before := make(map[int]events.Snapshot)
+	if progress, err := h.rewardsRepo.GetUserGoalProgress(c, currentUser.ID, currentUser.CircleID); err == nil {
+		for _, goalProgress := range progress {
+			before[goalProgress.GoalID] = events.NewSnapshot(goalProgress)
+		}
+	}
+
+	err := h.rewardsRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.rewardsRepo.UpdateGoalProgress(ctx, currentUser.CircleID); err != nil {
+			return err
+		}
+		// only the progress of the current user is published, goals without a change are skipped
+		progress, err := h.rewardsRepo.GetUserGoalProgress(ctx, currentUser.ID, currentUser.CircleID)
+		if err != nil {
+			return err
+		}
+		for _, goalProgress := range progress {
+			if err := h.eventProducer.ResourceChanged(ctx, currentUser.CircleID, currentUser.WebhookURL, events.EventTypeGoalProgressUpdated, events.ResourceGoal, goalProgress.GoalID, &currentUser.User, before[goalProgress.GoalID], events.NewSnapshot(goalProgress)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		log.Errorw("Failed to update goal progress", "error", err)
 		c.JSON(500, gin.H{"error": "Failed to update goal progress"})
 		return
@@ -412,6 +497,43 @@ func (h *Handler) UpdateGoalProgress(c *gin.Context) {
 }
 
 // Helper methods
+// addRedemptionToInbox tells the user about a redemption in the app, each status change is its own item
+func (h *Handler) addRedemptionToInbox(c *gin.Context, userID int, redemption *rModel.RewardRedemption, text string) {
+	item := &nModel.InboxItem{
+		UserID:    userID,
+		CircleID:  redemption.CircleID,
+		EventType: nModel.EventTypeRedemption,
+		Key:       fmt.Sprintf("%s:%d:%d", nModel.EventTypeRedemption, redemption.ID, redemption.Status),
+		Text:      text,
+		Data: nModel.JSONB{
+			"redemptionId": redemption.ID,
+			"rewardId":     redemption.RewardID,
+			"points":       redemption.Points,
+			"status":       redemption.Status,
+		},
+	}
+	if err := h.inbox.Add(c, item); err != nil {
+		logging.FromContext(c).Errorw("Failed to add redemption to inbox", "redemption_id", redemption.ID, "error", err)
+	}
+}
+
+func redemptionStatusText(redemption *rModel.RewardRedemption) string {
+	name := "your reward"
+	if redemption.Reward != nil {
+		name = "**" + redemption.Reward.Name + "**"
+	}
+	switch redemption.Status {
+	case rModel.RedemptionStatusApproved:
+		return fmt.Sprintf("✅ Your redemption of %s was approved", name)
+	case rModel.RedemptionStatusRejected:
+		return fmt.Sprintf("❌ Your redemption of %s was rejected", name)
+	case rModel.RedemptionStatusCompleted:
+		return fmt.Sprintf("🎉 Your redemption of %s was fulfilled", name)
+	default:
+		return ""
+	}
+}
+
 func (h *Handler) isCircleAdmin(c *gin.Context, userID int, circleID int) bool {
 	admins, err := h.circleRepo.GetCircleAdmins(c, circleID)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"donetick.com/core/config"
+	"donetick.com/core/internal/database/dbtx"
 	pModel "donetick.com/core/internal/points"
 	rModel "donetick.com/core/internal/rewards/model"
 	"gorm.io/gorm"
@@ -18,14 +19,18 @@ func NewRewardsRepository(db *gorm.DB, cfg *config.Config) *RewardsRepository {
 	return &RewardsRepository{db: db}
 }
 
+func (r *RewardsRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return dbtx.Run(ctx, r.db, fn)
+}
+
 // Rewards CRUD
 func (r *RewardsRepository) CreateReward(ctx context.Context, reward *rModel.Reward) error {
-	return r.db.WithContext(ctx).Create(reward).Error
+	return dbtx.DB(ctx, r.db).Create(reward).Error
 }
 
 func (r *RewardsRepository) GetRewardsByCircle(ctx context.Context, circleID int) ([]*rModel.Reward, error) {
 	var rewards []*rModel.Reward
-	if err := r.db.WithContext(ctx).Where("circle_id = ? AND is_active = ?", circleID, true).
+	if err := dbtx.DB(ctx, r.db).Where("circle_id = ? AND is_active = ?", circleID, true).
 		Order("points_cost ASC").Find(&rewards).Error; err != nil {
 		return nil, err
 	}
@@ -34,24 +39,24 @@ func (r *RewardsRepository) GetRewardsByCircle(ctx context.Context, circleID int
 
 func (r *RewardsRepository) GetRewardByID(ctx context.Context, rewardID int) (*rModel.Reward, error) {
 	var reward rModel.Reward
-	if err := r.db.WithContext(ctx).First(&reward, rewardID).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).First(&reward, rewardID).Error; err != nil {
 		return nil, err
 	}
 	return &reward, nil
 }
 
 func (r *RewardsRepository) UpdateReward(ctx context.Context, reward *rModel.Reward) error {
-	return r.db.WithContext(ctx).Save(reward).Error
+	return dbtx.DB(ctx, r.db).Save(reward).Error
 }
 
 func (r *RewardsRepository) DeleteReward(ctx context.Context, rewardID int) error {
-	return r.db.WithContext(ctx).Model(&rModel.Reward{}).Where("id = ?", rewardID).
+	return dbtx.DB(ctx, r.db).Model(&rModel.Reward{}).Where("id = ?", rewardID).
 		Update("is_active", false).Error
 }
 
 // Reward Redemptions
 func (r *RewardsRepository) CreateRedemption(ctx context.Context, redemption *rModel.RewardRedemption) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
 		// Create redemption record
 		if err := tx.Create(redemption).Error; err != nil {
 			return err
@@ -82,7 +87,7 @@ func (r *RewardsRepository) CreateRedemption(ctx context.Context, redemption *rM
 
 func (r *RewardsRepository) GetRedemptionsByUser(ctx context.Context, userID int, circleID int) ([]*rModel.RewardRedemption, error) {
 	var redemptions []*rModel.RewardRedemption
-	if err := r.db.WithContext(ctx).Preload("Reward").
+	if err := dbtx.DB(ctx, r.db).Preload("Reward").
 		Where("user_id = ? AND circle_id = ?", userID, circleID).
 		Order("created_at DESC").Find(&redemptions).Error; err != nil {
 		return nil, err
@@ -92,7 +97,7 @@ func (r *RewardsRepository) GetRedemptionsByUser(ctx context.Context, userID int
 
 func (r *RewardsRepository) GetRedemptionsByCircle(ctx context.Context, circleID int) ([]*rModel.RewardRedemption, error) {
 	var redemptions []*rModel.RewardRedemption
-	if err := r.db.WithContext(ctx).Preload("Reward").
+	if err := dbtx.DB(ctx, r.db).Preload("Reward").
 		Where("circle_id = ?", circleID).
 		Order("created_at DESC").Find(&redemptions).Error; err != nil {
 		return nil, err
@@ -100,6 +105,14 @@ func (r *RewardsRepository) GetRedemptionsByCircle(ctx context.Context, circleID
 	return redemptions, nil
 }
 
+func (r *RewardsRepository) GetRedemptionByID(ctx context.Context, redemptionID int) (*rModel.RewardRedemption, error) {
+	var redemption rModel.RewardRedemption
+	if err := dbtx.DB(ctx, r.db).Preload("Reward").First(&redemption, redemptionID).Error; err != nil {
+		return nil, err
+	}
+	return &redemption, nil
+}
+
 func (r *RewardsRepository) UpdateRedemptionStatus(ctx context.Context, redemptionID int, status rModel.RedemptionStatus, notes *string) error {
 	updates := map[string]interface{}{
 		"status":     status,
@@ -108,18 +121,18 @@ func (r *RewardsRepository) UpdateRedemptionStatus(ctx context.Context, redempti
 	if notes != nil {
 		updates["notes"] = *notes
 	}
-	return r.db.WithContext(ctx).Model(&rModel.RewardRedemption{}).
+	return dbtx.DB(ctx, r.db).Model(&rModel.RewardRedemption{}).
 		Where("id = ?", redemptionID).Updates(updates).Error
 }
 
 // Goals CRUD
 func (r *RewardsRepository) CreateGoal(ctx context.Context, goal *rModel.Goal) error {
-	return r.db.WithContext(ctx).Create(goal).Error
+	return dbtx.DB(ctx, r.db).Create(goal).Error
 }
 
 func (r *RewardsRepository) GetGoalsByCircle(ctx context.Context, circleID int, userID *int) ([]*rModel.Goal, error) {
 	var goals []*rModel.Goal
-	query := r.db.WithContext(ctx).Where("circle_id = ? AND is_active = ?", circleID, true)
+	query := dbtx.DB(ctx, r.db).Where("circle_id = ? AND is_active = ?", circleID, true)
 	
 	if userID != nil {
 		// Get both circle-wide goals and user-specific goals
@@ -137,29 +150,29 @@ func (r *RewardsRepository) GetGoalsByCircle(ctx context.Context, circleID int,
 
 func (r *RewardsRepository) GetGoalByID(ctx context.Context, goalID int) (*rModel.Goal, error) {
 	var goal rModel.Goal
-	if err := r.db.WithContext(ctx).First(&goal, goalID).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).First(&goal, goalID).Error; err != nil {
 		return nil, err
 	}
 	return &goal, nil
 }
 
 func (r *RewardsRepository) UpdateGoal(ctx context.Context, goal *rModel.Goal) error {
-	return r.db.WithContext(ctx).Save(goal).Error
+	return dbtx.DB(ctx, r.db).Save(goal).Error
 }
 
 func (r *RewardsRepository) DeleteGoal(ctx context.Context, goalID int) error {
-	return r.db.WithContext(ctx).Model(&rModel.Goal{}).Where("id = ?", goalID).
+	return dbtx.DB(ctx, r.db).Model(&rModel.Goal{}).Where("id = ?", goalID).
 		Update("is_active", false).Error
 }
 
 // Goal Progress
 func (r *RewardsRepository) UpsertGoalProgress(ctx context.Context, progress *rModel.GoalProgress) error {
-	return r.db.WithContext(ctx).Save(progress).Error
+	return dbtx.DB(ctx, r.db).Save(progress).Error
 }
 
 func (r *RewardsRepository) GetGoalProgress(ctx context.Context, goalID int, userID int) (*rModel.GoalProgress, error) {
 	var progress rModel.GoalProgress
-	if err := r.db.WithContext(ctx).Where("goal_id = ? AND user_id = ?", goalID, userID).
+	if err := dbtx.DB(ctx, r.db).Where("goal_id = ? AND user_id = ?", goalID, userID).
 		First(&progress).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
@@ -171,7 +184,7 @@ func (r *RewardsRepository) GetGoalProgress(ctx context.Context, goalID int, use
 
 func (r *RewardsRepository) GetGoalProgressByCircle(ctx context.Context, circleID int) ([]*rModel.GoalProgress, error) {
 	var progress []*rModel.GoalProgress
-	if err := r.db.WithContext(ctx).Preload("Goal").
+	if err := dbtx.DB(ctx, r.db).Preload("Goal").
 		Joins("JOIN goals ON goal_progresses.goal_id = goals.id").
 		Where("goals.circle_id = ?", circleID).
 		Order("goal_progresses.progress DESC").Find(&progress).Error; err != nil {
@@ -221,7 +234,7 @@ func (r *RewardsRepository) GetLeaderboard(ctx context.Context, circleID int, li
 		LIMIT ?
 	`
 	
-	if err := r.db.WithContext(ctx).Raw(query, circleID, circleID, circleID, limit).
+	if err := dbtx.DB(ctx, r.db).Raw(query, circleID, circleID, circleID, limit).
 		Scan(&leaderboard).Error; err != nil {
 		return nil, err
 	}
@@ -235,7 +248,7 @@ func (r *RewardsRepository) GetUserPointsStats(ctx context.Context, userID int,
 	
 	// Get current points
 	var currentPoints int
-	if err := r.db.WithContext(ctx).Model(&struct {
+	if err := dbtx.DB(ctx, r.db).Model(&struct {
 		Points int `gorm:"column:points"`
 	}{}).Table("user_circles").
 		Where("user_id = ? AND circle_id = ?", userID, circleID).
@@ -246,7 +259,7 @@ func (r *RewardsRepository) GetUserPointsStats(ctx context.Context, userID int,
 	
 	// Get points this week
 	var weekPoints int
-	if err := r.db.WithContext(ctx).Model(&pModel.PointsHistory{}).
+	if err := dbtx.DB(ctx, r.db).Model(&pModel.PointsHistory{}).
 		Where("user_id = ? AND circle_id = ? AND action = ? AND created_at >= ?", 
 			userID, circleID, pModel.PointsHistoryActionAdd, time.Now().AddDate(0, 0, -7)).
 		Select("COALESCE(SUM(points), 0)").Scan(&weekPoints).Error; err != nil {
@@ -256,7 +269,7 @@ func (r *RewardsRepository) GetUserPointsStats(ctx context.Context, userID int,
 	
 	// Get points this month
 	var monthPoints int
-	if err := r.db.WithContext(ctx).Model(&pModel.PointsHistory{}).
+	if err := dbtx.DB(ctx, r.db).Model(&pModel.PointsHistory{}).
 		Where("user_id = ? AND circle_id = ? AND action = ? AND created_at >= ?", 
 			userID, circleID, pModel.PointsHistoryActionAdd, time.Now().AddDate(0, -1, 0)).
 		Select("COALESCE(SUM(points), 0)").Scan(&monthPoints).Error; err != nil {
@@ -266,7 +279,7 @@ func (r *RewardsRepository) GetUserPointsStats(ctx context.Context, userID int,
 	
 	// Get total points redeemed
 	var redeemedPoints int
-	if err := r.db.WithContext(ctx).Model(&pModel.PointsHistory{}).
+	if err := dbtx.DB(ctx, r.db).Model(&pModel.PointsHistory{}).
 		Where("user_id = ? AND circle_id = ? AND action = ?", 
 			userID, circleID, pModel.PointsHistoryActionRedeem).
 		Select("COALESCE(SUM(points), 0)").Scan(&redeemedPoints).Error; err != nil {
@@ -278,7 +291,7 @@ func (r *RewardsRepository) GetUserPointsStats(ctx context.Context, userID int,
 }
 
 func (r *RewardsRepository) UpdateGoalProgress(ctx context.Context, circleID int) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
 		// Get all active goals for the circle
 		var goals []*rModel.Goal
 		if err := tx.Where("circle_id = ? AND is_active = ?", circleID, true).Find(&goals).Error; err != nil {
@@ -390,7 +403,7 @@ func (r *RewardsRepository) updateUserGoalProgress(ctx context.Context, tx *gorm
 
 func (r *RewardsRepository) GetUserGoalProgress(ctx context.Context, userID int, circleID int) ([]*rModel.GoalProgress, error) {
 	var progress []*rModel.GoalProgress
-	if err := r.db.WithContext(ctx).Preload("Goal").
+	if err := dbtx.DB(ctx, r.db).Preload("Goal").
 		Joins("JOIN goals ON goal_progresses.goal_id = goals.id").
 		Where("goal_progresses.user_id = ? AND goals.circle_id = ? AND goals.is_active = ?", 
 			userID, circleID, true).
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"donetick.com/core/config"
+	"donetick.com/core/internal/database/dbtx"
 	errorx "donetick.com/core/internal/error"
 
 	st "donetick.com/core/internal/storage/model"
@@ -25,7 +26,7 @@ func (r *StorageRepository) AddMediaRecord(ctx context.Context, media *st.Storag
 		return errorx.ErrNotAPlusMember
 	}
 	// create transaction and increment the storage then save the file:
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
 
 		// confirm is the user have enough space and increment the storage:
 		res := tx.Debug().Model(&st.StorageUsage{}).Where("user_id = ? and used_bytes <= ? ", user.ID, r.maxUserStorage-media.SizeBytes).Updates(map[string]interface{}{"used_bytes": gorm.Expr("used_bytes + ?", media.SizeBytes)})
@@ -45,7 +46,7 @@ func (r *StorageRepository) AddMediaRecord(ctx context.Context, media *st.Storag
 
 func (r *StorageRepository) RemoveFileRecords(ctx context.Context, files []*st.StorageFile, userID int) error {
 	// create transaction and increment the storage then save the file:
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(ctx, r.db).Transaction(func(tx *gorm.DB) error {
 
 		ids := make([]string, len(files))
 		filesSize := 0
@@ -70,7 +71,7 @@ func (r *StorageRepository) RemoveFileRecords(ctx context.Context, files []*st.S
 
 func (r *StorageRepository) GetAllFilesByOwnerType(ctx context.Context, entityType st.EntityType, entityID int) ([]*st.StorageFile, error) {
 	var files []*st.StorageFile
-	if err := r.db.WithContext(ctx).Where("entity_type = ? and entity_id = ?", entityType, entityID).Find(&files).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).Where("entity_type = ? and entity_id = ?", entityType, entityID).Find(&files).Error; err != nil {
 		return nil, err
 	}
 	return files, nil
@@ -80,7 +81,7 @@ func (r *StorageRepository) GetFilesByUser(ctx context.Context, userID int, enti
 	var files []*st.StorageFile
 	// we are getting files by user ID, entity type and entity ID, or entity ID = 0 which will get file for this specific entity and anything
 	// in purgatory ( file upload without having yet an entity ID )
-	if err := r.db.WithContext(ctx).Where("user_id = ? and entity_type = ? and (entity_id = ?  or entity_id = 0)", userID, entityType, entityID).Find(&files).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).Where("user_id = ? and entity_type = ? and (entity_id = ?  or entity_id = 0)", userID, entityType, entityID).Find(&files).Error; err != nil {
 		return nil, err
 	}
 	return files, nil
@@ -88,7 +89,7 @@ func (r *StorageRepository) GetFilesByUser(ctx context.Context, userID int, enti
 
 func (r *StorageRepository) GetStorageStats(ctx context.Context, userID int) (int, int, error) {
 	var usage st.StorageUsage
-	if err := r.db.WithContext(ctx).Model(&st.StorageUsage{}).Where("user_id = ?", userID).First(&usage).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).Model(&st.StorageUsage{}).Where("user_id = ?", userID).First(&usage).Error; err != nil {
 		return 0, 0, err
 	}
 
@@ -97,7 +98,7 @@ func (r *StorageRepository) GetStorageStats(ctx context.Context, userID int) (in
 
 func (r *StorageRepository) RemoveAllFileByEntity(ctx context.Context, entityType st.EntityType, entityID int) error {
 	// delete all files by entity type and entity ID:
-	if err := r.db.WithContext(ctx).Where("entity_type = ? and entity_id = ?", entityType, entityID).Delete(&st.StorageFile{}).Error; err != nil {
+	if err := dbtx.DB(ctx, r.db).Where("entity_type = ? and entity_id = ?", entityType, entityID).Delete(&st.StorageFile{}).Error; err != nil {
 		return err
 	}
 	return nil
@@ -6,6 +6,7 @@ import (
 	"log"
 	"time"
 
+	"donetick.com/core/internal/database/dbtx"
 	stModel "donetick.com/core/internal/subtask/model"
 	"gorm.io/gorm"
 )
@@ -20,7 +21,7 @@ func NewSubTasksRepository(db *gorm.DB) *SubTasksRepository {
 
 func (r *SubTasksRepository) UpdateSubtask(c context.Context, choreId int, toBeRemoved []stModel.SubTask, toBeAddedOrUpdated []stModel.SubTask) error {
 	// Start a database transaction. All operations within this function will be atomic. so if something wrong will just rollback
-	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
 		if len(toBeRemoved) > 0 {
 			var idsToRemove []int
 			for _, subtask := range toBeRemoved {
@@ -155,14 +156,14 @@ func (r *SubTasksRepository) UpdateSubtask(c context.Context, choreId int, toBeR
 	})
 }
 func (r *SubTasksRepository) UpdateSubTaskStatus(c context.Context, userID int, subtaskID int, completedAt *time.Time) error {
-	return r.db.Model(&stModel.SubTask{}).Where("id = ?", subtaskID).Updates(map[string]interface{}{
+	return dbtx.DB(c, r.db).Model(&stModel.SubTask{}).Where("id = ?", subtaskID).Updates(map[string]interface{}{
 		"completed_at": completedAt,
 		"completed_by": userID,
 	}).Error
 }
 
 func (r *SubTasksRepository) ResetSubtasksCompletion(c context.Context, choreID int) error {
-	return r.db.Model(&stModel.SubTask{}).Where("chore_id = ?", choreID).Updates(map[string]interface{}{
+	return dbtx.DB(c, r.db).Model(&stModel.SubTask{}).Where("chore_id = ?", choreID).Updates(map[string]interface{}{
 		"completed_at": nil,
 		"completed_by": nil,
 	}).Error
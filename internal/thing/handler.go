@@ -1,6 +1,7 @@
 package thing
 
 import (
+	"context"
 	"strconv"
 	"time"
 
@@ -113,7 +114,20 @@ func (h *Handler) UpdateThingState(c *gin.Context) {
 		return
 	}
 
-	if err := h.tRepo.UpdateThingState(c, thing); err != nil {
+	// the event is saved with the new state, so it's sent if and only if the state is committed
+	err = h.tRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.tRepo.UpdateThingState(ctx, thing); err != nil {
+			return err
+		}
+		return h.eventsProducer.ThingsUpdated(ctx, currentUser.CircleID, thing.ID, currentUser.WebhookURL, map[string]interface{}{
+			"id":         thing.ID,
+			"name":       thing.Name,
+			"type":       thing.Type,
+			"from_state": old_state,
+			"to_state":   val,
+		})
+	})
+	if err != nil {
 		c.JSON(500, gin.H{"error": err.Error()})
 		return
 	}
@@ -122,13 +136,6 @@ func (h *Handler) UpdateThingState(c *gin.Context) {
 	if shouldReturn {
 		return
 	}
-	h.eventsProducer.ThingsUpdated(c.Request.Context(), currentUser.WebhookURL, map[string]interface{}{
-		"id":         thing.ID,
-		"name":       thing.Name,
-		"type":       thing.Type,
-		"from_state": old_state,
-		"to_state":   val,
-	})
 
 	c.JSON(200, gin.H{
 		"res": thing,
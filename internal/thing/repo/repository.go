@@ -5,6 +5,7 @@ import (
 	"time"
 
 	config "donetick.com/core/config"
+	"donetick.com/core/internal/database/dbtx"
 	tModel "donetick.com/core/internal/thing/model"
 	"gorm.io/gorm"
 )
@@ -18,13 +19,19 @@ func NewThingRepository(db *gorm.DB, cfg *config.Config) *ThingRepository {
 	return &ThingRepository{db: db, dbType: cfg.Database.Type}
 }
 
+// Transaction calls fn in a transaction carried by its context, the thing writes and events published
+// in fn are committed together
+func (r *ThingRepository) Transaction(c context.Context, fn func(c context.Context) error) error {
+	return dbtx.Run(c, r.db, fn)
+}
+
 func (r *ThingRepository) UpsertThing(c context.Context, thing *tModel.Thing) error {
-	return r.db.WithContext(c).Model(&thing).Save(thing).Error
+	return dbtx.DB(c, r.db).Model(&thing).Save(thing).Error
 }
 
 func (r *ThingRepository) UpdateThingState(c context.Context, thing *tModel.Thing) error {
 	// update the state of the thing where the id is the same:
-	if err := r.db.WithContext(c).Model(&thing).Where("id = ?", thing.ID).Updates(map[string]interface{}{
+	if err := dbtx.DB(c, r.db).Model(&thing).Where("id = ?", thing.ID).Updates(map[string]interface{}{
 		"state":      thing.State,
 		"updated_at": time.Now().UTC(),
 	}).Error; err != nil {
@@ -39,7 +46,7 @@ func (r *ThingRepository) UpdateThingState(c context.Context, thing *tModel.Thin
 		UpdatedAt: &createdAt,
 	}
 
-	if err := r.db.WithContext(c).Create(thingHistory).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Create(thingHistory).Error; err != nil {
 		return err
 	}
 
@@ -47,7 +54,7 @@ func (r *ThingRepository) UpdateThingState(c context.Context, thing *tModel.Thin
 }
 func (r *ThingRepository) GetThingByID(c context.Context, thingID int) (*tModel.Thing, error) {
 	var thing tModel.Thing
-	if err := r.db.WithContext(c).Model(&tModel.Thing{}).Preload("ThingChores").First(&thing, thingID).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Model(&tModel.Thing{}).Preload("ThingChores").First(&thing, thingID).Error; err != nil {
 		return nil, err
 	}
 	return &thing, nil
@@ -55,7 +62,7 @@ func (r *ThingRepository) GetThingByID(c context.Context, thingID int) (*tModel.
 
 func (r *ThingRepository) GetThingByChoreID(c context.Context, choreID int) (*tModel.Thing, error) {
 	var thing tModel.Thing
-	if err := r.db.WithContext(c).Model(&tModel.Thing{}).Joins("left join thing_chores on things.id = thing_chores.thing_id").First(&thing, "thing_chores.chore_id = ?", choreID).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Model(&tModel.Thing{}).Joins("left join thing_chores on things.id = thing_chores.thing_id").First(&thing, "thing_chores.chore_id = ?", choreID).Error; err != nil {
 		return nil, err
 	}
 	return &thing, nil
@@ -63,20 +70,20 @@ func (r *ThingRepository) GetThingByChoreID(c context.Context, choreID int) (*tM
 
 func (r *ThingRepository) AssociateThingWithChore(c context.Context, thingID int, choreID int, triggerState string, condition string) error {
 
-	return r.db.WithContext(c).Save(&tModel.ThingChore{ThingID: thingID, ChoreID: choreID, TriggerState: triggerState, Condition: condition}).Error
+	return dbtx.DB(c, r.db).Save(&tModel.ThingChore{ThingID: thingID, ChoreID: choreID, TriggerState: triggerState, Condition: condition}).Error
 }
 
 func (r *ThingRepository) DissociateThingWithChore(c context.Context, thingID int, choreID int) error {
-	return r.db.WithContext(c).Where("thing_id = ? AND chore_id = ?", thingID, choreID).Delete(&tModel.ThingChore{}).Error
+	return dbtx.DB(c, r.db).Where("thing_id = ? AND chore_id = ?", thingID, choreID).Delete(&tModel.ThingChore{}).Error
 }
 
 func (r *ThingRepository) DissociateChoreWithThing(c context.Context, choreID int) error {
-	return r.db.WithContext(c).Where("chore_id = ?", choreID).Delete(&tModel.ThingChore{}).Error
+	return dbtx.DB(c, r.db).Where("chore_id = ?", choreID).Delete(&tModel.ThingChore{}).Error
 }
 
 func (r *ThingRepository) GetThingHistoryWithOffset(c context.Context, thingID int, offset int) ([]*tModel.ThingHistory, error) {
 	var thingHistory []*tModel.ThingHistory
-	if err := r.db.WithContext(c).Model(&tModel.ThingHistory{}).Where("thing_id = ?", thingID).Order("created_at desc").Offset(offset).Limit(10).Find(&thingHistory).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Model(&tModel.ThingHistory{}).Where("thing_id = ?", thingID).Order("created_at desc").Offset(offset).Limit(10).Find(&thingHistory).Error; err != nil {
 		return nil, err
 	}
 	return thingHistory, nil
@@ -84,7 +91,7 @@ func (r *ThingRepository) GetThingHistoryWithOffset(c context.Context, thingID i
 
 func (r *ThingRepository) GetUserThings(c context.Context, userID int) ([]*tModel.Thing, error) {
 	var things []*tModel.Thing
-	if err := r.db.WithContext(c).Model(&tModel.Thing{}).Where("user_id = ?", userID).Find(&things).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Model(&tModel.Thing{}).Where("user_id = ?", userID).Find(&things).Error; err != nil {
 		return nil, err
 	}
 	return things, nil
@@ -92,11 +99,11 @@ func (r *ThingRepository) GetUserThings(c context.Context, userID int) ([]*tMode
 
 func (r *ThingRepository) DeleteThing(c context.Context, thingID int) error {
 	//  one transaction to delete the thing and its history :
-	return r.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
-		if err := r.db.WithContext(c).Where("thing_id = ?", thingID).Delete(&tModel.ThingHistory{}).Error; err != nil {
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := dbtx.DB(c, r.db).Where("thing_id = ?", thingID).Delete(&tModel.ThingHistory{}).Error; err != nil {
 			return err
 		}
-		if err := r.db.WithContext(c).Delete(&tModel.Thing{}, thingID).Error; err != nil {
+		if err := dbtx.DB(c, r.db).Delete(&tModel.Thing{}, thingID).Error; err != nil {
 			return err
 		}
 		return nil
@@ -106,7 +113,7 @@ func (r *ThingRepository) DeleteThing(c context.Context, thingID int) error {
 // get ThingChores by thingID:
 func (r *ThingRepository) GetThingChoresByThingId(c context.Context, thingID int) ([]*tModel.ThingChore, error) {
 	var thingChores []*tModel.ThingChore
-	if err := r.db.WithContext(c).Model(&tModel.ThingChore{}).Where("thing_id = ?", thingID).Find(&thingChores).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Model(&tModel.ThingChore{}).Where("thing_id = ?", thingID).Find(&thingChores).Error; err != nil {
 		return nil, err
 	}
 	return thingChores, nil
@@ -114,7 +121,7 @@ func (r *ThingRepository) GetThingChoresByThingId(c context.Context, thingID int
 
 func (r *ThingRepository) GetThingsByUserID(c context.Context, userID int) ([]*tModel.Thing, error) {
 	var things []*tModel.Thing
-	if err := r.db.WithContext(c).Model(&tModel.Thing{}).Where("user_id = ?", userID).Find(&things).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Model(&tModel.Thing{}).Where("user_id = ?", userID).Find(&things).Error; err != nil {
 		return nil, err
 	}
 	return things, nil
@@ -122,7 +129,7 @@ func (r *ThingRepository) GetThingsByUserID(c context.Context, userID int) ([]*t
 
 // func (r *ThingRepository) GetChoresByThingId(c context.Context, thingID int) ([]*chModel.Chore, error) {
 // 	var chores []*chModel.Chore
-// 	if err := r.db.WithContext(c).Model(&chModel.Chore{}).Joins("left join thing_chores on chores.id = thing_chores.chore_id").Where("thing_chores.thing_id = ?", thingID).Find(&chores).Error; err != nil {
+// 	if err := dbtx.DB(c, r.db).Model(&chModel.Chore{}).Joins("left join thing_chores on chores.id = thing_chores.chore_id").Where("thing_chores.thing_id = ?", thingID).Find(&chores).Error; err != nil {
 // 		return nil, err
 // 	}
 // 	return chores, nil
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
@@ -17,6 +18,9 @@ import (
 	"donetick.com/core/internal/email"
 	"donetick.com/core/internal/mfa"
 	nModel "donetick.com/core/internal/notifier/model"
+	nps "donetick.com/core/internal/notifier/service"
+	"donetick.com/core/internal/notifier/service/webpush"
+	"donetick.com/core/internal/notifier/templates"
 	storage "donetick.com/core/internal/storage"
 	storageRepo "donetick.com/core/internal/storage/repo"
 	uModel "donetick.com/core/internal/user/model"
@@ -43,13 +47,15 @@ type Handler struct {
 	storage                *storage.S3Storage
 	storageRepo            *storageRepo.StorageRepository
 	signer                 *storage.URLSignerS3
+	notificationProviders  *nps.ProviderRegistry
+	vapidKeys              *webpush.VAPIDKeys
 }
 
 func NewHandler(ur *uRepo.UserRepository, cr *cRepo.CircleRepository,
 	jwtAuth *jwt.GinJWTMiddleware, email *email.EmailSender,
 	idp *auth.IdentityProvider, storage *storage.S3Storage,
 	signer *storage.URLSignerS3, storageRepo *storageRepo.StorageRepository,
-	config *config.Config) *Handler {
+	config *config.Config, np *nps.ProviderRegistry, vapidKeys *webpush.VAPIDKeys) *Handler {
 	return &Handler{
 		userRepo:               ur,
 		circleRepo:             cr,
@@ -62,6 +68,8 @@ func NewHandler(ur *uRepo.UserRepository, cr *cRepo.CircleRepository,
 		storage:                storage,
 		storageRepo:            storageRepo,
 		signer:                 signer,
+		notificationProviders:  np,
+		vapidKeys:              vapidKeys,
 	}
 }
 
@@ -624,6 +632,7 @@ func (h *Handler) UpdateUserDetails(c *gin.Context) {
 		ChatID      *int64  `json:"chatID" binding:"omitempty"`
 		Image       *string `json:"image" binding:"omitempty"`
 		Timezone    *string `json:"timezone" binding:"omitempty"`
+		Language    *string `json:"language" binding:"omitempty"`
 	}
 	user, ok := auth.CurrentUser(c)
 	if !ok {
@@ -644,6 +653,10 @@ func (h *Handler) UpdateUserDetails(c *gin.Context) {
 		user.DisplayName = *req.DisplayName
 	}
 	if req.ChatID != nil {
+		if *req.ChatID != user.ChatID {
+			// a chat id typed in the settings isn't verified, the bot only acts for chats linked with a code
+			user.TelegramLinked = false
+		}
 		user.ChatID = *req.ChatID
 	}
 	if req.Image != nil {
@@ -658,6 +671,18 @@ func (h *Handler) UpdateUserDetails(c *gin.Context) {
 		}
 		user.Timezone = *req.Timezone
 	}
+	if req.Language != nil {
+		if *req.Language != "" && !templates.IsSupportedLocale(*req.Language) {
+			c.JSON(400, gin.H{
+				"error": "Unsupported language",
+			})
+			return
+		}
+		user.Language = ""
+		if *req.Language != "" {
+			user.Language = templates.NormalizeLocale(*req.Language)
+		}
+	}
 
 	if err := h.userRepo.UpdateUser(c, &user.User); err != nil {
 		c.JSON(500, gin.H{
@@ -800,15 +825,37 @@ func (h *Handler) UpdateNotificationTarget(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification target"})
 			return
 		}
+		if err := h.userRepo.ReplaceNotificationTargets(c, currentUser.ID, nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification target"})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{})
 		return
 	}
+	if err := h.validateNotificationTarget(req.Type, req.Target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	err := h.userRepo.UpdateNotificationTarget(c, currentUser.ID, req.Target, req.Type)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification target"})
 		return
 	}
+	// this endpoint predates multiple targets, the single target replaces all of them
+	now := time.Now().UTC()
+	err = h.userRepo.ReplaceNotificationTargets(c, currentUser.ID, &uModel.NotificationTarget{
+		UserID:    currentUser.ID,
+		Type:      req.Type,
+		TargetID:  req.Target,
+		Enabled:   true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification target"})
+		return
+	}
 
 	err = h.userRepo.UpdateNotificationTargetForAllNotifications(c, currentUser.ID, req.Target, req.Type)
 	if err != nil {
@@ -900,7 +947,20 @@ func (h *Handler) setWebhook(c *gin.Context) {
 		return
 	}
 
-	err = h.circleRepo.SetWebhookURL(c, currentUser.CircleID, req.URL)
+	// deliveries are signed from the first one, so the circle gets its secret together with the URL
+	err = h.circleRepo.Transaction(c, func(ctx context.Context) error {
+		if err := h.circleRepo.SetWebhookURL(ctx, currentUser.CircleID, req.URL); err != nil {
+			return err
+		}
+		if req.URL == nil || *req.URL == "" {
+			return nil
+		}
+		secret, err := utils.GenerateWebhookSecret()
+		if err != nil {
+			return err
+		}
+		return h.circleRepo.SetWebhookSecretIfEmpty(ctx, currentUser.CircleID, secret)
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set webhook URL"})
 		return
@@ -1000,6 +1060,19 @@ func Routes(router *gin.Engine, h *Handler, auth *jwt.GinJWTMiddleware, limiter
 		userRoutes.DELETE("/tokens/:id", h.DeleteUserToken)
 		userRoutes.PUT("/webhook", h.setWebhook)
 		userRoutes.PUT("/targets", h.UpdateNotificationTarget)
+		userRoutes.GET("/notification-targets", h.getNotificationTargets)
+		userRoutes.POST("/notification-targets", h.createNotificationTarget)
+		userRoutes.PUT("/notification-targets/:id", h.updateNotificationTarget)
+		userRoutes.DELETE("/notification-targets/:id", h.deleteNotificationTarget)
+		userRoutes.GET("/notification-settings", h.getNotificationSettings)
+		userRoutes.PUT("/notification-settings", h.updateNotificationSettings)
+		userRoutes.POST("/dnd", h.enableDND)
+		userRoutes.DELETE("/dnd", h.disableDND)
+		userRoutes.GET("/push/vapid", h.getVAPIDPublicKey)
+		userRoutes.GET("/push", h.getPushSubscriptions)
+		userRoutes.POST("/push", h.createPushSubscription)
+		userRoutes.DELETE("/push/:id", h.deletePushSubscription)
+		userRoutes.POST("/telegram/link", h.createTelegramLinkCode)
 		userRoutes.PUT("change_password", h.updateUserPasswordLoggedInOnly)
 		userRoutes.POST("profile_photo", h.updateProfilePhoto)
 		userRoutes.GET("storage", h.getStorageUsage)
@@ -17,6 +17,9 @@ type User struct {
 	ChatID      int64            `json:"chatID" gorm:"column:chat_id"`           // Telegram chat ID
 	Image       string           `json:"image" gorm:"column:image"`              // Image
 	Timezone    string           `json:"timezone" gorm:"column:timezone"`        // Timezone
+	Language    string           `json:"language" gorm:"column:language"`        // Language of notifications, e.g. en or de
+	// TelegramLinked is set when the chat id was linked with a link code, only a linked chat acts for the user in the bot
+	TelegramLinked bool `json:"telegramLinked" gorm:"column:telegram_linked;default:false;not null"`
 	// MFA fields
 	MFAEnabled      bool      `json:"mfaEnabled" gorm:"column:mfa_enabled;default:false;not null"`    // MFA enabled status
 	MFASecret       string    `json:"-" gorm:"column:mfa_secret;type:text"`                           // TOTP secret (hidden from JSON)
@@ -52,12 +55,50 @@ type APIToken struct {
 	CreatedAt time.Time `json:"createdAt" gorm:"column:created_at"`
 }
 
+// UserNotificationTarget is the single target from before users could have several, it is kept in
+// sync by the legacy targets endpoint for older clients. NotificationTarget is used for delivery
 type UserNotificationTarget struct {
 	UserID    int                         `json:"userId" gorm:"column:user_id;index;primaryKey"` // Index on userID
 	Type      nModel.NotificationPlatform `json:"type" gorm:"column:type"`                       // Type
 	TargetID  string                      `json:"target_id" gorm:"column:target_id"`             // Target ID
 	CreatedAt time.Time                   `json:"-" gorm:"column:created_at"`
 }
+
+// NotificationTarget is one of the user's delivery channels, notifications go to every enabled
+// target whose event filter matches
+type NotificationTarget struct {
+	ID        int                         `json:"id" gorm:"primary_key"`
+	UserID    int                         `json:"userId" gorm:"column:user_id;index;not null"`
+	Name      string                      `json:"name" gorm:"column:name"`
+	Type      nModel.NotificationPlatform `json:"type" gorm:"column:type"`
+	TargetID  string                      `json:"target" gorm:"column:target_id"`
+	Events    nModel.EventFilter          `json:"events" gorm:"column:events;type:json"` // empty means every event
+	Enabled   bool                        `json:"enabled" gorm:"column:enabled;not null"`
+	CreatedAt time.Time                   `json:"createdAt" gorm:"column:created_at"`
+	UpdatedAt time.Time                   `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+// PushSubscription is a browser push subscription created with PushManager.subscribe, a user has one per browser or device
+type PushSubscription struct {
+	ID         int        `json:"id" gorm:"primary_key"`
+	UserID     int        `json:"userId" gorm:"column:user_id;index;not null"`
+	Endpoint   string     `json:"endpoint" gorm:"column:endpoint;uniqueIndex;not null"`
+	P256dh     string     `json:"-" gorm:"column:p256dh;not null"`
+	Auth       string     `json:"-" gorm:"column:auth;not null"`
+	UserAgent  string     `json:"userAgent" gorm:"column:user_agent"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"column:created_at"`
+	LastUsedAt *time.Time `json:"lastUsedAt" gorm:"column:last_used_at"`
+}
+
+// TelegramLinkCode is a one time code from the web UI that links the Telegram chat sending it to the user
+type TelegramLinkCode struct {
+	ID        int       `json:"-" gorm:"primary_key"`
+	Code      string    `json:"code" gorm:"column:code;uniqueIndex;not null"`
+	UserID    int       `json:"-" gorm:"column:user_id;index;not null"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"column:expires_at;not null"`
+	CreatedAt time.Time `json:"-" gorm:"column:created_at"`
+}
+
 type AuthProviderType int
 
 const (
@@ -0,0 +1,226 @@
+package user
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type QuietHoursPolicy string
+
+const (
+	// QuietHoursPolicyDefer holds notifications until the quiet hours or do not disturb end
+	QuietHoursPolicyDefer QuietHoursPolicy = "defer"
+	// QuietHoursPolicyDrop skips notifications that fall in quiet hours or do not disturb
+	QuietHoursPolicyDrop QuietHoursPolicy = "drop"
+)
+
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "off"
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+const defaultDigestTime = "08:00"
+
+// NotificationSettings are the user's quiet hours and do not disturb preferences, they apply to
+// the user's own notification targets and not to circle group chats
+type NotificationSettings struct {
+	UserID int `json:"userId" gorm:"column:user_id;primaryKey"`
+	// Timezone the quiet hours are in, the user's timezone when empty
+	Timezone   string            `json:"timezone" gorm:"column:timezone"`
+	QuietHours QuietHoursWindows `json:"quietHours" gorm:"column:quiet_hours;type:json"`
+	Policy     QuietHoursPolicy  `json:"policy" gorm:"column:policy;default:'defer'"`
+	// UrgentBypass lets P1 chores through quiet hours, do not disturb still holds them
+	UrgentBypass bool       `json:"urgentBypass" gorm:"column:urgent_bypass;not null"`
+	DNDUntil     *time.Time `json:"dndUntil" gorm:"column:dnd_until"`
+	// Digest sends a summary at DigestTime in the settings timezone, weekly digests go out on DigestWeekday
+	Digest        DigestFrequency `json:"digest" gorm:"column:digest"`
+	DigestTime    string          `json:"digestTime" gorm:"column:digest_time"`
+	DigestWeekday time.Weekday    `json:"digestWeekday" gorm:"column:digest_weekday;not null"`
+	// DigestOnly suppresses the individual pre-due, due and overdue reminders in favour of the digest
+	DigestOnly   bool       `json:"digestOnly" gorm:"column:digest_only;not null"`
+	LastDigestAt *time.Time `json:"lastDigestAt" gorm:"column:last_digest_at"`
+	UpdatedAt    time.Time  `json:"updatedAt" gorm:"column:updated_at"`
+}
+
+// QuietHoursWindow is a daily window in local time, e.g. 22:00 to 07:00 spans midnight
+type QuietHoursWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type QuietHoursWindows []QuietHoursWindow
+
+func (w QuietHoursWindows) Value() (driver.Value, error) {
+	if w == nil {
+		w = QuietHoursWindows{}
+	}
+	value, err := json.Marshal(w)
+	if err != nil {
+		return nil, err
+	}
+	return string(value), nil
+}
+
+func (w *QuietHoursWindows) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, w)
+	case string:
+		return json.Unmarshal([]byte(v), w)
+	default:
+		return errors.New("type assertion to []byte or string failed")
+	}
+}
+
+func (s *NotificationSettings) Validate() error {
+	if s.Timezone != "" {
+		if _, err := time.LoadLocation(s.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q", s.Timezone)
+		}
+	}
+	switch s.Policy {
+	case "", QuietHoursPolicyDefer, QuietHoursPolicyDrop:
+	default:
+		return fmt.Errorf("unknown quiet hours policy %q", s.Policy)
+	}
+	switch s.Digest {
+	case "", DigestOff, DigestDaily, DigestWeekly:
+	default:
+		return fmt.Errorf("unknown digest frequency %q", s.Digest)
+	}
+	if s.DigestTime != "" {
+		if _, err := parseClock(s.DigestTime); err != nil {
+			return err
+		}
+	}
+	if s.DigestWeekday < time.Sunday || s.DigestWeekday > time.Saturday {
+		return fmt.Errorf("invalid digest weekday %d", s.DigestWeekday)
+	}
+	for _, window := range s.QuietHours {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return fmt.Errorf("quiet hours window %s-%s is empty", window.Start, window.End)
+		}
+	}
+	return nil
+}
+
+// MutedUntil reports whether notifications are held at now and until when, urgent notifications
+// get through quiet hours when UrgentBypass is set
+func (s *NotificationSettings) MutedUntil(now time.Time, urgent bool) (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	if s.DNDUntil != nil && now.Before(*s.DNDUntil) {
+		return *s.DNDUntil, true
+	}
+	if urgent && s.UrgentBypass {
+		return time.Time{}, false
+	}
+
+	loc := s.Location()
+	until, muted := now, false
+	// windows can overlap or chain into each other, keep extending until no window covers the time
+	for i := 0; i <= len(s.QuietHours); i++ {
+		end, ok := s.windowEnd(until.In(loc))
+		if !ok {
+			break
+		}
+		until, muted = end, true
+	}
+	return until.UTC(), muted
+}
+
+// DigestEnabled reports whether the user receives digests
+func (s *NotificationSettings) DigestEnabled() bool {
+	return s != nil && (s.Digest == DigestDaily || s.Digest == DigestWeekly)
+}
+
+// Location is the settings timezone, UTC when it is not set or unknown
+func (s *NotificationSettings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// DigestDue returns the time the current digest was due at, and whether it is due at now and was
+// not sent yet. a digest that was missed is only sent later the same day
+func (s *NotificationSettings) DigestDue(now time.Time) (time.Time, bool) {
+	if !s.DigestEnabled() {
+		return time.Time{}, false
+	}
+	clock := s.DigestTime
+	if clock == "" {
+		clock = defaultDigestTime
+	}
+	minutes, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+	local := now.In(s.Location())
+	if s.Digest == DigestWeekly && local.Weekday() != s.DigestWeekday {
+		return time.Time{}, false
+	}
+	sendAt := time.Date(local.Year(), local.Month(), local.Day(), 0, minutes, 0, 0, local.Location())
+	if local.Before(sendAt) {
+		return time.Time{}, false
+	}
+	if s.LastDigestAt != nil && !s.LastDigestAt.Before(sendAt) {
+		return time.Time{}, false
+	}
+	return sendAt, true
+}
+
+// windowEnd returns the end of the latest ending window that covers t
+func (s *NotificationSettings) windowEnd(t time.Time) (time.Time, bool) {
+	var latest time.Time
+	found := false
+	for _, window := range s.QuietHours {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			continue
+		}
+		end, err := parseClock(window.End)
+		if err != nil || start == end {
+			continue
+		}
+		// the window may have started yesterday when it spans midnight
+		for _, dayOffset := range []int{-1, 0} {
+			windowStart := time.Date(t.Year(), t.Month(), t.Day()+dayOffset, 0, start, 0, 0, t.Location())
+			windowEnd := time.Date(t.Year(), t.Month(), t.Day()+dayOffset, 0, end, 0, 0, t.Location())
+			if end < start {
+				windowEnd = windowEnd.AddDate(0, 0, 1)
+			}
+			if !t.Before(windowStart) && t.Before(windowEnd) && windowEnd.After(latest) {
+				latest, found = windowEnd, true
+			}
+		}
+	}
+	return latest, found
+}
+
+// parseClock parses HH:MM into minutes since midnight
+func parseClock(clock string) (int, error) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", clock)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
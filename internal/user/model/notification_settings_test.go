@@ -0,0 +1,195 @@
+package user
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotificationSettingsMutedUntil(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, berlin)
+	}
+	dnd := at(10, 12, 0)
+
+	tests := []struct {
+		name      string
+		settings  *NotificationSettings
+		now       time.Time
+		urgent    bool
+		wantMuted bool
+		wantUntil time.Time
+	}{
+		{
+			name:      "no settings",
+			now:       at(10, 3, 0),
+			wantMuted: false,
+		},
+		{
+			name:      "inside a window spanning midnight, after midnight",
+			settings:  &NotificationSettings{Timezone: "Europe/Berlin", QuietHours: QuietHoursWindows{{Start: "22:00", End: "07:00"}}},
+			now:       at(10, 3, 0),
+			wantMuted: true,
+			wantUntil: at(10, 7, 0),
+		},
+		{
+			name:      "inside a window spanning midnight, before midnight",
+			settings:  &NotificationSettings{Timezone: "Europe/Berlin", QuietHours: QuietHoursWindows{{Start: "22:00", End: "07:00"}}},
+			now:       at(10, 23, 30),
+			wantMuted: true,
+			wantUntil: at(11, 7, 0),
+		},
+		{
+			name:      "outside the window",
+			settings:  &NotificationSettings{Timezone: "Europe/Berlin", QuietHours: QuietHoursWindows{{Start: "22:00", End: "07:00"}}},
+			now:       at(10, 7, 0),
+			wantMuted: false,
+		},
+		{
+			name: "chained windows",
+			settings: &NotificationSettings{Timezone: "Europe/Berlin", QuietHours: QuietHoursWindows{
+				{Start: "22:00", End: "07:00"},
+				{Start: "06:30", End: "09:00"},
+			}},
+			now:       at(10, 2, 0),
+			wantMuted: true,
+			wantUntil: at(10, 9, 0),
+		},
+		{
+			name:      "across the daylight saving change",
+			settings:  &NotificationSettings{Timezone: "Europe/Berlin", QuietHours: QuietHoursWindows{{Start: "22:00", End: "07:00"}}},
+			now:       at(28, 23, 0),
+			wantMuted: true,
+			wantUntil: at(29, 7, 0),
+		},
+		{
+			name:      "urgent bypasses quiet hours",
+			settings:  &NotificationSettings{Timezone: "Europe/Berlin", QuietHours: QuietHoursWindows{{Start: "22:00", End: "07:00"}}, UrgentBypass: true},
+			now:       at(10, 3, 0),
+			urgent:    true,
+			wantMuted: false,
+		},
+		{
+			name:      "urgent does not bypass do not disturb",
+			settings:  &NotificationSettings{UrgentBypass: true, DNDUntil: &dnd},
+			now:       at(10, 10, 0),
+			urgent:    true,
+			wantMuted: true,
+			wantUntil: dnd,
+		},
+		{
+			name:      "expired do not disturb",
+			settings:  &NotificationSettings{DNDUntil: &dnd},
+			now:       at(10, 13, 0),
+			wantMuted: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, muted := tt.settings.MutedUntil(tt.now.UTC(), tt.urgent)
+			if muted != tt.wantMuted {
+				t.Fatalf("muted = %v, want %v", muted, tt.wantMuted)
+			}
+			if muted && !until.Equal(tt.wantUntil) {
+				t.Errorf("until = %v, want %v", until.In(berlin), tt.wantUntil)
+			}
+		})
+	}
+}
+
+func TestNotificationSettingsValidate(t *testing.T) {
+	tests := map[string]struct {
+		settings NotificationSettings
+		valid    bool
+	}{
+		"valid":            {NotificationSettings{Timezone: "America/New_York", Policy: QuietHoursPolicyDrop, QuietHours: QuietHoursWindows{{Start: "21:30", End: "06:00"}}}, true},
+		"unknown timezone": {NotificationSettings{Timezone: "Mars/Olympus"}, false},
+		"unknown policy":   {NotificationSettings{Policy: "snooze"}, false},
+		"bad time":         {NotificationSettings{QuietHours: QuietHoursWindows{{Start: "25:00", End: "06:00"}}}, false},
+		"empty window":     {NotificationSettings{QuietHours: QuietHoursWindows{{Start: "06:00", End: "06:00"}}}, false},
+		"unknown digest":   {NotificationSettings{Digest: "hourly"}, false},
+		"bad digest time":  {NotificationSettings{Digest: DigestDaily, DigestTime: "8am"}, false},
+		"bad weekday":      {NotificationSettings{Digest: DigestWeekly, DigestWeekday: 7}, false},
+	}
+	for name, tt := range tests {
+		if err := tt.settings.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate() error = %v, want valid %v", name, err, tt.valid)
+		}
+	}
+}
+
+func TestNotificationSettingsDigestDue(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+	// 2026-03-10 is a Tuesday
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.March, day, hour, minute, 0, 0, berlin)
+	}
+	sent := at(10, 8, 0)
+
+	tests := []struct {
+		name     string
+		settings *NotificationSettings
+		now      time.Time
+		wantDue  bool
+		wantSend time.Time
+	}{
+		{
+			name:     "digest off",
+			settings: &NotificationSettings{Timezone: "Europe/Berlin", Digest: DigestOff},
+			now:      at(10, 9, 0),
+		},
+		{
+			name:     "before the digest time",
+			settings: &NotificationSettings{Timezone: "Europe/Berlin", Digest: DigestDaily, DigestTime: "08:00"},
+			now:      at(10, 7, 59),
+		},
+		{
+			name:     "daily digest due",
+			settings: &NotificationSettings{Timezone: "Europe/Berlin", Digest: DigestDaily},
+			now:      at(10, 8, 15),
+			wantDue:  true,
+			wantSend: at(10, 8, 0),
+		},
+		{
+			name:     "already sent today",
+			settings: &NotificationSettings{Timezone: "Europe/Berlin", Digest: DigestDaily, DigestTime: "08:00", LastDigestAt: &sent},
+			now:      at(10, 20, 0),
+		},
+		{
+			name:     "sent yesterday",
+			settings: &NotificationSettings{Timezone: "Europe/Berlin", Digest: DigestDaily, DigestTime: "08:00", LastDigestAt: &sent},
+			now:      at(11, 8, 0),
+			wantDue:  true,
+			wantSend: at(11, 8, 0),
+		},
+		{
+			name:     "weekly digest on another day",
+			settings: &NotificationSettings{Timezone: "Europe/Berlin", Digest: DigestWeekly, DigestWeekday: time.Monday},
+			now:      at(10, 9, 0),
+		},
+		{
+			name:     "weekly digest on its day",
+			settings: &NotificationSettings{Timezone: "Europe/Berlin", Digest: DigestWeekly, DigestWeekday: time.Monday, DigestTime: "07:30"},
+			now:      at(16, 9, 0),
+			wantDue:  true,
+			wantSend: at(16, 7, 30),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sendAt, due := tt.settings.DigestDue(tt.now.UTC())
+			if due != tt.wantDue {
+				t.Fatalf("due = %v, want %v", due, tt.wantDue)
+			}
+			if due && !sendAt.Equal(tt.wantSend) {
+				t.Errorf("sendAt = %v, want %v", sendAt.In(berlin), tt.wantSend)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package user
+
+import (
+	"net/http"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const maxDNDDuration = 30 * 24 * time.Hour
+
+type NotificationSettingsReq struct {
+	Timezone      string                   `json:"timezone"`
+	QuietHours    uModel.QuietHoursWindows `json:"quietHours"`
+	Policy        uModel.QuietHoursPolicy  `json:"policy"`
+	UrgentBypass  bool                     `json:"urgentBypass"`
+	Digest        uModel.DigestFrequency   `json:"digest"`
+	DigestTime    string                   `json:"digestTime"`
+	DigestWeekday time.Weekday             `json:"digestWeekday"`
+	DigestOnly    bool                     `json:"digestOnly"`
+}
+
+// DNDReq turns on do not disturb for the given number of minutes
+type DNDReq struct {
+	Minutes int `json:"minutes" binding:"required,min=1"`
+}
+
+// notificationSettings returns the stored settings or the defaults for a user without any
+func (h *Handler) notificationSettings(c *gin.Context, user *uModel.User) (*uModel.NotificationSettings, error) {
+	settings, err := h.userRepo.GetNotificationSettings(c, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &uModel.NotificationSettings{
+			UserID:     user.ID,
+			Timezone:   user.Timezone,
+			QuietHours: uModel.QuietHoursWindows{},
+			Policy:     uModel.QuietHoursPolicyDefer,
+			Digest:     uModel.DigestOff,
+			DigestTime: "08:00",
+		}
+	}
+	return settings, nil
+}
+
+func (h *Handler) getNotificationSettings(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+
+	settings, err := h.notificationSettings(c, &currentUser.User)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"res": settings})
+}
+
+func (h *Handler) updateNotificationSettings(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+	var req NotificationSettingsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	settings, err := h.notificationSettings(c, &currentUser.User)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification settings"})
+		return
+	}
+	settings.Timezone = req.Timezone
+	if settings.Timezone == "" {
+		settings.Timezone = currentUser.Timezone
+	}
+	settings.QuietHours = req.QuietHours
+	settings.Policy = req.Policy
+	if settings.Policy == "" {
+		settings.Policy = uModel.QuietHoursPolicyDefer
+	}
+	settings.UrgentBypass = req.UrgentBypass
+	wasDigestEnabled := settings.DigestEnabled()
+	settings.Digest = req.Digest
+	if settings.Digest == "" {
+		settings.Digest = uModel.DigestOff
+	}
+	settings.DigestTime = req.DigestTime
+	if settings.DigestTime == "" {
+		settings.DigestTime = "08:00"
+	}
+	settings.DigestWeekday = req.DigestWeekday
+	settings.DigestOnly = req.DigestOnly
+	if settings.DigestEnabled() && !wasDigestEnabled {
+		// start with the next digest instead of sending one for a time that already passed today
+		now := time.Now().UTC()
+		settings.LastDigestAt = &now
+	}
+	if err := settings.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	settings.UpdatedAt = time.Now().UTC()
+	if err := h.userRepo.SaveNotificationSettings(c, settings); err != nil {
+		logging.FromContext(c).Errorw("Failed to save notification settings", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"res": settings})
+}
+
+func (h *Handler) enableDND(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+	var req DNDReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	duration := time.Duration(req.Minutes) * time.Minute
+	if duration > maxDNDDuration {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Do not disturb can be enabled for at most 30 days"})
+		return
+	}
+
+	h.setDND(c, &currentUser.User, time.Now().UTC().Add(duration))
+}
+
+func (h *Handler) disableDND(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+	h.setDND(c, &currentUser.User, time.Time{})
+}
+
+// setDND stores the do not disturb expiry, a zero until turns it off
+func (h *Handler) setDND(c *gin.Context, user *uModel.User, until time.Time) {
+	settings, err := h.notificationSettings(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification settings"})
+		return
+	}
+	settings.DNDUntil = nil
+	if !until.IsZero() {
+		settings.DNDUntil = &until
+	}
+	settings.UpdatedAt = time.Now().UTC()
+	if err := h.userRepo.SaveNotificationSettings(c, settings); err != nil {
+		logging.FromContext(c).Errorw("Failed to save do not disturb", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"res": settings})
+}
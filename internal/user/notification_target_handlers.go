@@ -0,0 +1,175 @@
+package user
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	nModel "donetick.com/core/internal/notifier/model"
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationTargetReq creates or updates one of the user's notification targets, events
+// limits the target to pre_due, due, overdue, completion or nagging and is all events when empty
+type NotificationTargetReq struct {
+	Name    string                      `json:"name"`
+	Type    nModel.NotificationPlatform `json:"type"`
+	Target  string                      `json:"target"`
+	Events  nModel.EventFilter          `json:"events"`
+	Enabled *bool                       `json:"enabled"`
+}
+
+// validateNotificationTarget checks the target with the platform's provider
+func (h *Handler) validateNotificationTarget(platform nModel.NotificationPlatform, target string) error {
+	// webhook users may leave the target empty to rely on the circle webhook only
+	if platform == nModel.NotificationPlatformWebhook && target == "" {
+		return nil
+	}
+	return h.notificationProviders.ValidateTarget(platform, target)
+}
+
+func (h *Handler) bindNotificationTarget(c *gin.Context) (*NotificationTargetReq, bool) {
+	var req NotificationTargetReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return nil, false
+	}
+	if req.Type == nModel.NotificationPlatformNone {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Notification target type is required"})
+		return nil, false
+	}
+	if err := req.Events.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err := h.validateNotificationTarget(req.Type, req.Target); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &req, true
+}
+
+func (h *Handler) getNotificationTargets(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+
+	targets, err := h.userRepo.GetNotificationTargets(c, currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification targets"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"res": targets})
+}
+
+func (h *Handler) createNotificationTarget(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+	req, ok := h.bindNotificationTarget(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	target := &uModel.NotificationTarget{
+		UserID:    currentUser.ID,
+		Name:      req.Name,
+		Type:      req.Type,
+		TargetID:  req.Target,
+		Events:    req.Events,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := h.userRepo.SaveNotificationTarget(c, target); err != nil {
+		logging.FromContext(c).Errorw("Failed to create notification target", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification target"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"res": target})
+}
+
+// updateNotificationTarget changes a target, reminders already planned for it follow the new
+// platform and target while event filter changes apply to the next planned reminders
+func (h *Handler) updateNotificationTarget(c *gin.Context) {
+	log := logging.FromContext(c)
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification target ID"})
+		return
+	}
+	target, err := h.userRepo.GetNotificationTargetByID(c, currentUser.ID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification target not found"})
+		return
+	}
+	req, ok := h.bindNotificationTarget(c)
+	if !ok {
+		return
+	}
+
+	previous := *target
+	target.Name = req.Name
+	target.Type = req.Type
+	target.TargetID = req.Target
+	target.Events = req.Events
+	if req.Enabled != nil {
+		target.Enabled = *req.Enabled
+	}
+	target.UpdatedAt = time.Now().UTC()
+	if err := h.userRepo.SaveNotificationTarget(c, target); err != nil {
+		log.Errorw("Failed to update notification target", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification target"})
+		return
+	}
+
+	if !target.Enabled {
+		err = h.userRepo.DeletePendingNotificationsForTarget(c, currentUser.ID, &previous)
+	} else if previous.Type != target.Type || previous.TargetID != target.TargetID {
+		err = h.userRepo.UpdatePendingNotificationsTarget(c, currentUser.ID, &previous, target)
+	}
+	if err != nil {
+		log.Errorw("Failed to update pending notifications for target", "target_id", target.ID, "error", err)
+	}
+	c.JSON(http.StatusOK, gin.H{"res": target})
+}
+
+func (h *Handler) deleteNotificationTarget(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification target ID"})
+		return
+	}
+	target, err := h.userRepo.GetNotificationTargetByID(c, currentUser.ID, id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification target not found"})
+		return
+	}
+
+	if err := h.userRepo.DeleteNotificationTargetByID(c, currentUser.ID, target.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification target"})
+		return
+	}
+	if err := h.userRepo.DeletePendingNotificationsForTarget(c, currentUser.ID, target); err != nil {
+		logging.FromContext(c).Errorw("Failed to delete pending notifications for target", "target_id", target.ID, "error", err)
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
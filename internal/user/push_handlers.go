@@ -0,0 +1,100 @@
+package user
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// PushSubscriptionReq mirrors PushSubscription.toJSON() from the browser
+type PushSubscriptionReq struct {
+	Endpoint string `json:"endpoint" binding:"required"`
+	Keys     struct {
+		P256dh string `json:"p256dh" binding:"required"`
+		Auth   string `json:"auth" binding:"required"`
+	} `json:"keys" binding:"required"`
+}
+
+// getVAPIDPublicKey returns the applicationServerKey the browser subscribes with
+func (h *Handler) getVAPIDPublicKey(c *gin.Context) {
+	publicKey, _, err := h.vapidKeys.Get(c)
+	if err != nil {
+		logging.FromContext(c).Errorw("Failed to load VAPID keys", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load web push keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"res": gin.H{"publicKey": publicKey}})
+}
+
+func (h *Handler) getPushSubscriptions(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+
+	subscriptions, err := h.userRepo.GetPushSubscriptions(c, currentUser.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get push subscriptions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"res": subscriptions})
+}
+
+func (h *Handler) createPushSubscription(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+
+	var req PushSubscriptionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		return
+	}
+	if endpoint, err := url.Parse(req.Endpoint); err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Push endpoint must be an https URL"})
+		return
+	}
+
+	subscription := &uModel.PushSubscription{
+		UserID:    currentUser.ID,
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: c.Request.UserAgent(),
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.userRepo.SavePushSubscription(c, subscription); err != nil {
+		logging.FromContext(c).Errorw("Failed to save push subscription", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save push subscription"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"res": subscription})
+}
+
+func (h *Handler) deletePushSubscription(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+
+	subscriptionID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subscription ID"})
+		return
+	}
+	if err := h.userRepo.DeletePushSubscription(c, currentUser.ID, subscriptionID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete push subscription"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{})
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"donetick.com/core/config"
+	"donetick.com/core/internal/database/dbtx"
 	nModel "donetick.com/core/internal/notifier/model"
 	storageModel "donetick.com/core/internal/storage/model"
 	uModel "donetick.com/core/internal/user/model"
@@ -45,7 +47,7 @@ func NewUserRepository(db *gorm.DB, cfg *config.Config) *UserRepository {
 
 func (r *UserRepository) GetAllUsers(c context.Context, circleID int) ([]*uModel.User, error) {
 	var users []*uModel.User
-	if err := r.db.WithContext(c).Where("circle_id = ?", circleID).Find(&users).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Where("circle_id = ?", circleID).Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
@@ -53,16 +55,16 @@ func (r *UserRepository) GetAllUsers(c context.Context, circleID int) ([]*uModel
 
 func (r *UserRepository) GetAllUsersForSystemOnly(c context.Context) ([]*uModel.User, error) {
 	var users []*uModel.User
-	if err := r.db.WithContext(c).Find(&users).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Find(&users).Error; err != nil {
 		return nil, err
 	}
 	return users, nil
 }
 func (r *UserRepository) CreateUser(c context.Context, user *uModel.User) (*uModel.User, error) {
-	if err := r.db.WithContext(c).Create(user).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Create(user).Error; err != nil {
 		return nil, err
 	}
-	if err := r.db.WithContext(c).Create(&storageModel.StorageUsage{
+	if err := dbtx.DB(c, r.db).Create(&storageModel.StorageUsage{
 		UserID:    user.ID,
 		UsedBytes: 0,
 		UpdatedAt: time.Now().UTC(),
@@ -75,12 +77,12 @@ func (r *UserRepository) CreateUser(c context.Context, user *uModel.User) (*uMod
 func (r *UserRepository) GetUserByUsername(c context.Context, username string) (*uModel.UserDetails, error) {
 	var user *uModel.UserDetails
 	if r.isDonetickDotCom {
-		if err := r.db.WithContext(c).Preload("UserNotificationTargets").Table("users u").Select("u.*, ss.status as  subscription, ss.expired_at as expiration, c.webhook_url as webhook_url").Joins("left join stripe_customers sc on sc.user_id = u.id ").Joins("left join stripe_subscriptions ss on sc.customer_id = ss.customer_id").Joins("left join circles c on c.id = u.circle_id").Where("username = ?", username).First(&user).Error; err != nil {
+		if err := dbtx.DB(c, r.db).Preload("UserNotificationTargets").Table("users u").Select("u.*, ss.status as  subscription, ss.expired_at as expiration, c.webhook_url as webhook_url").Joins("left join stripe_customers sc on sc.user_id = u.id ").Joins("left join stripe_subscriptions ss on sc.customer_id = ss.customer_id").Joins("left join circles c on c.id = u.circle_id").Where("username = ?", username).First(&user).Error; err != nil {
 			return nil, err
 		}
 	} else {
 		// For self-hosted, first get the user without subscription/expiration fields
-		if err := r.db.WithContext(c).Preload("UserNotificationTargets").Table("users u").Select("u.*, c.webhook_url as webhook_url").Joins("left join circles c on c.id = u.circle_id").Where("username = ?", username).First(&user).Error; err != nil {
+		if err := dbtx.DB(c, r.db).Preload("UserNotificationTargets").Table("users u").Select("u.*, c.webhook_url as webhook_url").Joins("left join circles c on c.id = u.circle_id").Where("username = ?", username).First(&user).Error; err != nil {
 			return nil, err
 		}
 		// Then manually set the subscription status and expiration for self-hosted users
@@ -96,7 +98,7 @@ func (r *UserRepository) GetUserByUsername(c context.Context, username string) (
 func (r *UserRepository) GetUserByID(c context.Context, userID int) (*uModel.User, error) {
 	var user *uModel.User
 	if r.isDonetickDotCom {
-		if err := r.db.WithContext(c).Preload("UserNotificationTargets").
+		if err := dbtx.DB(c, r.db).Preload("UserNotificationTargets").
 			Table("users u").
 			Select("u.*, ss.status as subscription, ss.expired_at as expiration, c.webhook_url as webhook_url").
 			Joins("left join stripe_customers sc on sc.user_id = u.id").
@@ -107,7 +109,7 @@ func (r *UserRepository) GetUserByID(c context.Context, userID int) (*uModel.Use
 			return nil, err
 		}
 	} else {
-		if err := r.db.WithContext(c).Preload("UserNotificationTargets").
+		if err := dbtx.DB(c, r.db).Preload("UserNotificationTargets").
 			Table("users u").
 			Select("u.*, c.webhook_url as webhook_url").
 			Joins("left join circles c on c.id = u.circle_id").
@@ -126,17 +128,110 @@ func (r *UserRepository) GetUserByID(c context.Context, userID int) (*uModel.Use
 	return user, nil
 }
 
+// GetUserByTelegramChatID returns the user who linked the chat with a link code, nil when there is none.
+// notification targets and chat ids set in the user settings are free-form, they never identify a user
+func (r *UserRepository) GetUserByTelegramChatID(c context.Context, chatID int64) (*uModel.UserDetails, error) {
+	var userIDs []int
+	if err := dbtx.DB(c, r.db).Model(&uModel.User{}).Where("chat_id = ? AND telegram_linked = ?", chatID, true).Order("id asc").Limit(1).Pluck("id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	var users []*uModel.UserDetails
+	if err := dbtx.DB(c, r.db).
+		Table("users u").
+		Select("u.*, c.webhook_url as webhook_url").
+		Joins("left join circles c on c.id = u.circle_id").
+		Where("u.id = ? AND u.disabled = ?", userIDs[0], false).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return nil, nil
+	}
+	return users[0], nil
+}
+
+// CreateTelegramLinkCode stores a new link code for the user, replacing any code they had not used yet
+func (r *UserRepository) CreateTelegramLinkCode(c context.Context, code *uModel.TelegramLinkCode) error {
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", code.UserID).Delete(&uModel.TelegramLinkCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(code).Error
+	})
+}
+
+// ConsumeTelegramLinkCode deletes the code and returns it when it has not expired, nil when there is no such code
+func (r *UserRepository) ConsumeTelegramLinkCode(c context.Context, code string, now time.Time) (*uModel.TelegramLinkCode, error) {
+	var linkCode *uModel.TelegramLinkCode
+	err := dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
+		var codes []*uModel.TelegramLinkCode
+		if err := tx.Where("code = ?", code).Limit(1).Find(&codes).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		if err := tx.Delete(codes[0]).Error; err != nil {
+			return err
+		}
+		if codes[0].ExpiresAt.After(now) {
+			linkCode = codes[0]
+		}
+		return nil
+	})
+	return linkCode, err
+}
+
+// LinkTelegramChat makes the chat the user's Telegram chat and adds it as a notification target,
+// the chat is unlinked from any other user so it always resolves to one account
+func (r *UserRepository) LinkTelegramChat(c context.Context, userID int, chatID int64) error {
+	targetID := strconv.FormatInt(chatID, 10)
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&uModel.User{}).Where("chat_id = ? AND id <> ?", chatID, userID).Updates(map[string]interface{}{"chat_id": 0, "telegram_linked": false}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("type = ? AND target_id = ? AND user_id <> ?", nModel.NotificationPlatformTelegram, targetID, userID).Delete(&uModel.NotificationTarget{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&uModel.User{}).Where("id = ?", userID).Updates(map[string]interface{}{"chat_id": chatID, "telegram_linked": true}).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&uModel.NotificationTarget{}).Where("user_id = ? AND type = ? AND target_id = ?", userID, nModel.NotificationPlatformTelegram, targetID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		now := time.Now().UTC()
+		return tx.Create(&uModel.NotificationTarget{
+			UserID:    userID,
+			Name:      "Telegram",
+			Type:      nModel.NotificationPlatformTelegram,
+			TargetID:  targetID,
+			Enabled:   true,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}).Error
+	})
+}
+
 func (r *UserRepository) UpdateUser(c context.Context, user *uModel.User) error {
-	return r.db.WithContext(c).Save(user).Error
+	return dbtx.DB(c, r.db).Save(user).Error
 }
 
 func (r *UserRepository) UpdateUserCircle(c context.Context, userID, circleID int) error {
-	return r.db.WithContext(c).Model(&uModel.User{}).Where("id = ?", userID).Update("circle_id", circleID).Error
+	return dbtx.DB(c, r.db).Model(&uModel.User{}).Where("id = ?", userID).Update("circle_id", circleID).Error
 }
 
 func (r *UserRepository) FindByEmail(c context.Context, email string) (*uModel.UserDetails, error) {
 	var user *uModel.UserDetails
-	if err := r.db.WithContext(c).Table("users u").Select("u.*, c.webhook_url as webhook_url").Joins("left join circles c on c.id = u.circle_id").Where("email = ?", email).First(&user).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Table("users u").Select("u.*, c.webhook_url as webhook_url").Joins("left join circles c on c.id = u.circle_id").Where("email = ?", email).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -149,7 +244,7 @@ func (r *UserRepository) SetPasswordResetToken(c context.Context, email, token s
 		return err
 	}
 	// save new token:
-	if err := r.db.WithContext(c).Model(&uModel.UserPasswordReset{}).Save(&uModel.UserPasswordReset{
+	if err := dbtx.DB(c, r.db).Model(&uModel.UserPasswordReset{}).Save(&uModel.UserPasswordReset{
 		UserID:         user.ID,
 		Token:          token,
 		Email:          email,
@@ -168,12 +263,12 @@ func (r *UserRepository) UpdatePasswordByToken(ctx context.Context, email string
 		Email: email,
 		Token: token,
 	}
-	result := r.db.WithContext(ctx).Where("email = ?", email).Where("token = ?", token).Delete(upr)
+	result := dbtx.DB(ctx, r.db).Where("email = ?", email).Where("token = ?", token).Delete(upr)
 	if result.RowsAffected <= 0 {
 		return fmt.Errorf("invalid token")
 	}
 	// find account by email and update password:
-	chain := r.db.WithContext(ctx).Model(&uModel.User{}).Where("email = ?", email).UpdateColumns(map[string]interface{}{"password": password})
+	chain := dbtx.DB(ctx, r.db).Model(&uModel.User{}).Where("email = ?", email).UpdateColumns(map[string]interface{}{"password": password})
 	if chain.Error != nil {
 		return chain.Error
 	}
@@ -191,7 +286,7 @@ func (r *UserRepository) StoreAPIToken(c context.Context, userID int, name strin
 		Token:     tokenCode,
 		CreatedAt: time.Now().UTC(),
 	}
-	if err := r.db.WithContext(c).Model(&uModel.APIToken{}).Save(
+	if err := dbtx.DB(c, r.db).Model(&uModel.APIToken{}).Save(
 		token).Error; err != nil {
 		return nil, err
 
@@ -202,7 +297,7 @@ func (r *UserRepository) StoreAPIToken(c context.Context, userID int, name strin
 func (r *UserRepository) GetUserByToken(c context.Context, token string) (*uModel.UserDetails, error) {
 	var user *uModel.UserDetails
 
-	if err := r.db.WithContext(c).Table("users u").Select("u.*, c.webhook_url as webhook_url").Joins("left join api_tokens at on at.user_id = u.id").Joins("left join circles c on c.id = u.circle_id").Where("at.token = ?", token).First(&user).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Table("users u").Select("u.*, c.webhook_url as webhook_url").Joins("left join api_tokens at on at.user_id = u.id").Joins("left join circles c on c.id = u.circle_id").Where("at.token = ?", token).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -210,18 +305,18 @@ func (r *UserRepository) GetUserByToken(c context.Context, token string) (*uMode
 
 func (r *UserRepository) GetAllUserTokens(c context.Context, userID int) ([]*uModel.APIToken, error) {
 	var tokens []*uModel.APIToken
-	if err := r.db.WithContext(c).Where("user_id = ?", userID).Find(&tokens).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Where("user_id = ?", userID).Find(&tokens).Error; err != nil {
 		return nil, err
 	}
 	return tokens, nil
 }
 
 func (r *UserRepository) DeleteAPIToken(c context.Context, userID int, tokenID string) error {
-	return r.db.WithContext(c).Where("id = ? AND user_id = ?", tokenID, userID).Delete(&uModel.APIToken{}).Error
+	return dbtx.DB(c, r.db).Where("id = ? AND user_id = ?", tokenID, userID).Delete(&uModel.APIToken{}).Error
 }
 
 func (r *UserRepository) UpdateNotificationTarget(c context.Context, userID int, targetID string, targetType nModel.NotificationPlatform) error {
-	return r.db.WithContext(c).Save(&uModel.UserNotificationTarget{
+	return dbtx.DB(c, r.db).Save(&uModel.UserNotificationTarget{
 		UserID:    userID,
 		TargetID:  targetID,
 		Type:      targetType,
@@ -230,17 +325,98 @@ func (r *UserRepository) UpdateNotificationTarget(c context.Context, userID int,
 }
 
 func (r *UserRepository) DeleteNotificationTarget(c context.Context, userID int) error {
-	return r.db.WithContext(c).Where("user_id = ?", userID).Delete(&uModel.UserNotificationTarget{}).Error
+	return dbtx.DB(c, r.db).Where("user_id = ?", userID).Delete(&uModel.UserNotificationTarget{}).Error
 }
 
 func (r *UserRepository) UpdateNotificationTargetForAllNotifications(c context.Context, userID int, targetID string, targetType nModel.NotificationPlatform) error {
-	return r.db.WithContext(c).Model(&nModel.Notification{}).Where("user_id = ?", userID).Update("target_id", targetID).Update("type", targetType).Error
+	return dbtx.DB(c, r.db).Model(&nModel.Notification{}).Where("user_id = ?", userID).Update("target_id", targetID).Update("type", targetType).Error
+}
+
+func (r *UserRepository) GetNotificationTargets(c context.Context, userID int) ([]*uModel.NotificationTarget, error) {
+	var targets []*uModel.NotificationTarget
+	if err := dbtx.DB(c, r.db).Where("user_id = ?", userID).Order("id asc").Find(&targets).Error; err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+func (r *UserRepository) GetNotificationTargetByID(c context.Context, userID int, targetID int) (*uModel.NotificationTarget, error) {
+	var target uModel.NotificationTarget
+	if err := dbtx.DB(c, r.db).Where("id = ? AND user_id = ?", targetID, userID).First(&target).Error; err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+func (r *UserRepository) SaveNotificationTarget(c context.Context, target *uModel.NotificationTarget) error {
+	return dbtx.DB(c, r.db).Save(target).Error
+}
+
+func (r *UserRepository) DeleteNotificationTargetByID(c context.Context, userID int, targetID int) error {
+	return dbtx.DB(c, r.db).Where("id = ? AND user_id = ?", targetID, userID).Delete(&uModel.NotificationTarget{}).Error
+}
+
+// ReplaceNotificationTargets swaps all of the user's targets for the given one, or removes them when target is nil
+func (r *UserRepository) ReplaceNotificationTargets(c context.Context, userID int, target *uModel.NotificationTarget) error {
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&uModel.NotificationTarget{}).Error; err != nil {
+			return err
+		}
+		if target == nil {
+			return nil
+		}
+		return tx.Create(target).Error
+	})
 }
+
+// UpdatePendingNotificationsTarget moves the user's unsent notifications from one target to another
+func (r *UserRepository) UpdatePendingNotificationsTarget(c context.Context, userID int, from, to *uModel.NotificationTarget) error {
+	return dbtx.DB(c, r.db).Model(&nModel.Notification{}).
+		Where("user_id = ? AND is_sent = ? AND type = ? AND target_id = ?", userID, false, from.Type, from.TargetID).
+		Updates(map[string]interface{}{"type": to.Type, "target_id": to.TargetID}).Error
+}
+
+// DeletePendingNotificationsForTarget drops the user's unsent notifications for a removed or disabled target
+func (r *UserRepository) DeletePendingNotificationsForTarget(c context.Context, userID int, target *uModel.NotificationTarget) error {
+	return dbtx.DB(c, r.db).
+		Where("user_id = ? AND is_sent = ? AND type = ? AND target_id = ?", userID, false, target.Type, target.TargetID).
+		Delete(&nModel.Notification{}).Error
+}
+
+// GetNotificationSettings returns the user's quiet hours settings, nil when the user has none
+func (r *UserRepository) GetNotificationSettings(c context.Context, userID int) (*uModel.NotificationSettings, error) {
+	var settings []*uModel.NotificationSettings
+	if err := dbtx.DB(c, r.db).Where("user_id = ?", userID).Limit(1).Find(&settings).Error; err != nil {
+		return nil, err
+	}
+	if len(settings) == 0 {
+		return nil, nil
+	}
+	return settings[0], nil
+}
+
+// GetDigestSubscribers returns the settings of every user with daily or weekly digests enabled
+func (r *UserRepository) GetDigestSubscribers(c context.Context) ([]*uModel.NotificationSettings, error) {
+	var settings []*uModel.NotificationSettings
+	if err := dbtx.DB(c, r.db).Where("digest IN (?)", []uModel.DigestFrequency{uModel.DigestDaily, uModel.DigestWeekly}).Find(&settings).Error; err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *UserRepository) UpdateLastDigestAt(c context.Context, userID int, sentAt time.Time) error {
+	return dbtx.DB(c, r.db).Model(&uModel.NotificationSettings{}).Where("user_id = ?", userID).Update("last_digest_at", sentAt).Error
+}
+
+func (r *UserRepository) SaveNotificationSettings(c context.Context, settings *uModel.NotificationSettings) error {
+	return dbtx.DB(c, r.db).Save(settings).Error
+}
+
 func (r *UserRepository) UpdatePasswordByUserId(c context.Context, userID int, password string) error {
-	return r.db.WithContext(c).Model(&uModel.User{}).Where("id = ?", userID).Update("password", password).Error
+	return dbtx.DB(c, r.db).Model(&uModel.User{}).Where("id = ?", userID).Update("password", password).Error
 }
 func (r *UserRepository) UpdateUserImage(c context.Context, userID int, image string) error {
-	return r.db.WithContext(c).Model(&uModel.User{}).Where("id = ?", userID).Update("image", image).Error
+	return dbtx.DB(c, r.db).Model(&uModel.User{}).Where("id = ?", userID).Update("image", image).Error
 }
 
 // MFA-related methods
@@ -252,7 +428,7 @@ func (r *UserRepository) EnableMFA(c context.Context, userID int, secret string,
 		return err
 	}
 
-	return r.db.WithContext(c).Model(&uModel.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+	return dbtx.DB(c, r.db).Model(&uModel.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
 		"mfa_enabled":             true,
 		"mfa_secret":              secret,
 		"mfa_backup_codes":        string(backupCodesJSON),
@@ -262,7 +438,7 @@ func (r *UserRepository) EnableMFA(c context.Context, userID int, secret string,
 
 // DisableMFA disables MFA for a user
 func (r *UserRepository) DisableMFA(c context.Context, userID int) error {
-	return r.db.WithContext(c).Model(&uModel.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+	return dbtx.DB(c, r.db).Model(&uModel.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
 		"mfa_enabled":             false,
 		"mfa_secret":              "",
 		"mfa_backup_codes":        "",
@@ -272,20 +448,20 @@ func (r *UserRepository) DisableMFA(c context.Context, userID int) error {
 
 // UpdateMFARecoveryCodes updates the used recovery codes for a user
 func (r *UserRepository) UpdateMFARecoveryCodes(c context.Context, userID int, usedCodes string) error {
-	return r.db.WithContext(c).Model(&uModel.User{}).Where("id = ?", userID).Update("mfa_recovery_codes_used", usedCodes).Error
+	return dbtx.DB(c, r.db).Model(&uModel.User{}).Where("id = ?", userID).Update("mfa_recovery_codes_used", usedCodes).Error
 }
 
 // MFA Session methods
 
 // CreateMFASession creates a new MFA session
 func (r *UserRepository) CreateMFASession(c context.Context, session *uModel.MFASession) error {
-	return r.db.WithContext(c).Create(session).Error
+	return dbtx.DB(c, r.db).Create(session).Error
 }
 
 // GetMFASession retrieves an MFA session by token
 func (r *UserRepository) GetMFASession(c context.Context, sessionToken string) (*uModel.MFASession, error) {
 	var session uModel.MFASession
-	if err := r.db.WithContext(c).Where("session_token = ? AND expires_at > ?", sessionToken, time.Now()).First(&session).Error; err != nil {
+	if err := dbtx.DB(c, r.db).Where("session_token = ? AND expires_at > ?", sessionToken, time.Now()).First(&session).Error; err != nil {
 		return nil, err
 	}
 	return &session, nil
@@ -293,15 +469,58 @@ func (r *UserRepository) GetMFASession(c context.Context, sessionToken string) (
 
 // UpdateMFASession updates an MFA session
 func (r *UserRepository) UpdateMFASession(c context.Context, session *uModel.MFASession) error {
-	return r.db.WithContext(c).Save(session).Error
+	return dbtx.DB(c, r.db).Save(session).Error
 }
 
 // DeleteMFASession deletes an MFA session
 func (r *UserRepository) DeleteMFASession(c context.Context, sessionToken string) error {
-	return r.db.WithContext(c).Where("session_token = ?", sessionToken).Delete(&uModel.MFASession{}).Error
+	return dbtx.DB(c, r.db).Where("session_token = ?", sessionToken).Delete(&uModel.MFASession{}).Error
 }
 
 // CleanupExpiredMFASessions removes expired MFA sessions
 func (r *UserRepository) CleanupExpiredMFASessions(c context.Context) error {
-	return r.db.WithContext(c).Where("expires_at < ?", time.Now()).Delete(&uModel.MFASession{}).Error
+	return dbtx.DB(c, r.db).Where("expires_at < ?", time.Now()).Delete(&uModel.MFASession{}).Error
+}
+
+// SavePushSubscription stores the subscription, a browser that subscribes again with the same
+// endpoint takes it over with its new keys
+func (r *UserRepository) SavePushSubscription(c context.Context, subscription *uModel.PushSubscription) error {
+	return dbtx.DB(c, r.db).Transaction(func(tx *gorm.DB) error {
+		var existing uModel.PushSubscription
+		err := tx.Where("endpoint = ?", subscription.Endpoint).First(&existing).Error
+		if err == nil {
+			subscription.ID = existing.ID
+			return tx.Model(&existing).Updates(map[string]interface{}{
+				"user_id":    subscription.UserID,
+				"p256dh":     subscription.P256dh,
+				"auth":       subscription.Auth,
+				"user_agent": subscription.UserAgent,
+			}).Error
+		}
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+		return tx.Create(subscription).Error
+	})
+}
+
+func (r *UserRepository) GetPushSubscriptions(c context.Context, userID int) ([]*uModel.PushSubscription, error) {
+	var subscriptions []*uModel.PushSubscription
+	if err := dbtx.DB(c, r.db).Where("user_id = ?", userID).Order("created_at desc").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+func (r *UserRepository) DeletePushSubscription(c context.Context, userID int, subscriptionID int) error {
+	return dbtx.DB(c, r.db).Where("id = ? AND user_id = ?", subscriptionID, userID).Delete(&uModel.PushSubscription{}).Error
+}
+
+// DeletePushSubscriptionByEndpoint removes a subscription the push service reported as expired
+func (r *UserRepository) DeletePushSubscriptionByEndpoint(c context.Context, endpoint string) error {
+	return dbtx.DB(c, r.db).Where("endpoint = ?", endpoint).Delete(&uModel.PushSubscription{}).Error
+}
+
+func (r *UserRepository) TouchPushSubscription(c context.Context, subscriptionID int) error {
+	return dbtx.DB(c, r.db).Model(&uModel.PushSubscription{}).Where("id = ?", subscriptionID).Update("last_used_at", time.Now().UTC()).Error
 }
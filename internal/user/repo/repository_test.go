@@ -0,0 +1,66 @@
+package user
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"donetick.com/core/config"
+	cModel "donetick.com/core/internal/circle/model"
+	nModel "donetick.com/core/internal/notifier/model"
+	uModel "donetick.com/core/internal/user/model"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestGetUserByTelegramChatIDOnlyResolvesLinkedChats(t *testing.T) {
+	ctx := context.Background()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "users.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&uModel.User{}, &uModel.NotificationTarget{}, &cModel.Circle{}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewUserRepository(db, &config.Config{})
+
+	owner := &uModel.User{Username: "owner", Email: "owner@example.com"}
+	other := &uModel.User{Username: "other", Email: "other@example.com", ChatID: 42}
+	for _, user := range []*uModel.User{owner, other} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// another member registers the owner's chat as their own target, and as their chat id in the settings
+	if err := db.Create(&uModel.NotificationTarget{UserID: other.ID, Type: nModel.NotificationPlatformTelegram, TargetID: "42", Enabled: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	user, err := repo.GetUserByTelegramChatID(ctx, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user != nil {
+		t.Fatalf("GetUserByTelegramChatID() = user %d for a chat nobody linked, want none", user.ID)
+	}
+
+	if err := repo.LinkTelegramChat(ctx, owner.ID, 42); err != nil {
+		t.Fatal(err)
+	}
+	user, err = repo.GetUserByTelegramChatID(ctx, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user == nil || user.ID != owner.ID {
+		t.Fatalf("GetUserByTelegramChatID() = %v, want the owner who linked the chat", user)
+	}
+	var unlinked uModel.User
+	if err := db.First(&unlinked, other.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if unlinked.ChatID != 0 || unlinked.TelegramLinked {
+		t.Errorf("the other member keeps chat %d linked %v after the owner linked it", unlinked.ChatID, unlinked.TelegramLinked)
+	}
+}
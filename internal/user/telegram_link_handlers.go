@@ -0,0 +1,66 @@
+package user
+
+import (
+	"crypto/rand"
+	"math/big"
+	"net/http"
+	"time"
+
+	auth "donetick.com/core/internal/authorization"
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	telegramLinkCodeLength = 8
+	telegramLinkCodeTTL    = 15 * time.Minute
+	// no 0/O or 1/I so the code survives being typed from the screen
+	telegramLinkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+)
+
+func generateTelegramLinkCode() (string, error) {
+	code := make([]byte, telegramLinkCodeLength)
+	max := big.NewInt(int64(len(telegramLinkCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = telegramLinkCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// createTelegramLinkCode returns a one time code the user sends to the bot with /link to link
+// their Telegram chat, a new code replaces the previous one
+func (h *Handler) createTelegramLinkCode(c *gin.Context) {
+	currentUser, ok := auth.CurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get current user"})
+		return
+	}
+
+	code, err := generateTelegramLinkCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate link code"})
+		return
+	}
+	now := time.Now().UTC()
+	linkCode := &uModel.TelegramLinkCode{
+		Code:      code,
+		UserID:    currentUser.ID,
+		ExpiresAt: now.Add(telegramLinkCodeTTL),
+		CreatedAt: now,
+	}
+	if err := h.userRepo.CreateTelegramLinkCode(c, linkCode); err != nil {
+		logging.FromContext(c).Errorw("Failed to create telegram link code", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create link code"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"res": gin.H{
+		"code":      linkCode.Code,
+		"expiresAt": linkCode.ExpiresAt,
+		"command":   "/link " + linkCode.Code,
+	}})
+}
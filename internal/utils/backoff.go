@@ -0,0 +1,16 @@
+package utils
+
+import "time"
+
+// RetryBackoff returns the delay before the next attempt after the given number of failed ones, it starts
+// at base and doubles after every attempt, capped at max
+func RetryBackoff(attempts int, base time.Duration, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+	return delay
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		0:  time.Minute,
+		1:  time.Minute,
+		2:  2 * time.Minute,
+		3:  4 * time.Minute,
+		5:  16 * time.Minute,
+		9:  256 * time.Minute,
+		10: 6 * time.Hour,
+		50: 6 * time.Hour,
+	}
+	for attempts, want := range tests {
+		if got := RetryBackoff(attempts, time.Minute, 6*time.Hour); got != want {
+			t.Errorf("RetryBackoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 
 	crand "crypto/rand"
 
@@ -26,3 +27,12 @@ func GenerateInviteCode(c *gin.Context) string {
 
 	return token
 }
+
+// GenerateWebhookSecret returns a new secret webhook deliveries are signed with
+func GenerateWebhookSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := crand.Read(key); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
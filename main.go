@@ -24,6 +24,7 @@ import (
 	"donetick.com/core/internal/database"
 	"donetick.com/core/internal/email"
 	"donetick.com/core/internal/events"
+	evRepo "donetick.com/core/internal/events/repo"
 	label "donetick.com/core/internal/label"
 	lRepo "donetick.com/core/internal/label/repo"
 	"donetick.com/core/internal/mfa"
@@ -36,8 +37,16 @@ import (
 	nRepo "donetick.com/core/internal/notifier/repo"
 	nps "donetick.com/core/internal/notifier/service"
 	discord "donetick.com/core/internal/notifier/service/discord"
+	emailNotifier "donetick.com/core/internal/notifier/service/email"
+	"donetick.com/core/internal/notifier/service/gotify"
+	"donetick.com/core/internal/notifier/service/matrix"
+	"donetick.com/core/internal/notifier/service/ntfy"
 	"donetick.com/core/internal/notifier/service/pushover"
+	"donetick.com/core/internal/notifier/service/slack"
 	telegram "donetick.com/core/internal/notifier/service/telegram"
+	"donetick.com/core/internal/notifier/service/webhook"
+	"donetick.com/core/internal/notifier/service/webpush"
+	"donetick.com/core/internal/notifier/templates"
 	pRepo "donetick.com/core/internal/points/repo"
 	"donetick.com/core/internal/points"
 	"donetick.com/core/internal/realtime"
@@ -74,21 +83,37 @@ func main() {
 		// fx.Provide(NewBot),
 		fx.Provide(database.NewDatabase),
 		fx.Provide(chRepo.NewChoreRepository),
+		fx.Provide(fx.Annotate(chore.NewActions, fx.As(fx.Self()), fx.As(new(telegram.ChoreActions)))),
 		fx.Provide(chore.NewHandler),
+		fx.Provide(chore.NewEscalationScheduler),
 		fx.Provide(uRepo.NewUserRepository),
 		fx.Provide(user.NewHandler),
 		fx.Provide(cRepo.NewCircleRepository),
 		fx.Provide(circle.NewHandler),
 
 		fx.Provide(nRepo.NewNotificationRepository),
+		fx.Provide(evRepo.NewWebhookRepository),
+		fx.Provide(templates.NewRenderer),
+		fx.Provide(nps.NewInbox),
 		fx.Provide(nps.NewNotificationPlanner),
 
 		// add notifier
-		fx.Provide(pushover.NewPushover),
-		fx.Provide(telegram.NewTelegramNotifier),
-		fx.Provide(discord.NewDiscordNotifier),
+		fx.Provide(asNotificationProvider(pushover.NewPushover)),
+		fx.Provide(asNotificationProvider(telegram.NewTelegramNotifier)),
+		fx.Provide(asNotificationProvider(discord.NewDiscordNotifier)),
+		fx.Provide(asNotificationProvider(emailNotifier.NewEmailNotifier)),
+		fx.Provide(asNotificationProvider(ntfy.NewNtfyNotifier)),
+		fx.Provide(asNotificationProvider(gotify.NewGotifyNotifier)),
+		fx.Provide(asNotificationProvider(matrix.NewMatrixNotifier)),
+		fx.Provide(asNotificationProvider(slack.NewSlackNotifier)),
+		fx.Provide(asNotificationProvider(webhook.NewWebhookNotifier)),
+		fx.Provide(webpush.NewVAPIDKeys),
+		fx.Provide(asNotificationProvider(webpush.NewWebPushNotifier)),
+		fx.Provide(fx.Annotate(nps.NewProviderRegistry, fx.ParamTags(``, `group:"notification_providers"`))),
 		fx.Provide(notifier.NewNotifier),
+		fx.Provide(notifier.NewHandler),
 		fx.Provide(events.NewEventsProducer),
+		fx.Provide(events.NewHandler),
 
 		// Rate limiter
 		fx.Provide(utils.NewRateLimiter),
@@ -154,6 +179,8 @@ func main() {
 			frontend.Routes,
 			resource.Routes,
 			rewards.Routes,
+			notifier.Routes,
+			events.Routes,
 
 			realtime.Routes, //(router, rts, authMiddleware, pollingHandler)
 
@@ -169,7 +196,13 @@ func main() {
 
 }
 
-func newServer(lc fx.Lifecycle, cfg *config.Config, db *gorm.DB, notifier *notifier.Scheduler, eventProducer *events.EventsProducer, mfaCleanup *mfa.CleanupService, rts *realtime.RealTimeService) *gin.Engine {
+// asNotificationProvider adds a provider constructor to the group consumed by the provider registry,
+// constructors return an unconfigured provider instead of a nil pointer, which the group would hold as a non-nil interface
+func asNotificationProvider(constructor interface{}) interface{} {
+	return fx.Annotate(constructor, fx.As(new(nps.Provider)), fx.ResultTags(`group:"notification_providers"`))
+}
+
+func newServer(lc fx.Lifecycle, cfg *config.Config, db *gorm.DB, notifier *notifier.Scheduler, eventProducer *events.EventsProducer, mfaCleanup *mfa.CleanupService, escalations *chore.EscalationScheduler, rts *realtime.RealTimeService) *gin.Engine {
 	// Set Gin mode based on logging configuration
 	if cfg.Logging.Development || strings.ToLower(cfg.Logging.Level) == "debug" {
 		gin.SetMode(gin.DebugMode)
@@ -225,6 +258,7 @@ func newServer(lc fx.Lifecycle, cfg *config.Config, db *gorm.DB, notifier *notif
 			notifier.Start(context.Background())
 			eventProducer.Start(context.Background())
 			mfaCleanup.Start(context.Background())
+			escalations.Start(context.Background())
 
 			// Start real-time service
 			if err := rts.Start(ctx); err != nil {
@@ -255,6 +289,7 @@ func newServer(lc fx.Lifecycle, cfg *config.Config, db *gorm.DB, notifier *notif
 			}
 
 			mfaCleanup.Stop()
+			eventProducer.Stop()
 
 			// Shutdown HTTP server with timeout
 			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -0,0 +1,93 @@
+package migrations
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"donetick.com/core/logging"
+	"gorm.io/gorm"
+)
+
+type MigrateBackfillFrequencyMetadataTimezone20261016 struct{}
+
+func (m MigrateBackfillFrequencyMetadataTimezone20261016) ID() string {
+	return "20261016_backfill_frequency_metadata_timezone"
+}
+
+func (m MigrateBackfillFrequencyMetadataTimezone20261016) Description() string {
+	return `Backfill frequency_meta_v2 timezone from the chore assignee's timezone, falling back to the creator's, so recurrence is calculated in local time.`
+}
+
+func (m MigrateBackfillFrequencyMetadataTimezone20261016) Down(ctx context.Context, db *gorm.DB) error {
+	// No-op: the timezone is only a hint for scheduling, leaving it in place is harmless
+	return nil
+}
+
+func (m MigrateBackfillFrequencyMetadataTimezone20261016) Up(ctx context.Context, db *gorm.DB) error {
+	log := logging.FromContext(ctx)
+
+	type Chore struct {
+		ID               int     `gorm:"column:id;primary_key"`
+		CreatedBy        int     `gorm:"column:created_by"`
+		FrequencyMetaV2  *string `gorm:"column:frequency_meta_v2"`
+		AssigneeTimezone *string `gorm:"column:assignee_timezone"`
+		CreatorTimezone  *string `gorm:"column:creator_timezone"`
+	}
+
+	var chores []Chore
+	if err := db.Table("chores").
+		Select("chores.id, chores.created_by, chores.frequency_meta_v2, assignees.timezone AS assignee_timezone, creators.timezone AS creator_timezone").
+		Joins("LEFT JOIN users AS assignees ON assignees.id = chores.assigned_to").
+		Joins("LEFT JOIN users AS creators ON creators.id = chores.created_by").
+		Find(&chores).Error; err != nil {
+		log.Errorf("Failed to fetch chores: %v", err)
+		return err
+	}
+
+	for _, chore := range chores {
+		// Same order as the scheduler's fallback: the assignee's timezone, then the creator's
+		timezone := ""
+		for _, candidate := range []*string{chore.AssigneeTimezone, chore.CreatorTimezone} {
+			if candidate == nil || *candidate == "" {
+				continue
+			}
+			if _, err := time.LoadLocation(*candidate); err != nil {
+				log.Warnf("Chore %d: user has invalid timezone %q, skipping it", chore.ID, *candidate)
+				continue
+			}
+			timezone = *candidate
+			break
+		}
+		if timezone == "" {
+			continue
+		}
+
+		meta := map[string]interface{}{}
+		if chore.FrequencyMetaV2 != nil && *chore.FrequencyMetaV2 != "" && *chore.FrequencyMetaV2 != "null" {
+			if err := json.Unmarshal([]byte(*chore.FrequencyMetaV2), &meta); err != nil {
+				log.Warnf("Chore %d: failed to parse frequency_meta_v2: %v", chore.ID, err)
+				continue
+			}
+		}
+		if tz, ok := meta["timezone"].(string); ok && tz != "" {
+			continue
+		}
+		meta["timezone"] = timezone
+
+		newMetaBytes, err := json.Marshal(meta)
+		if err != nil {
+			log.Warnf("Chore %d: failed to marshal new frequency_meta_v2: %v", chore.ID, err)
+			continue
+		}
+		if err := db.Table("chores").Where("id = ?", chore.ID).Update("frequency_meta_v2", string(newMetaBytes)).Error; err != nil {
+			log.Warnf("Chore %d: failed to update frequency_meta_v2: %v", chore.ID, err)
+			continue
+		}
+	}
+	return nil
+}
+
+func init() {
+	Register(MigrateBackfillFrequencyMetadataTimezone20261016{})
+}
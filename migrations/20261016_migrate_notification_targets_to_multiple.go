@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"context"
+	"time"
+
+	uModel "donetick.com/core/internal/user/model"
+	"donetick.com/core/logging"
+	"gorm.io/gorm"
+)
+
+type MigrateNotificationTargetsToMultiple20261016 struct{}
+
+func (m MigrateNotificationTargetsToMultiple20261016) ID() string {
+	return "20261016_migrate_notification_targets_to_multiple"
+}
+
+func (m MigrateNotificationTargetsToMultiple20261016) Description() string {
+	return `Copy the single user notification target into notification_targets, which allows several targets per user with event filters`
+}
+
+func (m MigrateNotificationTargetsToMultiple20261016) Down(ctx context.Context, db *gorm.DB) error {
+	// No-op: user_notification_targets is left untouched
+	return nil
+}
+
+func (m MigrateNotificationTargetsToMultiple20261016) Up(ctx context.Context, db *gorm.DB) error {
+	log := logging.FromContext(ctx)
+
+	if err := db.AutoMigrate(&uModel.NotificationTarget{}); err != nil {
+		log.Errorf("Failed to create notification_targets table: %v", err)
+		return err
+	}
+
+	var legacyTargets []uModel.UserNotificationTarget
+	if err := db.Table("user_notification_targets").Where("type <> 0").Find(&legacyTargets).Error; err != nil {
+		log.Errorf("Failed to fetch user notification targets: %v", err)
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UTC()
+		for _, legacy := range legacyTargets {
+			var count int64
+			if err := tx.Model(&uModel.NotificationTarget{}).Where("user_id = ?", legacy.UserID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			// an empty event filter keeps the old behaviour of sending every event
+			if err := tx.Create(&uModel.NotificationTarget{
+				UserID:    legacy.UserID,
+				Type:      legacy.Type,
+				TargetID:  legacy.TargetID,
+				Enabled:   true,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}).Error; err != nil {
+				log.Errorf("Failed to migrate notification target for user %d: %v", legacy.UserID, err)
+				return err
+			}
+		}
+		log.Infof("Migrated %d notification targets", len(legacyTargets))
+		return nil
+	})
+}
+
+// Register this migration
+func init() {
+	Register(MigrateNotificationTargetsToMultiple20261016{})
+}